@@ -0,0 +1,121 @@
+package attachments
+
+import (
+	"budget-tracker-api/models"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+
+	log "github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MaxSize defines the maximum size of attachments files
+const MaxSize = 10 << 20
+
+var (
+	// ErrTooLarge is returned when an attachment file exceeds MaxSize
+	ErrTooLarge = errors.New("attachments must have at most 10MB")
+
+	// ErrUnsupportedType is returned when an attachment file isn't an image or a PDF
+	ErrUnsupportedType = errors.New("attachments must be JPEG, PNG, WebP or GIF images or PDF documents")
+)
+
+// allowedTypes defines the MIME types of attachments files, as detected from their contents
+var allowedTypes = map[string]bool{
+	"image/jpeg":      true,
+	"image/png":       true,
+	"image/webp":      true,
+	"image/gif":       true,
+	"application/pdf": true,
+}
+
+// Upload will store a file attached to a spend and register it. The file type is detected from its
+// contents, regardless of its name or declared type
+func Upload(ctx context.Context, spend models.Spend, filename string, r io.Reader, size int64) (attachment models.Attachment, err error) {
+	if size > MaxSize {
+		return attachment, ErrTooLarge
+	}
+
+	head := make([]byte, 512)
+	n, err := io.ReadFull(r, head)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return attachment, err
+	}
+	head = head[:n]
+
+	contentType, _, _ := mime.ParseMediaType(http.DetectContentType(head))
+	if !allowedTypes[contentType] {
+		return attachment, ErrUnsupportedType
+	}
+
+	attachment = models.Attachment{
+		ID:          primitive.NewObjectID(),
+		OwnerID:     spend.OwnerID,
+		SpendID:     spend.ID,
+		Filename:    filename,
+		ContentType: contentType,
+		Size:        size,
+	}
+	attachment.Key = spend.OwnerID.Hex() + "/" + spend.ID.Hex() + "/" + attachment.ID.Hex()
+
+	err = store.Put(ctx, attachment.Key, io.MultiReader(bytes.NewReader(head), r), size, contentType)
+	if err != nil {
+		return attachment, err
+	}
+
+	_, err = models.CreateAttachment(ctx, attachment)
+	if err != nil {
+		deleteFile(ctx, attachment)
+		return attachment, err
+	}
+
+	return attachment, nil
+}
+
+// Open will open the file of an attachment
+func Open(ctx context.Context, attachment models.Attachment) (io.ReadCloser, error) {
+	return store.Get(ctx, attachment.Key)
+}
+
+// deleteFile will remove the file of an attachment. Failures only leave an orphan file behind, so they
+// are logged instead of failing the deletion
+func deleteFile(ctx context.Context, attachment models.Attachment) {
+	err := store.Delete(ctx, attachment.Key)
+	if err != nil {
+		log.Errorln("could not delete file of attachment", attachment.ID.Hex(), ":", err)
+	}
+}
+
+// Remove will delete an attachment given an ID along with its file
+func Remove(ctx context.Context, id string) (err error) {
+	attachment, err := models.DeleteAttachment(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	deleteFile(ctx, *attachment)
+	return nil
+}
+
+// RemoveSpends will delete all attachments from deleted spends along with their files
+func RemoveSpends(ctx context.Context, spends []models.Spend) (err error) {
+	ids := []primitive.ObjectID{}
+	for _, s := range spends {
+		ids = append(ids, s.ID)
+	}
+
+	deleted, err := models.DeleteSpendsAttachments(ctx, ids)
+	if err != nil {
+		return err
+	}
+
+	for _, attachment := range deleted {
+		deleteFile(ctx, attachment)
+	}
+
+	return nil
+}
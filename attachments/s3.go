@@ -0,0 +1,181 @@
+package attachments
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// S3 stores attachments files at a bucket from S3 or any S3-compatible service, such as MinIO. Objects
+// are addressed path-style and requests are signed with AWS Signature Version 4
+type S3 struct {
+	// example: https://s3.us-east-1.amazonaws.com or http://minio:9000
+	Endpoint  string
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	Client    *http.Client
+}
+
+// s3Escape will URI encode a path segment as required by signatures
+func s3Escape(segment string) string {
+	var b strings.Builder
+	for i := 0; i < len(segment); i++ {
+		c := segment[i]
+		if (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') || c == '-' || c == '_' || c == '.' || c == '~' {
+			b.WriteByte(c)
+			continue
+		}
+		b.WriteString("%" + strings.ToUpper(hex.EncodeToString([]byte{c})))
+	}
+	return b.String()
+}
+
+// hmacSHA256 will return the HMAC-SHA256 of a message
+func hmacSHA256(key []byte, message string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(message))
+	return h.Sum(nil)
+}
+
+// request will build a signed request to an object. Payloads aren't signed, which S3 allows through
+// the UNSIGNED-PAYLOAD content hash
+func (s S3) request(ctx context.Context, method string, key string, body io.Reader) (*http.Request, error) {
+	endpoint, err := url.Parse(s.Endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	segments := []string{s3Escape(s.Bucket)}
+	for _, segment := range strings.Split(key, "/") {
+		segments = append(segments, s3Escape(segment))
+	}
+	path := strings.TrimSuffix(endpoint.Path, "/") + "/" + strings.Join(segments, "/")
+
+	request, err := http.NewRequestWithContext(ctx, method, endpoint.Scheme+"://"+endpoint.Host+path, body)
+	if err != nil {
+		return nil, err
+	}
+
+	region := s.Region
+	if region == "" {
+		region = "us-east-1"
+	}
+
+	now := time.Now().UTC()
+	amzDate := now.Format("20060102T150405Z")
+	scope := now.Format("20060102") + "/" + region + "/s3/aws4_request"
+
+	request.Header.Set("x-amz-date", amzDate)
+	request.Header.Set("x-amz-content-sha256", "UNSIGNED-PAYLOAD")
+
+	canonical := strings.Join([]string{
+		method,
+		path,
+		"",
+		"host:" + request.URL.Host,
+		"x-amz-content-sha256:UNSIGNED-PAYLOAD",
+		"x-amz-date:" + amzDate,
+		"",
+		"host;x-amz-content-sha256;x-amz-date",
+		"UNSIGNED-PAYLOAD",
+	}, "\n")
+
+	hash := sha256.Sum256([]byte(canonical))
+	toSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(hash[:])
+
+	signingKey := []byte("AWS4" + s.SecretKey)
+	for _, part := range strings.Split(scope, "/") {
+		signingKey = hmacSHA256(signingKey, part)
+	}
+
+	request.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential="+s.AccessKey+"/"+scope+
+		", SignedHeaders=host;x-amz-content-sha256;x-amz-date, Signature="+hex.EncodeToString(hmacSHA256(signingKey, toSign)))
+	return request, nil
+}
+
+// do will send a request, turning unexpected responses into errors
+func (s S3) do(request *http.Request) (*http.Response, error) {
+	client := s.Client
+	if client == nil {
+		client = &http.Client{Timeout: 60 * time.Second}
+	}
+
+	response, err := client.Do(request)
+	if err != nil {
+		return nil, err
+	}
+
+	if response.StatusCode == http.StatusNotFound {
+		response.Body.Close()
+		return nil, ErrNotFound
+	}
+
+	if response.StatusCode >= 300 {
+		details, _ := ioutil.ReadAll(io.LimitReader(response.Body, 1024))
+		response.Body.Close()
+		return nil, errors.New("storage responded " + response.Status + ": " + string(details))
+	}
+
+	return response, nil
+}
+
+// Put will upload an object
+func (s S3) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	request, err := s.request(ctx, http.MethodPut, key, r)
+	if err != nil {
+		return err
+	}
+
+	request.ContentLength = size
+	request.Header.Set("Content-Type", contentType)
+
+	response, err := s.do(request)
+	if err != nil {
+		return err
+	}
+
+	return response.Body.Close()
+}
+
+// Get will download an object
+func (s S3) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	request, err := s.request(ctx, http.MethodGet, key, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := s.do(request)
+	if err != nil {
+		return nil, err
+	}
+
+	return response.Body, nil
+}
+
+// Delete will remove an object, objects already removed are ignored
+func (s S3) Delete(ctx context.Context, key string) error {
+	request, err := s.request(ctx, http.MethodDelete, key, nil)
+	if err != nil {
+		return err
+	}
+
+	response, err := s.do(request)
+	if err == ErrNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	return response.Body.Close()
+}
@@ -0,0 +1,167 @@
+package attachments
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestS3Escape(t *testing.T) {
+	tests := []struct {
+		segment string
+		want    string
+	}{
+		{"receipt-1_final.v2~", "receipt-1_final.v2~"},
+		{"receipt 1.jpg", "receipt%201.jpg"},
+		{"a+b=c", "a%2Bb%3Dc"},
+		{"recibo-ção.pdf", "recibo-%C3%A7%C3%A3o.pdf"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.segment, func(t *testing.T) {
+			if got := s3Escape(tt.segment); got != tt.want {
+				t.Errorf("s3Escape(%q) = %q, want %q", tt.segment, got, tt.want)
+			}
+		})
+	}
+}
+
+// authorizationPattern matches the SigV4 authorization header sent by S3 requests
+var authorizationPattern = regexp.MustCompile(`^AWS4-HMAC-SHA256 Credential=([^/]+)/(\d{8})/([^/]+)/s3/aws4_request, SignedHeaders=([^,]+), Signature=([0-9a-f]{64})$`)
+
+// verifySignature will check the SigV4 signature of a request as received by the server, rebuilding its
+// canonical request independently from the signing code
+func verifySignature(r *http.Request, accessKey string, secretKey string, region string) string {
+	m := authorizationPattern.FindStringSubmatch(r.Header.Get("Authorization"))
+	if m == nil {
+		return "malformed authorization header '" + r.Header.Get("Authorization") + "'"
+	}
+
+	if m[1] != accessKey || m[3] != region {
+		return "unexpected credential " + m[1] + " at " + m[3]
+	}
+
+	amzDate := r.Header.Get("x-amz-date")
+	if _, err := time.Parse("20060102T150405Z", amzDate); err != nil || !strings.HasPrefix(amzDate, m[2]) {
+		return "x-amz-date '" + amzDate + "' doesn't match the credential date " + m[2]
+	}
+
+	headers := []string{}
+	for _, name := range strings.Split(m[4], ";") {
+		value := r.Header.Get(name)
+		if name == "host" {
+			value = r.Host
+		}
+		headers = append(headers, name+":"+strings.TrimSpace(value))
+	}
+
+	canonical := r.Method + "\n" + r.URL.EscapedPath() + "\n" + r.URL.RawQuery + "\n" +
+		strings.Join(headers, "\n") + "\n\n" + m[4] + "\n" + r.Header.Get("x-amz-content-sha256")
+	hash := sha256.Sum256([]byte(canonical))
+	scope := m[2] + "/" + region + "/s3/aws4_request"
+	toSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(hash[:])
+
+	key := []byte("AWS4" + secretKey)
+	for _, part := range []string{m[2], region, "s3", "aws4_request"} {
+		h := hmac.New(sha256.New, key)
+		h.Write([]byte(part))
+		key = h.Sum(nil)
+	}
+
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(toSign))
+	if want := hex.EncodeToString(h.Sum(nil)); m[5] != want {
+		return "signature " + m[5] + " doesn't match " + want + " for canonical request:\n" + canonical
+	}
+
+	return ""
+}
+
+func TestS3(t *testing.T) {
+	ctx := context.Background()
+
+	// the server stands in for a MinIO bucket, keeping objects in memory
+	var mu sync.Mutex
+	objects := map[string]string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if problem := verifySignature(r, "access", "secret", "sa-east-1"); problem != "" {
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(problem))
+			return
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		path := r.URL.EscapedPath()
+		switch r.Method {
+		case http.MethodPut:
+			data, _ := ioutil.ReadAll(r.Body)
+			objects[path] = r.Header.Get("Content-Type") + ":" + string(data)
+		case http.MethodGet:
+			object, ok := objects[path]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Write([]byte(object))
+		case http.MethodDelete:
+			if _, ok := objects[path]; !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			delete(objects, path)
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer server.Close()
+
+	s := S3{Endpoint: server.URL + "/storage/", Region: "sa-east-1", Bucket: "receipts", AccessKey: "access", SecretKey: "secret", Client: server.Client()}
+	key := "owner/spend/receipt 1.pdf"
+
+	err := s.Put(ctx, key, strings.NewReader("receipt"), 7, "application/pdf")
+	if err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+
+	if _, ok := objects["/storage/receipts/owner/spend/receipt%201.pdf"]; !ok {
+		t.Fatalf("Put() stored %v, want a path-style escaped key", objects)
+	}
+
+	body, err := s.Get(ctx, key)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	data, _ := ioutil.ReadAll(body)
+	body.Close()
+	if string(data) != "application/pdf:receipt" {
+		t.Errorf("Get() = %q, want %q", data, "application/pdf:receipt")
+	}
+
+	for i := 0; i < 2; i++ {
+		err = s.Delete(ctx, key)
+		if err != nil {
+			t.Fatalf("Delete() #%d error = %v", i+1, err)
+		}
+	}
+
+	_, err = s.Get(ctx, key)
+	if err != ErrNotFound {
+		t.Errorf("Get() of a deleted object error = %v, want %v", err, ErrNotFound)
+	}
+
+	s.SecretKey = "wrong"
+	err = s.Put(ctx, key, strings.NewReader("receipt"), 7, "application/pdf")
+	if err == nil || !strings.Contains(err.Error(), "403") {
+		t.Errorf("Put() with a wrong secret error = %v, want a 403 response", err)
+	}
+}
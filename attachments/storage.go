@@ -0,0 +1,101 @@
+package attachments
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ErrNotFound is returned when a stored file doesn't exist
+var ErrNotFound = errors.New("non existent file")
+
+// Storage defines where attachments files are kept, addressed by slash separated keys
+type Storage interface {
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// store is the storage used by attachments, the local filesystem until Init is called
+var store Storage = FileSystem{Root: "attachments"}
+
+// Init will set the storage used by attachments
+func Init(s Storage) {
+	store = s
+}
+
+// FileSystem stores attachments files under a local directory
+type FileSystem struct {
+	Root string
+}
+
+// path will return the file path of a key, refusing keys leading out of the root directory
+func (f FileSystem) path(key string) (string, error) {
+	root := filepath.Clean(f.Root)
+	path := filepath.Join(root, filepath.FromSlash(key))
+	if !strings.HasPrefix(path, root+string(filepath.Separator)) {
+		return "", errors.New("invalid key '" + key + "'")
+	}
+	return path, nil
+}
+
+// Put will write a file, replacing it only once it is completely written
+func (f FileSystem) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	path, err := f.path(key)
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(path), 0750)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(tmp, r)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+// Get will open a file
+func (f FileSystem) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	path, err := f.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, ErrNotFound
+	}
+	return file, err
+}
+
+// Delete will remove a file, files already removed are ignored
+func (f FileSystem) Delete(ctx context.Context, key string) error {
+	path, err := f.path(key)
+	if err != nil {
+		return err
+	}
+
+	err = os.Remove(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,78 @@
+package attachments
+
+import (
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFileSystemPath(t *testing.T) {
+	root := filepath.Join("data", "attachments")
+
+	tests := []struct {
+		name    string
+		key     string
+		want    string
+		wantErr bool
+	}{
+		{"nested key", "owner/spend/receipt.jpg", filepath.Join(root, "owner", "spend", "receipt.jpg"), false},
+		{"dots inside the root", "owner/../spend/receipt.jpg", filepath.Join(root, "spend", "receipt.jpg"), false},
+		{"leading slash", "/owner/receipt.jpg", filepath.Join(root, "owner", "receipt.jpg"), false},
+		{"parent directory", "../receipt.jpg", "", true},
+		{"nested parent directory", "owner/../../receipt.jpg", "", true},
+		{"sibling with root prefix", "../attachments-other/receipt.jpg", "", true},
+		{"root itself", "", "", true},
+		{"dot", ".", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := FileSystem{Root: root}.path(tt.key)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("path(%q) error = %v, wantErr %v", tt.key, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("path(%q) = %q, want %q", tt.key, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFileSystem(t *testing.T) {
+	ctx := context.Background()
+	f := FileSystem{Root: t.TempDir()}
+
+	err := f.Put(ctx, "owner/spend/receipt.pdf", strings.NewReader("receipt"), 7, "application/pdf")
+	if err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+
+	file, err := f.Get(ctx, "owner/spend/receipt.pdf")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	data, _ := ioutil.ReadAll(file)
+	file.Close()
+	if string(data) != "receipt" {
+		t.Errorf("Get() = %q, want %q", data, "receipt")
+	}
+
+	err = f.Put(ctx, "../receipt.pdf", strings.NewReader("receipt"), 7, "application/pdf")
+	if err == nil {
+		t.Errorf("Put() of a key out of the root should fail")
+	}
+
+	for i := 0; i < 2; i++ {
+		err = f.Delete(ctx, "owner/spend/receipt.pdf")
+		if err != nil {
+			t.Fatalf("Delete() #%d error = %v", i+1, err)
+		}
+	}
+
+	_, err = f.Get(ctx, "owner/spend/receipt.pdf")
+	if err != ErrNotFound {
+		t.Errorf("Get() of a deleted file error = %v, want %v", err, ErrNotFound)
+	}
+}
@@ -0,0 +1,157 @@
+package controllers
+
+import (
+	"budget-tracker-api/attachments"
+	"budget-tracker-api/models"
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	log "github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// UploadAttachmentEndpoint will attach a file, such as a receipt photo, sent as the 'file' field of a
+// multipart form to a spend
+func UploadAttachmentEndpoint(response http.ResponseWriter, request *http.Request) {
+	response.Header().Add("content-type", "application/json")
+	response.Header().Add("backend", "budget-tracker")
+
+	params := mux.Vars(request)
+	// leaving room for the multipart encoding around the file
+	request.Body = http.MaxBytesReader(response, request.Body, attachments.MaxSize+1<<20)
+
+	spend, err := models.GetSpend(request.Context(), params["id"])
+	if err != nil {
+		response.WriteHeader(http.StatusNotFound)
+		response.Write([]byte(`{"message": "could not attach file", "details": "non existent spend"}`))
+		return
+	}
+
+	file, header, err := request.FormFile("file")
+	if err != nil {
+		response.WriteHeader(http.StatusBadRequest)
+		response.Write([]byte(`{"message": "could not attach file", "details": "missing 'file' field or file larger than 10MB"}`))
+		return
+	}
+
+	defer file.Close()
+
+	attachment, err := attachments.Upload(request.Context(), *spend, header.Filename, file, header.Size)
+	if err == attachments.ErrTooLarge {
+		response.WriteHeader(http.StatusRequestEntityTooLarge)
+		response.Write([]byte(`{"message": "could not attach file", "details": "` + err.Error() + `"}`))
+		return
+	}
+
+	if err == attachments.ErrUnsupportedType {
+		response.WriteHeader(http.StatusUnsupportedMediaType)
+		response.Write([]byte(`{"message": "could not attach file", "details": "` + err.Error() + `"}`))
+		return
+	}
+
+	if err != nil {
+		response.WriteHeader(http.StatusInternalServerError)
+		response.Write([]byte(`{"message": "could not attach file", "details": "` + err.Error() + `"}`))
+		return
+	}
+
+	response.WriteHeader(http.StatusCreated)
+	response.Write([]byte(`{"message": "attached file to spend '` + params["id"] + `'", "id": "` + attachment.ID.Hex() + `"}`))
+}
+
+// GetAttachmentsEndpoint will return the attachments from a spend
+func GetAttachmentsEndpoint(response http.ResponseWriter, request *http.Request) {
+	response.Header().Add("content-type", "application/json")
+	response.Header().Add("backend", "budget-tracker")
+
+	params := mux.Vars(request)
+
+	list, err := models.GetSpendAttachments(request.Context(), params["id"])
+	if err != nil {
+		response.WriteHeader(http.StatusInternalServerError)
+		response.Write([]byte(`{"message": "` + err.Error() + `"}`))
+		return
+	}
+
+	if len(list) == 0 {
+		response.Write([]byte(`[]`))
+		return
+	}
+
+	json.NewEncoder(response).Encode(list)
+}
+
+// DownloadAttachmentEndpoint will stream the file of an attachment given its ID
+func DownloadAttachmentEndpoint(response http.ResponseWriter, request *http.Request) {
+	response.Header().Add("content-type", "application/json")
+	response.Header().Add("backend", "budget-tracker")
+	response.Header().Set("Access-Control-Allow-Origin", "*")
+
+	params := mux.Vars(request)
+
+	attachment, err := models.GetAttachment(request.Context(), params["id"])
+	if err == mongo.ErrNoDocuments {
+		response.WriteHeader(http.StatusNotFound)
+		response.Write([]byte(`{"message": "could not download attachment", "details": "non existent attachment"}`))
+		return
+	}
+
+	if err != nil {
+		response.WriteHeader(http.StatusInternalServerError)
+		response.Write([]byte(`{"message": "could not download attachment", "details": "` + err.Error() + `"}`))
+		return
+	}
+
+	file, err := attachments.Open(request.Context(), *attachment)
+	if err == attachments.ErrNotFound {
+		response.WriteHeader(http.StatusNotFound)
+		response.Write([]byte(`{"message": "could not download attachment", "details": "missing attachment file"}`))
+		return
+	}
+
+	if err != nil {
+		response.WriteHeader(http.StatusInternalServerError)
+		response.Write([]byte(`{"message": "could not download attachment", "details": "` + err.Error() + `"}`))
+		return
+	}
+
+	defer file.Close()
+
+	response.Header().Set("content-type", attachment.ContentType)
+	response.Header().Set("Content-Length", strconv.FormatInt(attachment.Size, 10))
+	response.Header().Set("Content-Disposition", mime.FormatMediaType("inline", map[string]string{"filename": attachment.Filename}))
+	response.WriteHeader(http.StatusOK)
+
+	_, err = io.Copy(response, file)
+	if err != nil {
+		log.Errorln("could not download attachment", params["id"], ":", err)
+	}
+}
+
+// DeleteAttachmentEndpoint will delete an attachment given its ID along with its file
+func DeleteAttachmentEndpoint(response http.ResponseWriter, request *http.Request) {
+	response.Header().Add("content-type", "application/json")
+	response.Header().Add("backend", "budget-tracker")
+
+	params := mux.Vars(request)
+
+	err := attachments.Remove(request.Context(), params["id"])
+	if err == mongo.ErrNoDocuments {
+		response.WriteHeader(http.StatusNotFound)
+		response.Write([]byte(`{"message": "could not delete attachment", "details": "non existent attachment"}`))
+		return
+	}
+
+	if err != nil {
+		response.WriteHeader(http.StatusInternalServerError)
+		response.Write([]byte(`{"message": "could not delete attachment", "details": "` + err.Error() + `"}`))
+		return
+	}
+
+	response.WriteHeader(http.StatusOK)
+	response.Write([]byte(`{"message": "deleted attachment '` + params["id"] + `'"}`))
+}
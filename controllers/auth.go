@@ -50,6 +50,7 @@ func GenerateJWTRefreshToken(sub string) (string, error) {
 // CreateJWTTokenEndpoint creates a token based on user credentials
 func CreateJWTTokenEndpoint(response http.ResponseWriter, request *http.Request) {
 	response.Header().Add("content-type", "application/json")
+	response.Header().Add("backend", "budget-tracker")
 	response.Header().Set("Access-Control-Allow-Origin", "*")
 
 	var jwtUser models.JWTUser
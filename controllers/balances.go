@@ -2,17 +2,23 @@ package controllers
 
 import (
 	"budget-tracker-api/models"
+	"budget-tracker-api/taxes"
 	"encoding/json"
+	"errors"
 	"strconv"
 
 	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 
 	"net/http"
 )
 
-// CreateBalanceEndpoint will create a balance to an user
+// CreateBalanceEndpoint will create a balance to an user. Net salaries not informed are derived from their
+// gross ones
 func CreateBalanceEndpoint(response http.ResponseWriter, request *http.Request) {
 	response.Header().Add("content-type", "application/json")
+	response.Header().Add("backend", "budget-tracker")
 
 	var balance models.Balance
 
@@ -24,6 +30,37 @@ func CreateBalanceEndpoint(response http.ResponseWriter, request *http.Request)
 		return
 	}
 
+	// balances informing only a gross income are handled as a salary
+	if len(balance.Incomes) == 0 && balance.Income.GrossIncome > 0 && balance.Income.NetIncome == 0 {
+		balance.Incomes = []models.IncomeEntry{{Source: "salary", GrossIncome: balance.Income.GrossIncome}}
+		balance.Income = models.Income{}
+	}
+
+	dependents, _ := strconv.Atoi(request.URL.Query().Get("dependents"))
+
+	errs := []models.FieldError{}
+	for i, income := range balance.Incomes {
+		field := "incomes." + strconv.Itoa(i) + "."
+
+		// deriving net salaries from gross ones when only gross was informed
+		if income.Source == "salary" && income.GrossIncome > 0 && income.NetIncome == 0 {
+			result, err := taxes.Calculate(taxes.Input{Gross: income.GrossIncome, Year: int(balance.Year), Dependents: dependents})
+			if err != nil {
+				errs = append(errs, models.FieldError{Field: field + "net", Message: "could not derive net income: " + err.Error()})
+				continue
+			}
+			balance.Incomes[i].NetIncome = result.Net
+			income.NetIncome = result.Net
+		}
+
+		errs = append(errs, models.ValidateIncomeEntry(field, income)...)
+	}
+
+	if len(errs) > 0 {
+		writeValidationErrors(response, "could not create balance", errs)
+		return
+	}
+
 	result, err := models.CreateBalance(request.Context(), balance)
 	if err != nil {
 		response.WriteHeader(http.StatusInternalServerError)
@@ -38,6 +75,7 @@ func CreateBalanceEndpoint(response http.ResponseWriter, request *http.Request)
 // GetBalanceEndpoint will return a balance from a given user
 func GetBalanceEndpoint(response http.ResponseWriter, request *http.Request) {
 	response.Header().Add("content-type", "application/json")
+	response.Header().Add("backend", "budget-tracker")
 
 	v := request.URL.Query()
 
@@ -84,3 +122,266 @@ func GetBalanceEndpoint(response http.ResponseWriter, request *http.Request) {
 		json.NewEncoder(response).Encode(balance)
 	}
 }
+
+// parsePeriodParams will parse the required 'month' and 'year' URL params
+func parsePeriodParams(request *http.Request) (month int64, year int64, err error) {
+	v := request.URL.Query()
+
+	month, err = strconv.ParseInt(v.Get("month"), 10, 64)
+	if err != nil || month < 1 || month > 12 {
+		return 0, 0, errors.New("invalid or missing 'month' param")
+	}
+
+	year, err = strconv.ParseInt(v.Get("year"), 10, 64)
+	if err != nil {
+		return 0, 0, errors.New("invalid or missing 'year' param")
+	}
+
+	return month, year, nil
+}
+
+// SetBudgetsEndpoint will replace the category budgets from a month balance
+func SetBudgetsEndpoint(response http.ResponseWriter, request *http.Request) {
+	response.Header().Add("content-type", "application/json")
+	response.Header().Add("backend", "budget-tracker")
+
+	params := mux.Vars(request)
+
+	month, year, err := parsePeriodParams(request)
+	if err != nil {
+		response.WriteHeader(http.StatusBadRequest)
+		response.Write([]byte(`{"message": "could not set budgets", "details": "` + err.Error() + `"}`))
+		return
+	}
+
+	oid, err := primitive.ObjectIDFromHex(params["owner_id"])
+	if err != nil {
+		response.WriteHeader(http.StatusBadRequest)
+		response.Write([]byte(`{"message": "could not set budgets", "details": "invalid owner ID"}`))
+		return
+	}
+
+	var budgets []models.CategoryBudget
+
+	err = json.NewDecoder(request.Body).Decode(&budgets)
+	if err != nil {
+		response.WriteHeader(http.StatusBadRequest)
+		response.Write([]byte(`{"message": "could not set budgets", "details": "malformed payload"}`))
+		return
+	}
+
+	budgets, errs, err := models.ValidateBudgets(request.Context(), oid, budgets)
+	if err != nil {
+		response.WriteHeader(http.StatusInternalServerError)
+		response.Write([]byte(`{"message": "could not set budgets", "details": "` + err.Error() + `"}`))
+		return
+	}
+
+	if len(errs) > 0 {
+		writeValidationErrors(response, "could not set budgets", errs)
+		return
+	}
+
+	err = models.SetBalanceBudgets(request.Context(), params["owner_id"], month, year, budgets)
+	if err != nil {
+		response.WriteHeader(http.StatusInternalServerError)
+		response.Write([]byte(`{"message": "could not set budgets", "details": "` + err.Error() + `"}`))
+		return
+	}
+
+	response.WriteHeader(http.StatusOK)
+	response.Write([]byte(`{"message": "set budgets to balance ` + strconv.FormatInt(month, 10) + `/` + strconv.FormatInt(year, 10) + `"}`))
+}
+
+// GetBudgetsProgressEndpoint will return how much was spent from each category budget of a month balance
+func GetBudgetsProgressEndpoint(response http.ResponseWriter, request *http.Request) {
+	response.Header().Add("content-type", "application/json")
+	response.Header().Add("backend", "budget-tracker")
+
+	params := mux.Vars(request)
+
+	month, year, err := parsePeriodParams(request)
+	if err != nil {
+		response.WriteHeader(http.StatusBadRequest)
+		response.Write([]byte(`{"message": "could not get budgets progress", "details": "` + err.Error() + `"}`))
+		return
+	}
+
+	progress, err := models.GetBudgetsProgress(request.Context(), params["owner_id"], month, year)
+	if err == mongo.ErrNoDocuments {
+		response.WriteHeader(http.StatusNotFound)
+		response.Write([]byte(`{"message": "could not get budgets progress", "details": "non existent balance"}`))
+		return
+	}
+
+	if err != nil {
+		response.WriteHeader(http.StatusInternalServerError)
+		response.Write([]byte(`{"message": "` + err.Error() + `"}`))
+		return
+	}
+
+	json.NewEncoder(response).Encode(progress)
+}
+
+// CloseBalanceEndpoint will close a month balance, optionally carrying its leftover over to the next month
+func CloseBalanceEndpoint(response http.ResponseWriter, request *http.Request) {
+	response.Header().Add("content-type", "application/json")
+	response.Header().Add("backend", "budget-tracker")
+
+	params := mux.Vars(request)
+
+	month, year, err := parsePeriodParams(request)
+	if err != nil {
+		response.WriteHeader(http.StatusBadRequest)
+		response.Write([]byte(`{"message": "could not close balance", "details": "` + err.Error() + `"}`))
+		return
+	}
+
+	carryOver, _ := strconv.ParseBool(request.URL.Query().Get("carry_over"))
+
+	balance, err := models.CloseBalance(request.Context(), params["owner_id"], month, year, carryOver)
+	if err == mongo.ErrNoDocuments {
+		response.WriteHeader(http.StatusNotFound)
+		response.Write([]byte(`{"message": "could not close balance", "details": "non existent balance"}`))
+		return
+	}
+
+	if err == models.ErrBalanceClosed && balance.ID.IsZero() {
+		response.WriteHeader(http.StatusConflict)
+		response.Write([]byte(`{"message": "could not close balance", "details": "` + err.Error() + `"}`))
+		return
+	}
+
+	if err != nil && balance.ID.IsZero() {
+		response.WriteHeader(http.StatusInternalServerError)
+		response.Write([]byte(`{"message": "could not close balance", "details": "` + err.Error() + `"}`))
+		return
+	}
+
+	// the balance is closed even when its leftover couldn't be carried over
+	if err != nil {
+		response.WriteHeader(http.StatusInternalServerError)
+		response.Write([]byte(`{"message": "closed balance but could not carry leftover over", "details": "` + err.Error() + `"}`))
+		return
+	}
+
+	json.NewEncoder(response).Encode(balance)
+}
+
+// UpdateBalanceEndpoint will update the incomes and currency from a month balance
+func UpdateBalanceEndpoint(response http.ResponseWriter, request *http.Request) {
+	response.Header().Add("content-type", "application/json")
+	response.Header().Add("backend", "budget-tracker")
+
+	params := mux.Vars(request)
+
+	month, year, err := parsePeriodParams(request)
+	if err != nil {
+		response.WriteHeader(http.StatusBadRequest)
+		response.Write([]byte(`{"message": "could not update balance", "details": "` + err.Error() + `"}`))
+		return
+	}
+
+	var update models.BalanceUpdate
+
+	err = json.NewDecoder(request.Body).Decode(&update)
+	if err != nil {
+		response.WriteHeader(http.StatusBadRequest)
+		response.Write([]byte(`{"message": "could not update balance", "details": "malformed payload"}`))
+		return
+	}
+
+	if update.Incomes != nil {
+		errs := []models.FieldError{}
+		for i, income := range *update.Incomes {
+			errs = append(errs, models.ValidateIncomeEntry("incomes."+strconv.Itoa(i)+".", income)...)
+		}
+
+		if len(errs) > 0 {
+			writeValidationErrors(response, "could not update balance", errs)
+			return
+		}
+	}
+
+	err = models.UpdateBalance(request.Context(), params["owner_id"], month, year, update)
+	if err == mongo.ErrNoDocuments {
+		response.WriteHeader(http.StatusNotFound)
+		response.Write([]byte(`{"message": "could not update balance", "details": "non existent balance"}`))
+		return
+	}
+
+	if err == models.ErrBalanceClosed {
+		response.WriteHeader(http.StatusConflict)
+		response.Write([]byte(`{"message": "could not update balance", "details": "` + err.Error() + `"}`))
+		return
+	}
+
+	if err != nil {
+		response.WriteHeader(http.StatusBadRequest)
+		response.Write([]byte(`{"message": "could not update balance", "details": "` + err.Error() + `"}`))
+		return
+	}
+
+	response.WriteHeader(http.StatusOK)
+	response.Write([]byte(`{"message": "updated balance ` + strconv.FormatInt(month, 10) + `/` + strconv.FormatInt(year, 10) + `"}`))
+}
+
+// RecomputeBalanceEndpoint will rebuild a month balance from its spends
+func RecomputeBalanceEndpoint(response http.ResponseWriter, request *http.Request) {
+	response.Header().Add("content-type", "application/json")
+	response.Header().Add("backend", "budget-tracker")
+
+	params := mux.Vars(request)
+
+	month, year, err := parsePeriodParams(request)
+	if err != nil {
+		response.WriteHeader(http.StatusBadRequest)
+		response.Write([]byte(`{"message": "could not recompute balance", "details": "` + err.Error() + `"}`))
+		return
+	}
+
+	balance, err := models.RecomputeBalance(request.Context(), params["owner_id"], month, year)
+	if err == mongo.ErrNoDocuments {
+		response.WriteHeader(http.StatusNotFound)
+		response.Write([]byte(`{"message": "could not recompute balance", "details": "non existent balance"}`))
+		return
+	}
+
+	if err == models.ErrBalanceClosed {
+		response.WriteHeader(http.StatusConflict)
+		response.Write([]byte(`{"message": "could not recompute balance", "details": "` + err.Error() + `"}`))
+		return
+	}
+
+	if err != nil {
+		response.WriteHeader(http.StatusInternalServerError)
+		response.Write([]byte(`{"message": "could not recompute balance", "details": "` + err.Error() + `"}`))
+		return
+	}
+
+	json.NewEncoder(response).Encode(balance)
+}
+
+// GetYearlySummaryEndpoint will summarize the balances from an user during a year
+func GetYearlySummaryEndpoint(response http.ResponseWriter, request *http.Request) {
+	response.Header().Add("content-type", "application/json")
+	response.Header().Add("backend", "budget-tracker")
+
+	params := mux.Vars(request)
+
+	year, err := strconv.ParseInt(request.URL.Query().Get("year"), 10, 64)
+	if err != nil {
+		response.WriteHeader(http.StatusBadRequest)
+		response.Write([]byte(`{"message": "could not get yearly summary", "details": "invalid or missing 'year' param"}`))
+		return
+	}
+
+	summary, err := models.GetYearlySummary(request.Context(), params["owner_id"], year)
+	if err != nil {
+		response.WriteHeader(http.StatusInternalServerError)
+		response.Write([]byte(`{"message": "could not get yearly summary", "details": "` + err.Error() + `"}`))
+		return
+	}
+
+	json.NewEncoder(response).Encode(summary)
+}
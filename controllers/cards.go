@@ -22,6 +22,7 @@ func validateCardNetwork(network string) bool {
 // CreateCardEndpoint will create a single card to an user
 func CreateCardEndpoint(response http.ResponseWriter, request *http.Request) {
 	response.Header().Add("content-type", "application/json")
+	response.Header().Add("backend", "budget-tracker")
 	response.Header().Set("Access-Control-Allow-Origin", "*")
 
 	var card models.CreditCard
@@ -49,6 +50,7 @@ func CreateCardEndpoint(response http.ResponseWriter, request *http.Request) {
 // GetAllCardsEndpoint will return all cards from database
 func GetAllCardsEndpoint(response http.ResponseWriter, request *http.Request) {
 	response.Header().Add("content-type", "application/json")
+	response.Header().Add("backend", "budget-tracker")
 	response.Header().Set("Access-Control-Allow-Origin", "*")
 
 	cards, err := models.GetAllCards(request.Context())
@@ -69,6 +71,7 @@ func GetAllCardsEndpoint(response http.ResponseWriter, request *http.Request) {
 // GetCardsEndpoint will return all cards from a given user
 func GetCardsEndpoint(response http.ResponseWriter, request *http.Request) {
 	response.Header().Add("content-type", "application/json")
+	response.Header().Add("backend", "budget-tracker")
 	response.Header().Set("Access-Control-Allow-Origin", "*")
 
 	params := mux.Vars(request)
@@ -91,6 +94,7 @@ func GetCardsEndpoint(response http.ResponseWriter, request *http.Request) {
 // DeleteCardEndpoint deletes a card given an ID
 func DeleteCardEndpoint(response http.ResponseWriter, request *http.Request) {
 	response.Header().Add("content-type", "application/json")
+	response.Header().Add("backend", "budget-tracker")
 	response.Header().Set("Access-Control-Allow-Origin", "*")
 
 	params := mux.Vars(request)
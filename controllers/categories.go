@@ -0,0 +1,164 @@
+package controllers
+
+import (
+	"budget-tracker-api/models"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// CreateCategoryEndpoint will create a category to an user
+func CreateCategoryEndpoint(response http.ResponseWriter, request *http.Request) {
+	response.Header().Add("content-type", "application/json")
+	response.Header().Add("backend", "budget-tracker")
+
+	var category models.Category
+
+	_ = json.NewDecoder(request.Body).Decode(&category)
+
+	if category.OwnerID.IsZero() {
+		response.WriteHeader(http.StatusBadRequest)
+		response.Write([]byte(`{"message": "could not create category", "details": "missing owner ID"}`))
+		return
+	}
+
+	result, err := models.CreateCategory(request.Context(), category)
+	if mongo.IsDuplicateKeyError(err) {
+		response.WriteHeader(http.StatusConflict)
+		response.Write([]byte(`{"message": "could not create category", "details": "category '` + category.Name + `' already exists"}`))
+		return
+	}
+
+	if err != nil {
+		response.WriteHeader(http.StatusBadRequest)
+		response.Write([]byte(`{"message": "could not create category", "details": "` + err.Error() + `"}`))
+		return
+	}
+
+	response.WriteHeader(http.StatusCreated)
+	response.Write([]byte(`{"message": "created category '` + category.Name + `'", "id": "` + result + `"}`))
+}
+
+// SeedDefaultCategoriesEndpoint will create the default categories to an user which has none. Users get
+// them on sign up, so this is meant for users created before categories existed
+func SeedDefaultCategoriesEndpoint(response http.ResponseWriter, request *http.Request) {
+	response.Header().Add("content-type", "application/json")
+	response.Header().Add("backend", "budget-tracker")
+
+	params := mux.Vars(request)
+
+	oid, err := primitive.ObjectIDFromHex(params["owner_id"])
+	if err != nil {
+		response.WriteHeader(http.StatusBadRequest)
+		response.Write([]byte(`{"message": "could not seed categories", "details": "invalid owner ID"}`))
+		return
+	}
+
+	err = models.SeedDefaultCategories(request.Context(), oid)
+	if err != nil {
+		response.WriteHeader(http.StatusInternalServerError)
+		response.Write([]byte(`{"message": "could not seed categories", "details": "` + err.Error() + `"}`))
+		return
+	}
+
+	response.WriteHeader(http.StatusOK)
+	response.Write([]byte(`{"message": "seeded default categories to user '` + params["owner_id"] + `'"}`))
+}
+
+// GetCategoriesEndpoint will return all categories from an user. A 'tree' query param will nest
+// categories under their parents
+func GetCategoriesEndpoint(response http.ResponseWriter, request *http.Request) {
+	response.Header().Add("content-type", "application/json")
+	response.Header().Add("backend", "budget-tracker")
+
+	params := mux.Vars(request)
+
+	categories, err := models.GetCategories(request.Context(), params["owner_id"])
+	if err != nil {
+		response.WriteHeader(http.StatusInternalServerError)
+		response.Write([]byte(`{"message": "` + err.Error() + `"}`))
+		return
+	}
+
+	if len(categories) == 0 {
+		response.Write([]byte(`[]`))
+		return
+	}
+
+	if tree, _ := strconv.ParseBool(request.URL.Query().Get("tree")); tree {
+		categories = models.CategoryTree(categories)
+	}
+
+	json.NewEncoder(response).Encode(categories)
+}
+
+// UpdateCategoryEndpoint will edit a category given an ID, renaming it on existing spends if needed
+func UpdateCategoryEndpoint(response http.ResponseWriter, request *http.Request) {
+	response.Header().Add("content-type", "application/json")
+	response.Header().Add("backend", "budget-tracker")
+
+	params := mux.Vars(request)
+
+	var update models.CategoryUpdate
+
+	err := json.NewDecoder(request.Body).Decode(&update)
+	if err != nil {
+		response.WriteHeader(http.StatusBadRequest)
+		response.Write([]byte(`{"message": "could not update category", "details": "malformed payload"}`))
+		return
+	}
+
+	err = models.UpdateCategory(request.Context(), params["id"], update)
+	if err == mongo.ErrNoDocuments {
+		response.WriteHeader(http.StatusNotFound)
+		response.Write([]byte(`{"message": "could not update category", "details": "non existent category"}`))
+		return
+	}
+
+	if err != nil {
+		response.WriteHeader(http.StatusBadRequest)
+		response.Write([]byte(`{"message": "could not update category", "details": "` + err.Error() + `"}`))
+		return
+	}
+
+	response.WriteHeader(http.StatusOK)
+	response.Write([]byte(`{"message": "updated category '` + params["id"] + `'"}`))
+}
+
+// MergeCategoryEndpoint will merge a category into another one, moving all its spends to the target category
+func MergeCategoryEndpoint(response http.ResponseWriter, request *http.Request) {
+	response.Header().Add("content-type", "application/json")
+	response.Header().Add("backend", "budget-tracker")
+
+	params := mux.Vars(request)
+
+	var merge models.CategoryMerge
+
+	_ = json.NewDecoder(request.Body).Decode(&merge)
+
+	if merge.Into.IsZero() {
+		response.WriteHeader(http.StatusBadRequest)
+		response.Write([]byte(`{"message": "could not merge category", "details": "missing target category 'into'"}`))
+		return
+	}
+
+	merged, err := models.MergeCategory(request.Context(), params["id"], merge.Into.Hex())
+	if err == mongo.ErrNoDocuments {
+		response.WriteHeader(http.StatusNotFound)
+		response.Write([]byte(`{"message": "could not merge category", "details": "non existent category"}`))
+		return
+	}
+
+	if err != nil {
+		response.WriteHeader(http.StatusBadRequest)
+		response.Write([]byte(`{"message": "could not merge category", "details": "` + err.Error() + `"}`))
+		return
+	}
+
+	response.WriteHeader(http.StatusOK)
+	response.Write([]byte(`{"message": "merged category '` + params["id"] + `' into '` + merge.Into.Hex() + `'", "spends": ` + strconv.FormatInt(merged, 10) + `}`))
+}
@@ -0,0 +1,101 @@
+package controllers
+
+import (
+	"budget-tracker-api/models"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// CreateCategorizationRuleEndpoint will create a categorization rule to an user
+func CreateCategorizationRuleEndpoint(response http.ResponseWriter, request *http.Request) {
+	response.Header().Add("content-type", "application/json")
+	response.Header().Add("backend", "budget-tracker")
+
+	var rule models.CategorizationRule
+
+	_ = json.NewDecoder(request.Body).Decode(&rule)
+
+	errs, err := models.ValidateCategorizationRule(request.Context(), rule)
+	if err != nil {
+		response.WriteHeader(http.StatusInternalServerError)
+		response.Write([]byte(`{"message": "could not create categorization rule", "details": "` + err.Error() + `"}`))
+		return
+	}
+
+	if len(errs) > 0 {
+		writeValidationErrors(response, "could not create categorization rule", errs)
+		return
+	}
+
+	result, err := models.CreateCategorizationRule(request.Context(), rule)
+	if err != nil {
+		response.WriteHeader(http.StatusInternalServerError)
+		response.Write([]byte(`{"message": "could not create categorization rule", "details": "` + err.Error() + `"}`))
+		return
+	}
+
+	response.WriteHeader(http.StatusCreated)
+	response.Write([]byte(`{"message": "created categorization rule to user '` + rule.OwnerID.Hex() + `'", "id": "` + result + `"}`))
+}
+
+// GetCategorizationRulesEndpoint will return all categorization rules from an user
+func GetCategorizationRulesEndpoint(response http.ResponseWriter, request *http.Request) {
+	response.Header().Add("content-type", "application/json")
+	response.Header().Add("backend", "budget-tracker")
+
+	params := mux.Vars(request)
+
+	rules, err := models.GetCategorizationRules(request.Context(), params["owner_id"])
+	if err != nil {
+		response.WriteHeader(http.StatusInternalServerError)
+		response.Write([]byte(`{"message": "` + err.Error() + `"}`))
+		return
+	}
+
+	if len(rules) == 0 {
+		response.Write([]byte(`[]`))
+		return
+	}
+
+	json.NewEncoder(response).Encode(rules)
+}
+
+// DeleteCategorizationRuleEndpoint deletes a categorization rule given an ID
+func DeleteCategorizationRuleEndpoint(response http.ResponseWriter, request *http.Request) {
+	response.Header().Add("content-type", "application/json")
+	response.Header().Add("backend", "budget-tracker")
+
+	params := mux.Vars(request)
+
+	err := models.DeleteCategorizationRule(request.Context(), params["id"])
+	if err != nil {
+		response.WriteHeader(http.StatusInternalServerError)
+		response.Write([]byte(`{"message": "could not delete categorization rule", "details": "` + err.Error() + `"}`))
+		return
+	}
+
+	response.WriteHeader(http.StatusOK)
+	response.Write([]byte(`{"message": "deleted categorization rule '` + params["id"] + `'"}`))
+}
+
+// ReapplyCategorizationRulesEndpoint will recategorize past spends from an user based on its current rules
+func ReapplyCategorizationRulesEndpoint(response http.ResponseWriter, request *http.Request) {
+	response.Header().Add("content-type", "application/json")
+	response.Header().Add("backend", "budget-tracker")
+
+	params := mux.Vars(request)
+	overwrite, _ := strconv.ParseBool(request.URL.Query().Get("overwrite"))
+
+	updated, err := models.ReapplyCategorizationRules(request.Context(), params["owner_id"], overwrite)
+	if err != nil {
+		response.WriteHeader(http.StatusInternalServerError)
+		response.Write([]byte(`{"message": "could not reapply categorization rules", "details": "` + err.Error() + `"}`))
+		return
+	}
+
+	response.WriteHeader(http.StatusOK)
+	response.Write([]byte(`{"message": "reapplied categorization rules to user '` + params["owner_id"] + `'", "spends": ` + strconv.FormatInt(updated, 10) + `}`))
+}
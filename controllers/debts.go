@@ -0,0 +1,50 @@
+package controllers
+
+import (
+	"budget-tracker-api/models"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// GetDebtsEndpoint will return who owes whom between an user and the users it shares spends with
+func GetDebtsEndpoint(response http.ResponseWriter, request *http.Request) {
+	response.Header().Add("content-type", "application/json")
+	response.Header().Add("backend", "budget-tracker")
+
+	params := mux.Vars(request)
+
+	debts, err := models.GetDebts(request.Context(), params["owner_id"])
+	if err != nil {
+		response.WriteHeader(http.StatusInternalServerError)
+		response.Write([]byte(`{"message": "could not get debts", "details": "` + err.Error() + `"}`))
+		return
+	}
+
+	if len(debts) == 0 {
+		response.Write([]byte(`[]`))
+		return
+	}
+
+	json.NewEncoder(response).Encode(debts)
+}
+
+// SettleDebtsEndpoint will settle every shared spend between an user and another one
+func SettleDebtsEndpoint(response http.ResponseWriter, request *http.Request) {
+	response.Header().Add("content-type", "application/json")
+	response.Header().Add("backend", "budget-tracker")
+
+	params := mux.Vars(request)
+
+	settled, err := models.SettleDebts(request.Context(), params["owner_id"], params["user_id"])
+	if err != nil {
+		response.WriteHeader(http.StatusInternalServerError)
+		response.Write([]byte(`{"message": "could not settle debts", "details": "` + err.Error() + `"}`))
+		return
+	}
+
+	response.WriteHeader(http.StatusOK)
+	response.Write([]byte(`{"message": "settled ` + strconv.FormatInt(settled, 10) + ` shared spends with user '` + params["user_id"] + `'"}`))
+}
@@ -0,0 +1,235 @@
+package controllers
+
+import (
+	"budget-tracker-api/exporter"
+	"budget-tracker-api/models"
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	log "github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// exportFormat will return the 'format' URL param of a table export, CSV by default
+func exportFormat(request *http.Request) (format string, ok bool) {
+	format = request.URL.Query().Get("format")
+	if format == "" {
+		format = "csv"
+	}
+	return format, format == "csv" || format == "xlsx"
+}
+
+// startDownload will set the headers of an export download
+func startDownload(response http.ResponseWriter, format string, filename string) {
+	response.Header().Set("content-type", exporter.ContentTypes[format])
+	response.Header().Set("Content-Disposition", `attachment; filename="`+filename+`.`+format+`"`)
+	response.WriteHeader(http.StatusOK)
+}
+
+// cardAliases will map the cards from an user to their aliases, naming cards at exports
+func cardAliases(ctx context.Context, ownerID string) (aliases map[primitive.ObjectID]string, err error) {
+	cards, err := models.GetCards(ctx, ownerID)
+	if err != nil {
+		return aliases, err
+	}
+
+	aliases = map[primitive.ObjectID]string{}
+	for _, card := range cards {
+		aliases[card.ID] = card.Alias
+	}
+	return aliases, nil
+}
+
+// ExportSpendsEndpoint will export the spends from an user as CSV or XLSX, filtered by the same URL params
+// of spends listing and by balance 'month' and 'year'. Only spends booked to balances are exported, so
+// installment plans and split spends are exported as their installments and parts. Spends are streamed,
+// oldest first unless sorted otherwise
+func ExportSpendsEndpoint(response http.ResponseWriter, request *http.Request) {
+	response.Header().Add("content-type", "application/json")
+	response.Header().Add("backend", "budget-tracker")
+	response.Header().Set("Access-Control-Allow-Origin", "*")
+
+	params := mux.Vars(request)
+	v := request.URL.Query()
+
+	format, ok := exportFormat(request)
+	if !ok {
+		response.WriteHeader(http.StatusBadRequest)
+		response.Write([]byte(`{"message": "could not export spends", "details": "invalid 'format' value"}`))
+		return
+	}
+
+	q, err := parseSpendsQuery(request)
+	if err != nil {
+		response.WriteHeader(http.StatusBadRequest)
+		response.Write([]byte(`{"message": "could not export spends", "details": "` + err.Error() + `"}`))
+		return
+	}
+
+	if v.Get("sort") == "" {
+		q.SortDesc = false
+	}
+	q.Booked = true
+
+	filename := "spends"
+	if v.Get("month") != "" || v.Get("year") != "" {
+		q.Month, q.Year, err = parsePeriodParams(request)
+		if err != nil {
+			response.WriteHeader(http.StatusBadRequest)
+			response.Write([]byte(`{"message": "could not export spends", "details": "` + err.Error() + `"}`))
+			return
+		}
+		filename = fmt.Sprintf("spends-%d-%02d", q.Year, q.Month)
+	}
+
+	cards, err := cardAliases(request.Context(), params["owner_id"])
+	if err != nil {
+		response.WriteHeader(http.StatusInternalServerError)
+		response.Write([]byte(`{"message": "could not export spends", "details": "` + err.Error() + `"}`))
+		return
+	}
+
+	startDownload(response, format, filename)
+
+	table, err := exporter.New(format, response, "Spends", exporter.SpendColumns)
+	if err != nil {
+		log.Errorln("could not export spends from", params["owner_id"], ":", err)
+		return
+	}
+
+	// once streaming has started errors can only cut the download short
+	err = models.StreamSpends(request.Context(), q, func(s models.Spend) error {
+		return table.Write(exporter.SpendRow(s, cards))
+	})
+	if err != nil {
+		log.Errorln("could not export spends from", params["owner_id"], ":", err)
+		return
+	}
+
+	err = table.Close()
+	if err != nil {
+		log.Errorln("could not export spends from", params["owner_id"], ":", err)
+	}
+}
+
+// ExportBalancesEndpoint will export the balances from an user as CSV or XLSX, optionally from a single 'year'
+func ExportBalancesEndpoint(response http.ResponseWriter, request *http.Request) {
+	response.Header().Add("content-type", "application/json")
+	response.Header().Add("backend", "budget-tracker")
+	response.Header().Set("Access-Control-Allow-Origin", "*")
+
+	params := mux.Vars(request)
+	v := request.URL.Query()
+
+	format, ok := exportFormat(request)
+	if !ok {
+		response.WriteHeader(http.StatusBadRequest)
+		response.Write([]byte(`{"message": "could not export balances", "details": "invalid 'format' value"}`))
+		return
+	}
+
+	var year int64
+	var err error
+	filename := "balances"
+	if v.Get("year") != "" {
+		year, err = strconv.ParseInt(v.Get("year"), 10, 64)
+		if err != nil {
+			response.WriteHeader(http.StatusBadRequest)
+			response.Write([]byte(`{"message": "could not export balances", "details": "invalid 'year' param"}`))
+			return
+		}
+		filename = fmt.Sprintf("balances-%d", year)
+	}
+
+	if _, err = primitive.ObjectIDFromHex(params["owner_id"]); err != nil {
+		response.WriteHeader(http.StatusBadRequest)
+		response.Write([]byte(`{"message": "could not export balances", "details": "invalid owner ID"}`))
+		return
+	}
+
+	startDownload(response, format, filename)
+
+	table, err := exporter.New(format, response, "Balances", exporter.BalanceColumns)
+	if err != nil {
+		log.Errorln("could not export balances from", params["owner_id"], ":", err)
+		return
+	}
+
+	err = models.StreamBalances(request.Context(), params["owner_id"], year, func(b models.Balance) error {
+		return table.Write(exporter.BalanceRow(b))
+	})
+	if err != nil {
+		log.Errorln("could not export balances from", params["owner_id"], ":", err)
+		return
+	}
+
+	err = table.Close()
+	if err != nil {
+		log.Errorln("could not export balances from", params["owner_id"], ":", err)
+	}
+}
+
+// ExportStatementEndpoint will export the statement of a month balance as a PDF, summarizing its incomes
+// and outcomes and listing its spends
+func ExportStatementEndpoint(response http.ResponseWriter, request *http.Request) {
+	response.Header().Add("content-type", "application/json")
+	response.Header().Add("backend", "budget-tracker")
+	response.Header().Set("Access-Control-Allow-Origin", "*")
+
+	params := mux.Vars(request)
+
+	month, year, err := parsePeriodParams(request)
+	if err != nil {
+		response.WriteHeader(http.StatusBadRequest)
+		response.Write([]byte(`{"message": "could not export statement", "details": "` + err.Error() + `"}`))
+		return
+	}
+
+	balance, err := models.GetBalance(request.Context(), params["owner_id"], month, year)
+	if err == mongo.ErrNoDocuments {
+		response.WriteHeader(http.StatusNotFound)
+		response.Write([]byte(`{"message": "could not export statement", "details": "non existent balance"}`))
+		return
+	}
+
+	if err != nil {
+		response.WriteHeader(http.StatusInternalServerError)
+		response.Write([]byte(`{"message": "could not export statement", "details": "` + err.Error() + `"}`))
+		return
+	}
+
+	cards, err := cardAliases(request.Context(), params["owner_id"])
+	if err != nil {
+		response.WriteHeader(http.StatusInternalServerError)
+		response.Write([]byte(`{"message": "could not export statement", "details": "` + err.Error() + `"}`))
+		return
+	}
+
+	startDownload(response, "pdf", fmt.Sprintf("statement-%d-%02d", year, month))
+
+	title := fmt.Sprintf("Monthly statement %02d/%d", month, year)
+	table, err := exporter.NewPDF(response, title, exporter.StatementSummary(*balance), exporter.StatementColumns, exporter.StatementWidths)
+	if err != nil {
+		log.Errorln("could not export statement from", params["owner_id"], ":", err)
+		return
+	}
+
+	// the statement lists what was booked to the balance, as its summary does
+	q := models.SpendsQuery{OwnerID: params["owner_id"], Month: month, Year: year, Booked: true}
+	err = models.StreamSpends(request.Context(), q, func(s models.Spend) error {
+		return table.Write(exporter.StatementRow(s, cards))
+	})
+	if err != nil {
+		log.Errorln("could not export statement from", params["owner_id"], ":", err)
+		return
+	}
+
+	err = table.Close()
+	if err != nil {
+		log.Errorln("could not export statement from", params["owner_id"], ":", err)
+	}
+}
@@ -0,0 +1,52 @@
+package controllers
+
+import (
+	"budget-tracker-api/models"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+const (
+	defaultForecastMonths = 6
+	maxForecastMonths     = 24
+)
+
+// GetForecastEndpoint will project the income, outcome and spendable amount from an user for upcoming months
+func GetForecastEndpoint(response http.ResponseWriter, request *http.Request) {
+	response.Header().Add("content-type", "application/json")
+	response.Header().Add("backend", "budget-tracker")
+
+	params := mux.Vars(request)
+
+	months := defaultForecastMonths
+	if v := request.URL.Query().Get("months"); v != "" {
+		var err error
+		months, err = strconv.Atoi(v)
+		if err != nil || months < 1 || months > maxForecastMonths {
+			response.WriteHeader(http.StatusBadRequest)
+			response.Write([]byte(`{"message": "could not get forecast", "details": "'months' must be between 1 and ` + strconv.Itoa(maxForecastMonths) + `"}`))
+			return
+		}
+	}
+
+	// months are bounded at the requested timezone, the same way spends get their balance month
+	loc, err := parseTimezoneParam(request)
+	if err != nil {
+		response.WriteHeader(http.StatusBadRequest)
+		response.Write([]byte(`{"message": "could not get forecast", "details": "` + err.Error() + `"}`))
+		return
+	}
+
+	forecast, err := models.Forecast(request.Context(), params["owner_id"], months, time.Now().In(loc))
+	if err != nil {
+		response.WriteHeader(http.StatusInternalServerError)
+		response.Write([]byte(`{"message": "could not get forecast", "details": "` + err.Error() + `"}`))
+		return
+	}
+
+	json.NewEncoder(response).Encode(forecast)
+}
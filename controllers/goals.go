@@ -0,0 +1,154 @@
+package controllers
+
+import (
+	"budget-tracker-api/models"
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// CreateGoalEndpoint will create a savings goal to an user
+func CreateGoalEndpoint(response http.ResponseWriter, request *http.Request) {
+	response.Header().Add("content-type", "application/json")
+	response.Header().Add("backend", "budget-tracker")
+
+	var goal models.Goal
+
+	_ = json.NewDecoder(request.Body).Decode(&goal)
+
+	errs := models.ValidateGoal(goal)
+	if len(errs) > 0 {
+		writeValidationErrors(response, "could not create goal", errs)
+		return
+	}
+
+	result, err := models.CreateGoal(request.Context(), goal)
+	if err != nil {
+		response.WriteHeader(http.StatusInternalServerError)
+		response.Write([]byte(`{"message": "could not create goal", "details": "` + err.Error() + `"}`))
+		return
+	}
+
+	response.WriteHeader(http.StatusCreated)
+	response.Write([]byte(`{"message": "created goal '` + goal.Name + `'", "id": "` + result + `"}`))
+}
+
+// GetGoalsEndpoint will return all goals from an user along with their progress
+func GetGoalsEndpoint(response http.ResponseWriter, request *http.Request) {
+	response.Header().Add("content-type", "application/json")
+	response.Header().Add("backend", "budget-tracker")
+
+	params := mux.Vars(request)
+
+	progress, err := models.GetGoalsProgress(request.Context(), params["owner_id"])
+	if err != nil {
+		response.WriteHeader(http.StatusInternalServerError)
+		response.Write([]byte(`{"message": "` + err.Error() + `"}`))
+		return
+	}
+
+	if len(progress) == 0 {
+		response.Write([]byte(`[]`))
+		return
+	}
+
+	json.NewEncoder(response).Encode(progress)
+}
+
+// UpdateGoalEndpoint will edit a goal given an ID
+func UpdateGoalEndpoint(response http.ResponseWriter, request *http.Request) {
+	response.Header().Add("content-type", "application/json")
+	response.Header().Add("backend", "budget-tracker")
+
+	params := mux.Vars(request)
+
+	var update models.GoalUpdate
+
+	err := json.NewDecoder(request.Body).Decode(&update)
+	if err != nil {
+		response.WriteHeader(http.StatusBadRequest)
+		response.Write([]byte(`{"message": "could not update goal", "details": "malformed payload"}`))
+		return
+	}
+
+	err = models.UpdateGoal(request.Context(), params["id"], update)
+	if err == mongo.ErrNoDocuments {
+		response.WriteHeader(http.StatusNotFound)
+		response.Write([]byte(`{"message": "could not update goal", "details": "non existent goal"}`))
+		return
+	}
+
+	if err != nil {
+		response.WriteHeader(http.StatusBadRequest)
+		response.Write([]byte(`{"message": "could not update goal", "details": "` + err.Error() + `"}`))
+		return
+	}
+
+	response.WriteHeader(http.StatusOK)
+	response.Write([]byte(`{"message": "updated goal '` + params["id"] + `'"}`))
+}
+
+// DeleteGoalEndpoint will delete a goal given an ID
+func DeleteGoalEndpoint(response http.ResponseWriter, request *http.Request) {
+	response.Header().Add("content-type", "application/json")
+	response.Header().Add("backend", "budget-tracker")
+
+	params := mux.Vars(request)
+
+	err := models.DeleteGoal(request.Context(), params["id"])
+	if err == mongo.ErrNoDocuments {
+		response.WriteHeader(http.StatusNotFound)
+		response.Write([]byte(`{"message": "could not delete goal", "details": "non existent goal"}`))
+		return
+	}
+
+	if err != nil {
+		response.WriteHeader(http.StatusInternalServerError)
+		response.Write([]byte(`{"message": "could not delete goal", "details": "` + err.Error() + `"}`))
+		return
+	}
+
+	response.WriteHeader(http.StatusOK)
+	response.Write([]byte(`{"message": "deleted goal '` + params["id"] + `'"}`))
+}
+
+// AddGoalContributionEndpoint will save an amount to a goal, taking it from the month balance
+func AddGoalContributionEndpoint(response http.ResponseWriter, request *http.Request) {
+	response.Header().Add("content-type", "application/json")
+	response.Header().Add("backend", "budget-tracker")
+
+	params := mux.Vars(request)
+
+	var contribution models.GoalContribution
+
+	err := json.NewDecoder(request.Body).Decode(&contribution)
+	if err != nil {
+		response.WriteHeader(http.StatusBadRequest)
+		response.Write([]byte(`{"message": "could not add contribution", "details": "malformed payload"}`))
+		return
+	}
+
+	result, err := models.AddGoalContribution(request.Context(), params["id"], contribution)
+	if err == mongo.ErrNoDocuments {
+		response.WriteHeader(http.StatusNotFound)
+		response.Write([]byte(`{"message": "could not add contribution", "details": "non existent goal"}`))
+		return
+	}
+
+	if err == models.ErrBalanceClosed {
+		response.WriteHeader(http.StatusConflict)
+		response.Write([]byte(`{"message": "could not add contribution", "details": "` + err.Error() + `"}`))
+		return
+	}
+
+	if err != nil {
+		response.WriteHeader(http.StatusBadRequest)
+		response.Write([]byte(`{"message": "could not add contribution", "details": "` + err.Error() + `"}`))
+		return
+	}
+
+	response.WriteHeader(http.StatusCreated)
+	response.Write([]byte(`{"message": "added contribution to goal '` + params["id"] + `'", "id": "` + result + `"}`))
+}
@@ -9,6 +9,7 @@ import (
 // HealthCheck will validate if external core components are working
 func HealthCheck(response http.ResponseWriter, request *http.Request) {
 	response.Header().Add("content-type", "application/json")
+	response.Header().Add("backend", "budget-tracker")
 
 	err := services.DatabaseHealth()
 	if err != nil {
@@ -0,0 +1,275 @@
+package controllers
+
+import (
+	"budget-tracker-api/importer"
+	"budget-tracker-api/models"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// maxImportSize defines the maximum size of imported files
+const maxImportSize = 20 << 20
+
+// CreateImportProfileEndpoint will create a bank statement import profile to an user
+func CreateImportProfileEndpoint(response http.ResponseWriter, request *http.Request) {
+	response.Header().Add("content-type", "application/json")
+	response.Header().Add("backend", "budget-tracker")
+
+	var profile models.ImportProfile
+
+	_ = json.NewDecoder(request.Body).Decode(&profile)
+
+	errs, err := models.ValidateImportProfile(request.Context(), profile)
+	if err != nil {
+		response.WriteHeader(http.StatusInternalServerError)
+		response.Write([]byte(`{"message": "could not create import profile", "details": "` + err.Error() + `"}`))
+		return
+	}
+
+	if len(errs) > 0 {
+		writeValidationErrors(response, "could not create import profile", errs)
+		return
+	}
+
+	result, err := models.CreateImportProfile(request.Context(), profile)
+	if mongo.IsDuplicateKeyError(err) {
+		response.WriteHeader(http.StatusConflict)
+		response.Write([]byte(`{"message": "could not create import profile", "details": "import profile '` + profile.Name + `' already exists"}`))
+		return
+	}
+
+	if err != nil {
+		response.WriteHeader(http.StatusInternalServerError)
+		response.Write([]byte(`{"message": "could not create import profile", "details": "` + err.Error() + `"}`))
+		return
+	}
+
+	response.WriteHeader(http.StatusCreated)
+	response.Write([]byte(`{"message": "created import profile '` + profile.Name + `'", "id": "` + result + `"}`))
+}
+
+// GetImportProfilesEndpoint will return all import profiles from an user
+func GetImportProfilesEndpoint(response http.ResponseWriter, request *http.Request) {
+	response.Header().Add("content-type", "application/json")
+	response.Header().Add("backend", "budget-tracker")
+
+	params := mux.Vars(request)
+
+	profiles, err := models.GetImportProfiles(request.Context(), params["owner_id"])
+	if err != nil {
+		response.WriteHeader(http.StatusInternalServerError)
+		response.Write([]byte(`{"message": "` + err.Error() + `"}`))
+		return
+	}
+
+	if len(profiles) == 0 {
+		response.Write([]byte(`[]`))
+		return
+	}
+
+	json.NewEncoder(response).Encode(profiles)
+}
+
+// DeleteImportProfileEndpoint will delete an import profile given an ID
+func DeleteImportProfileEndpoint(response http.ResponseWriter, request *http.Request) {
+	response.Header().Add("content-type", "application/json")
+	response.Header().Add("backend", "budget-tracker")
+
+	params := mux.Vars(request)
+
+	err := models.DeleteImportProfile(request.Context(), params["id"])
+	if err == mongo.ErrNoDocuments {
+		response.WriteHeader(http.StatusNotFound)
+		response.Write([]byte(`{"message": "could not delete import profile", "details": "non existent import profile"}`))
+		return
+	}
+
+	if err != nil {
+		response.WriteHeader(http.StatusInternalServerError)
+		response.Write([]byte(`{"message": "could not delete import profile", "details": "` + err.Error() + `"}`))
+		return
+	}
+
+	response.WriteHeader(http.StatusOK)
+	response.Write([]byte(`{"message": "deleted import profile '` + params["id"] + `'"}`))
+}
+
+// startImport will create the job of an import and start it, replying with the finished job or, for
+// large files imported in background, with the pending job and its location
+func startImport(response http.ResponseWriter, request *http.Request, job models.ImportJob, records []models.ImportRecord) {
+	id, err := models.CreateImportJob(request.Context(), job)
+	if err != nil {
+		response.WriteHeader(http.StatusInternalServerError)
+		response.Write([]byte(`{"message": "could not import file", "details": "` + err.Error() + `"}`))
+		return
+	}
+
+	job.ID, _ = primitive.ObjectIDFromHex(id)
+	job.Status = "pending"
+
+	job, async := importer.Start(request.Context(), job, records)
+	if async {
+		response.Header().Add("Location", "/api/v1/imports/jobs/"+id)
+		response.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(response).Encode(job)
+		return
+	}
+
+	if job.DryRun {
+		response.WriteHeader(http.StatusOK)
+	} else {
+		response.WriteHeader(http.StatusCreated)
+	}
+
+	json.NewEncoder(response).Encode(job)
+}
+
+// ImportCSVEndpoint will import spends from a bank statement CSV sent as the 'file' field of a multipart
+// form, mapping its columns with the given import profile. Duplicated spends are skipped
+func ImportCSVEndpoint(response http.ResponseWriter, request *http.Request) {
+	response.Header().Add("content-type", "application/json")
+	response.Header().Add("backend", "budget-tracker")
+
+	params := mux.Vars(request)
+	request.Body = http.MaxBytesReader(response, request.Body, maxImportSize)
+
+	file, header, err := request.FormFile("file")
+	if err != nil {
+		response.WriteHeader(http.StatusBadRequest)
+		response.Write([]byte(`{"message": "could not import file", "details": "missing 'file' field"}`))
+		return
+	}
+
+	defer file.Close()
+
+	profileID := request.FormValue("profile_id")
+	profile, err := models.GetImportProfile(request.Context(), profileID)
+	if err != nil || profile.OwnerID.Hex() != params["owner_id"] {
+		response.WriteHeader(http.StatusBadRequest)
+		response.Write([]byte(`{"message": "could not import file", "details": "non existent import profile '` + profileID + `'"}`))
+		return
+	}
+
+	dryRun, _ := strconv.ParseBool(request.FormValue("dry_run"))
+
+	records, skipped, errs, err := importer.ParseCSV(file, *profile)
+	if err != nil {
+		response.WriteHeader(http.StatusBadRequest)
+		response.Write([]byte(`{"message": "could not import file", "details": "` + err.Error() + `"}`))
+		return
+	}
+
+	startImport(response, request, models.ImportJob{
+		OwnerID:  profile.OwnerID,
+		Format:   "csv",
+		Filename: header.Filename,
+		DryRun:   dryRun,
+		Total:    len(records) + skipped + len(errs),
+		Skipped:  skipped,
+		Failed:   len(errs),
+		Errors:   errs,
+	}, records)
+}
+
+// importStatement will import the transactions of an OFX or QIF statement sent as the 'file' field of a
+// multipart form. Spends are paid with the chosen 'card_id' or, when none is given, the debit account
+func importStatement(response http.ResponseWriter, request *http.Request, format string, parse func(io.Reader, importer.Options) ([]models.ImportRecord, int, []models.ImportError, error)) {
+	response.Header().Add("content-type", "application/json")
+	response.Header().Add("backend", "budget-tracker")
+
+	params := mux.Vars(request)
+	request.Body = http.MaxBytesReader(response, request.Body, maxImportSize)
+
+	oid, err := primitive.ObjectIDFromHex(params["owner_id"])
+	if err != nil {
+		response.WriteHeader(http.StatusBadRequest)
+		response.Write([]byte(`{"message": "could not import file", "details": "invalid owner ID"}`))
+		return
+	}
+
+	file, header, err := request.FormFile("file")
+	if err != nil {
+		response.WriteHeader(http.StatusBadRequest)
+		response.Write([]byte(`{"message": "could not import file", "details": "missing 'file' field"}`))
+		return
+	}
+
+	defer file.Close()
+
+	options := importer.Options{
+		OwnerID:       oid,
+		PaymentMethod: models.PaymentMethod{Debit: true},
+		Timezone:      request.FormValue("timezone"),
+		DateFormat:    request.FormValue("date_format"),
+	}
+
+	if cardID := request.FormValue("card_id"); cardID != "" {
+		card, err := models.GetCard(request.Context(), cardID)
+		if err != nil || card.OwnerID != oid {
+			response.WriteHeader(http.StatusBadRequest)
+			response.Write([]byte(`{"message": "could not import file", "details": "non existent card '` + cardID + `'"}`))
+			return
+		}
+		options.PaymentMethod = models.PaymentMethod{Credit: true, CardID: card.ID}
+	}
+
+	dryRun, _ := strconv.ParseBool(request.FormValue("dry_run"))
+
+	records, skipped, errs, err := parse(file, options)
+	if err != nil {
+		response.WriteHeader(http.StatusBadRequest)
+		response.Write([]byte(`{"message": "could not import file", "details": "` + err.Error() + `"}`))
+		return
+	}
+
+	startImport(response, request, models.ImportJob{
+		OwnerID:  oid,
+		Format:   format,
+		Filename: header.Filename,
+		DryRun:   dryRun,
+		Total:    len(records) + skipped + len(errs),
+		Skipped:  skipped,
+		Failed:   len(errs),
+		Errors:   errs,
+	}, records)
+}
+
+// ImportOFXEndpoint will import spends and incomes from an OFX bank or credit card statement. Transactions
+// already imported are told apart by their account and FITID
+func ImportOFXEndpoint(response http.ResponseWriter, request *http.Request) {
+	importStatement(response, request, "ofx", importer.ParseOFX)
+}
+
+// ImportQIFEndpoint will import spends and incomes from a QIF bank or credit card statement
+func ImportQIFEndpoint(response http.ResponseWriter, request *http.Request) {
+	importStatement(response, request, "qif", importer.ParseQIF)
+}
+
+// GetImportJobEndpoint will return the status of an import job given an ID
+func GetImportJobEndpoint(response http.ResponseWriter, request *http.Request) {
+	response.Header().Add("content-type", "application/json")
+	response.Header().Add("backend", "budget-tracker")
+
+	params := mux.Vars(request)
+
+	job, err := models.GetImportJob(request.Context(), params["id"])
+	if err == mongo.ErrNoDocuments {
+		response.WriteHeader(http.StatusNotFound)
+		response.Write([]byte(`{"message": "could not get import job", "details": "non existent import job"}`))
+		return
+	}
+
+	if err != nil {
+		response.WriteHeader(http.StatusInternalServerError)
+		response.Write([]byte(`{"message": "` + err.Error() + `"}`))
+		return
+	}
+
+	json.NewEncoder(response).Encode(job)
+}
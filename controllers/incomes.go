@@ -0,0 +1,131 @@
+package controllers
+
+import (
+	"budget-tracker-api/models"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// AddIncomeEndpoint will add an income entry to a month balance, creating the balance if needed
+func AddIncomeEndpoint(response http.ResponseWriter, request *http.Request) {
+	response.Header().Add("content-type", "application/json")
+	response.Header().Add("backend", "budget-tracker")
+
+	params := mux.Vars(request)
+
+	month, year, err := parsePeriodParams(request)
+	if err != nil {
+		response.WriteHeader(http.StatusBadRequest)
+		response.Write([]byte(`{"message": "could not add income", "details": "` + err.Error() + `"}`))
+		return
+	}
+
+	oid, err := primitive.ObjectIDFromHex(params["owner_id"])
+	if err != nil {
+		response.WriteHeader(http.StatusBadRequest)
+		response.Write([]byte(`{"message": "could not add income", "details": "invalid owner ID"}`))
+		return
+	}
+
+	var income models.IncomeEntry
+
+	err = json.NewDecoder(request.Body).Decode(&income)
+	if err != nil {
+		response.WriteHeader(http.StatusBadRequest)
+		response.Write([]byte(`{"message": "could not add income", "details": "malformed payload"}`))
+		return
+	}
+
+	errs := models.ValidateIncomeEntry("", income)
+	if len(errs) > 0 {
+		writeValidationErrors(response, "could not add income", errs)
+		return
+	}
+
+	result, err := models.AddIncomeToBalance(request.Context(), oid, month, year, income)
+	if err == models.ErrBalanceClosed {
+		response.WriteHeader(http.StatusConflict)
+		response.Write([]byte(`{"message": "could not add income", "details": "` + err.Error() + `"}`))
+		return
+	}
+
+	if err != nil {
+		response.WriteHeader(http.StatusInternalServerError)
+		response.Write([]byte(`{"message": "could not add income", "details": "` + err.Error() + `"}`))
+		return
+	}
+
+	response.WriteHeader(http.StatusCreated)
+	response.Write([]byte(`{"message": "added income to balance ` + strconv.FormatInt(month, 10) + `/` + strconv.FormatInt(year, 10) + `", "id": "` + result + `"}`))
+}
+
+// RemoveIncomeEndpoint will remove an income entry from a month balance given its ID
+func RemoveIncomeEndpoint(response http.ResponseWriter, request *http.Request) {
+	response.Header().Add("content-type", "application/json")
+	response.Header().Add("backend", "budget-tracker")
+
+	params := mux.Vars(request)
+
+	month, year, err := parsePeriodParams(request)
+	if err != nil {
+		response.WriteHeader(http.StatusBadRequest)
+		response.Write([]byte(`{"message": "could not remove income", "details": "` + err.Error() + `"}`))
+		return
+	}
+
+	err = models.RemoveIncomeFromBalance(request.Context(), params["owner_id"], month, year, params["id"])
+	if err == mongo.ErrNoDocuments {
+		response.WriteHeader(http.StatusNotFound)
+		response.Write([]byte(`{"message": "could not remove income", "details": "non existent balance"}`))
+		return
+	}
+
+	if err == models.ErrBalanceClosed {
+		response.WriteHeader(http.StatusConflict)
+		response.Write([]byte(`{"message": "could not remove income", "details": "` + err.Error() + `"}`))
+		return
+	}
+
+	if err != nil {
+		response.WriteHeader(http.StatusBadRequest)
+		response.Write([]byte(`{"message": "could not remove income", "details": "` + err.Error() + `"}`))
+		return
+	}
+
+	response.WriteHeader(http.StatusOK)
+	response.Write([]byte(`{"message": "removed income '` + params["id"] + `'"}`))
+}
+
+// GetIncomesBreakdownEndpoint will return how much an user received from each income source during a year
+func GetIncomesBreakdownEndpoint(response http.ResponseWriter, request *http.Request) {
+	response.Header().Add("content-type", "application/json")
+	response.Header().Add("backend", "budget-tracker")
+
+	params := mux.Vars(request)
+
+	year, err := strconv.ParseInt(request.URL.Query().Get("year"), 10, 64)
+	if err != nil {
+		response.WriteHeader(http.StatusBadRequest)
+		response.Write([]byte(`{"message": "could not get incomes breakdown", "details": "invalid or missing 'year' param"}`))
+		return
+	}
+
+	breakdown, err := models.GetIncomesBreakdown(request.Context(), params["owner_id"], year)
+	if err != nil {
+		response.WriteHeader(http.StatusInternalServerError)
+		response.Write([]byte(`{"message": "` + err.Error() + `"}`))
+		return
+	}
+
+	if len(breakdown) == 0 {
+		response.Write([]byte(`[]`))
+		return
+	}
+
+	json.NewEncoder(response).Encode(breakdown)
+}
@@ -7,6 +7,7 @@ import (
 // JWTTokenOptionsEndpoint will return a set of headers for UI purposes
 func JWTTokenOptionsEndpoint(response http.ResponseWriter, request *http.Request) {
 	response.Header().Add("content-type", "application/json")
+	response.Header().Add("backend", "budget-tracker")
 	response.Header().Set("Access-Control-Allow-Origin", "*")
 	response.Header().Set("Access-Control-Allow-Credentials", "true")
 	response.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS")
@@ -16,6 +17,7 @@ func JWTTokenOptionsEndpoint(response http.ResponseWriter, request *http.Request
 // CardsOptionsEndpoint will return a set of headers for UI purposes
 func CardsOptionsEndpoint(response http.ResponseWriter, request *http.Request) {
 	response.Header().Add("content-type", "application/json")
+	response.Header().Add("backend", "budget-tracker")
 	response.Header().Set("Access-Control-Allow-Origin", "*")
 	response.Header().Set("Access-Control-Allow-Credentials", "true")
 	response.Header().Set("Access-Control-Allow-Methods", "GET, POST, DELETE, PUT, PATCH, OPTIONS")
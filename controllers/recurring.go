@@ -0,0 +1,190 @@
+package controllers
+
+import (
+	"budget-tracker-api/models"
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// CreateRecurringRuleEndpoint will create a recurring spend or income to an user
+func CreateRecurringRuleEndpoint(response http.ResponseWriter, request *http.Request) {
+	response.Header().Add("content-type", "application/json")
+	response.Header().Add("backend", "budget-tracker")
+
+	var rule models.RecurringRule
+
+	_ = json.NewDecoder(request.Body).Decode(&rule)
+
+	if rule.OwnerID.IsZero() {
+		response.WriteHeader(http.StatusBadRequest)
+		response.Write([]byte(`{"message": "could not create recurring rule", "details": "missing owner ID"}`))
+		return
+	}
+
+	err := models.ValidateRecurringRule(rule)
+	if err != nil {
+		response.WriteHeader(http.StatusBadRequest)
+		response.Write([]byte(`{"message": "could not create recurring rule", "details": "` + err.Error() + `"}`))
+		return
+	}
+
+	if rule.Kind == "spend" {
+		rule.Spend.OwnerID = rule.OwnerID
+
+		errs, err := models.ValidateSpend(request.Context(), rule.Spend)
+		if err != nil {
+			response.WriteHeader(http.StatusInternalServerError)
+			response.Write([]byte(`{"message": "could not create recurring rule", "details": "` + err.Error() + `"}`))
+			return
+		}
+
+		if len(errs) > 0 {
+			writeValidationErrors(response, "could not create recurring rule", errs)
+			return
+		}
+
+		rule.Spend, errs, err = models.ResolveSpendCategories(request.Context(), rule.Spend)
+		if err != nil {
+			response.WriteHeader(http.StatusInternalServerError)
+			response.Write([]byte(`{"message": "could not create recurring rule", "details": "` + err.Error() + `"}`))
+			return
+		}
+
+		if len(errs) > 0 {
+			writeValidationErrors(response, "could not create recurring rule", errs)
+			return
+		}
+	}
+
+	result, err := models.CreateRecurringRule(request.Context(), rule)
+	if err != nil {
+		response.WriteHeader(http.StatusInternalServerError)
+		response.Write([]byte(`{"message": "could not create recurring rule", "details": "` + err.Error() + `"}`))
+		return
+	}
+
+	response.WriteHeader(http.StatusCreated)
+	response.Write([]byte(`{"message": "created recurring rule to user '` + rule.OwnerID.Hex() + `'", "id": "` + result + `"}`))
+}
+
+// GetRecurringRulesEndpoint will return all recurring rules from an user
+func GetRecurringRulesEndpoint(response http.ResponseWriter, request *http.Request) {
+	response.Header().Add("content-type", "application/json")
+	response.Header().Add("backend", "budget-tracker")
+
+	params := mux.Vars(request)
+
+	rules, err := models.GetRecurringRules(request.Context(), params["owner_id"])
+	if err != nil {
+		response.WriteHeader(http.StatusInternalServerError)
+		response.Write([]byte(`{"message": "` + err.Error() + `"}`))
+		return
+	}
+
+	if len(rules) == 0 {
+		response.Write([]byte(`[]`))
+		return
+	}
+
+	json.NewEncoder(response).Encode(rules)
+}
+
+// UpdateRecurringRuleEndpoint will edit a recurring rule given an ID
+func UpdateRecurringRuleEndpoint(response http.ResponseWriter, request *http.Request) {
+	response.Header().Add("content-type", "application/json")
+	response.Header().Add("backend", "budget-tracker")
+
+	params := mux.Vars(request)
+
+	var update models.RecurringRuleUpdate
+
+	err := json.NewDecoder(request.Body).Decode(&update)
+	if err != nil {
+		response.WriteHeader(http.StatusBadRequest)
+		response.Write([]byte(`{"message": "could not update recurring rule", "details": "malformed payload"}`))
+		return
+	}
+
+	if update.Spend != nil {
+		rule, err := models.GetRecurringRule(request.Context(), params["id"])
+		if err != nil {
+			response.WriteHeader(http.StatusNotFound)
+			response.Write([]byte(`{"message": "could not update recurring rule", "details": "` + err.Error() + `"}`))
+			return
+		}
+
+		update.Spend.OwnerID = rule.OwnerID
+
+		errs, err := models.ValidateSpend(request.Context(), *update.Spend)
+		if err != nil {
+			response.WriteHeader(http.StatusInternalServerError)
+			response.Write([]byte(`{"message": "could not update recurring rule", "details": "` + err.Error() + `"}`))
+			return
+		}
+
+		if len(errs) > 0 {
+			writeValidationErrors(response, "could not update recurring rule", errs)
+			return
+		}
+
+		*update.Spend, errs, err = models.ResolveSpendCategories(request.Context(), *update.Spend)
+		if err != nil {
+			response.WriteHeader(http.StatusInternalServerError)
+			response.Write([]byte(`{"message": "could not update recurring rule", "details": "` + err.Error() + `"}`))
+			return
+		}
+
+		if len(errs) > 0 {
+			writeValidationErrors(response, "could not update recurring rule", errs)
+			return
+		}
+	}
+
+	err = models.UpdateRecurringRule(request.Context(), params["id"], update)
+	if err != nil {
+		response.WriteHeader(http.StatusBadRequest)
+		response.Write([]byte(`{"message": "could not update recurring rule", "details": "` + err.Error() + `"}`))
+		return
+	}
+
+	response.WriteHeader(http.StatusOK)
+	response.Write([]byte(`{"message": "updated recurring rule '` + params["id"] + `'"}`))
+}
+
+// PauseRecurringRuleEndpoint will stop materializing a recurring rule given an ID
+func PauseRecurringRuleEndpoint(response http.ResponseWriter, request *http.Request) {
+	response.Header().Add("content-type", "application/json")
+	response.Header().Add("backend", "budget-tracker")
+
+	params := mux.Vars(request)
+
+	err := models.PauseRecurringRule(request.Context(), params["id"], true)
+	if err != nil {
+		response.WriteHeader(http.StatusInternalServerError)
+		response.Write([]byte(`{"message": "could not pause recurring rule", "details": "` + err.Error() + `"}`))
+		return
+	}
+
+	response.WriteHeader(http.StatusOK)
+	response.Write([]byte(`{"message": "paused recurring rule '` + params["id"] + `'"}`))
+}
+
+// ResumeRecurringRuleEndpoint will resume materializing a paused recurring rule given an ID
+func ResumeRecurringRuleEndpoint(response http.ResponseWriter, request *http.Request) {
+	response.Header().Add("content-type", "application/json")
+	response.Header().Add("backend", "budget-tracker")
+
+	params := mux.Vars(request)
+
+	err := models.PauseRecurringRule(request.Context(), params["id"], false)
+	if err != nil {
+		response.WriteHeader(http.StatusInternalServerError)
+		response.Write([]byte(`{"message": "could not resume recurring rule", "details": "` + err.Error() + `"}`))
+		return
+	}
+
+	response.WriteHeader(http.StatusOK)
+	response.Write([]byte(`{"message": "resumed recurring rule '` + params["id"] + `'"}`))
+}
@@ -0,0 +1,170 @@
+package controllers
+
+import (
+	"budget-tracker-api/models"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// CreateRefundEndpoint will create a refund or reimbursement for a spend. Refunds are credited to their
+// month balance right away, reimbursements once received
+func CreateRefundEndpoint(response http.ResponseWriter, request *http.Request) {
+	response.Header().Add("content-type", "application/json")
+	response.Header().Add("backend", "budget-tracker")
+
+	var refund models.Refund
+
+	err := json.NewDecoder(request.Body).Decode(&refund)
+	if err != nil {
+		response.WriteHeader(http.StatusBadRequest)
+		response.Write([]byte(`{"message": "could not create refund", "details": "malformed payload"}`))
+		return
+	}
+
+	errs, err := models.ValidateRefund(request.Context(), refund)
+	if err != nil {
+		response.WriteHeader(http.StatusInternalServerError)
+		response.Write([]byte(`{"message": "could not create refund", "details": "` + err.Error() + `"}`))
+		return
+	}
+
+	if len(errs) > 0 {
+		writeValidationErrors(response, "could not create refund", errs)
+		return
+	}
+
+	result, err := models.CreateRefund(request.Context(), refund)
+	if err == models.ErrBalanceClosed {
+		response.WriteHeader(http.StatusConflict)
+		response.Write([]byte(`{"message": "could not create refund", "details": "` + err.Error() + `"}`))
+		return
+	}
+
+	if err != nil {
+		response.WriteHeader(http.StatusBadRequest)
+		response.Write([]byte(`{"message": "could not create refund", "details": "` + err.Error() + `"}`))
+		return
+	}
+
+	response.WriteHeader(http.StatusCreated)
+	response.Write([]byte(`{"message": "created ` + refund.Kind + ` for spend '` + refund.SpendID.Hex() + `'", "id": "` + result + `"}`))
+}
+
+// GetRefundsEndpoint will return the refunds and reimbursements from an user, optionally filtered by
+// status, kind and spend
+func GetRefundsEndpoint(response http.ResponseWriter, request *http.Request) {
+	response.Header().Add("content-type", "application/json")
+	response.Header().Add("backend", "budget-tracker")
+
+	params := mux.Vars(request)
+	query := request.URL.Query()
+
+	refunds, err := models.GetRefunds(request.Context(), params["owner_id"], query.Get("status"), query.Get("kind"), query.Get("spend_id"))
+	if err != nil {
+		response.WriteHeader(http.StatusInternalServerError)
+		response.Write([]byte(`{"message": "could not get refunds", "details": "` + err.Error() + `"}`))
+		return
+	}
+
+	if len(refunds) == 0 {
+		response.Write([]byte(`[]`))
+		return
+	}
+
+	json.NewEncoder(response).Encode(refunds)
+}
+
+// GetReceivablesEndpoint will return the reimbursements an user is still waiting for
+func GetReceivablesEndpoint(response http.ResponseWriter, request *http.Request) {
+	response.Header().Add("content-type", "application/json")
+	response.Header().Add("backend", "budget-tracker")
+
+	params := mux.Vars(request)
+
+	receivables, err := models.GetReceivables(request.Context(), params["owner_id"], time.Now())
+	if err != nil {
+		response.WriteHeader(http.StatusInternalServerError)
+		response.Write([]byte(`{"message": "could not get receivables", "details": "` + err.Error() + `"}`))
+		return
+	}
+
+	json.NewEncoder(response).Encode(receivables)
+}
+
+// ReceiveRefundEndpoint will mark a pending reimbursement as received, crediting it to the balance of
+// the month it was received
+func ReceiveRefundEndpoint(response http.ResponseWriter, request *http.Request) {
+	response.Header().Add("content-type", "application/json")
+	response.Header().Add("backend", "budget-tracker")
+
+	params := mux.Vars(request)
+
+	loc, err := parseTimezoneParam(request)
+	if err != nil {
+		response.WriteHeader(http.StatusBadRequest)
+		response.Write([]byte(`{"message": "could not receive refund", "details": "` + err.Error() + `"}`))
+		return
+	}
+
+	date, err := parseDateParam(request.URL.Query().Get("date"), false, loc)
+	if err != nil {
+		response.WriteHeader(http.StatusBadRequest)
+		response.Write([]byte(`{"message": "could not receive refund", "details": "'date' must be RFC3339 or YYYY-MM-DD"}`))
+		return
+	}
+
+	refund, err := models.ReceiveRefund(request.Context(), params["id"], date)
+	if err == mongo.ErrNoDocuments {
+		response.WriteHeader(http.StatusNotFound)
+		response.Write([]byte(`{"message": "could not receive refund", "details": "non existent refund"}`))
+		return
+	}
+
+	if err == models.ErrBalanceClosed || err == models.ErrRefundReceived {
+		response.WriteHeader(http.StatusConflict)
+		response.Write([]byte(`{"message": "could not receive refund", "details": "` + err.Error() + `"}`))
+		return
+	}
+
+	if err != nil {
+		response.WriteHeader(http.StatusBadRequest)
+		response.Write([]byte(`{"message": "could not receive refund", "details": "` + err.Error() + `"}`))
+		return
+	}
+
+	json.NewEncoder(response).Encode(refund)
+}
+
+// DeleteRefundEndpoint will delete a pending reimbursement given an ID
+func DeleteRefundEndpoint(response http.ResponseWriter, request *http.Request) {
+	response.Header().Add("content-type", "application/json")
+	response.Header().Add("backend", "budget-tracker")
+
+	params := mux.Vars(request)
+
+	err := models.DeleteRefund(request.Context(), params["id"])
+	if err == mongo.ErrNoDocuments {
+		response.WriteHeader(http.StatusNotFound)
+		response.Write([]byte(`{"message": "could not delete refund", "details": "non existent refund"}`))
+		return
+	}
+
+	if err == models.ErrRefundReceived {
+		response.WriteHeader(http.StatusConflict)
+		response.Write([]byte(`{"message": "could not delete refund", "details": "` + err.Error() + `"}`))
+		return
+	}
+
+	if err != nil {
+		response.WriteHeader(http.StatusInternalServerError)
+		response.Write([]byte(`{"message": "could not delete refund", "details": "` + err.Error() + `"}`))
+		return
+	}
+
+	response.WriteHeader(http.StatusOK)
+	response.Write([]byte(`{"message": "deleted refund '` + params["id"] + `'"}`))
+}
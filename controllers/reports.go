@@ -0,0 +1,97 @@
+package controllers
+
+import (
+	"budget-tracker-api/models"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// GetReportEndpoint will return the totals spent by an user grouped by category, payment method, card or
+// type, optionally by period as well
+func GetReportEndpoint(response http.ResponseWriter, request *http.Request) {
+	response.Header().Add("content-type", "application/json")
+	response.Header().Add("backend", "budget-tracker")
+
+	params := mux.Vars(request)
+	v := request.URL.Query()
+
+	q := models.ReportQuery{
+		OwnerID:     params["owner_id"],
+		GroupBy:     v.Get("group_by"),
+		Granularity: v.Get("granularity"),
+		Timezone:    v.Get("timezone"),
+	}
+
+	if q.GroupBy == "" {
+		q.GroupBy = "category"
+	}
+
+	loc, err := parseTimezoneParam(request)
+	if err != nil {
+		response.WriteHeader(http.StatusBadRequest)
+		response.Write([]byte(`{"message": "could not get report", "details": "` + err.Error() + `"}`))
+		return
+	}
+
+	q.From, err = parseDateParam(v.Get("from"), false, loc)
+	if err != nil {
+		response.WriteHeader(http.StatusBadRequest)
+		response.Write([]byte(`{"message": "could not get report", "details": "invalid 'from' date"}`))
+		return
+	}
+
+	q.To, err = parseDateParam(v.Get("to"), true, loc)
+	if err != nil {
+		response.WriteHeader(http.StatusBadRequest)
+		response.Write([]byte(`{"message": "could not get report", "details": "invalid 'to' date"}`))
+		return
+	}
+
+	report, err := models.GetReport(request.Context(), q)
+	if err != nil {
+		response.WriteHeader(http.StatusBadRequest)
+		response.Write([]byte(`{"message": "could not get report", "details": "` + err.Error() + `"}`))
+		return
+	}
+
+	json.NewEncoder(response).Encode(report)
+}
+
+// CompareReportEndpoint will compare the totals spent by an user on a month with the previous month and the
+// same month from the previous year. Defaults to the current month
+func CompareReportEndpoint(response http.ResponseWriter, request *http.Request) {
+	response.Header().Add("content-type", "application/json")
+	response.Header().Add("backend", "budget-tracker")
+
+	params := mux.Vars(request)
+	v := request.URL.Query()
+
+	groupBy := v.Get("group_by")
+	if groupBy == "" {
+		groupBy = "category"
+	}
+
+	now := time.Now()
+	month, year := int64(now.Month()), int64(now.Year())
+	if v.Get("month") != "" || v.Get("year") != "" {
+		var err error
+		month, year, err = parsePeriodParams(request)
+		if err != nil {
+			response.WriteHeader(http.StatusBadRequest)
+			response.Write([]byte(`{"message": "could not compare report", "details": "` + err.Error() + `"}`))
+			return
+		}
+	}
+
+	comparison, err := models.CompareReport(request.Context(), params["owner_id"], groupBy, month, year)
+	if err != nil {
+		response.WriteHeader(http.StatusBadRequest)
+		response.Write([]byte(`{"message": "could not compare report", "details": "` + err.Error() + `"}`))
+		return
+	}
+
+	json.NewEncoder(response).Encode(comparison)
+}
@@ -0,0 +1,92 @@
+package controllers
+
+import (
+	"budget-tracker-api/models"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// SearchSpendsEndpoint will search the spends from an user by the text of the 'q' URL param, the most
+// relevant ones first, optionally filtered by date and cost
+func SearchSpendsEndpoint(response http.ResponseWriter, request *http.Request) {
+	response.Header().Add("content-type", "application/json")
+	response.Header().Add("backend", "budget-tracker")
+
+	params := mux.Vars(request)
+	v := request.URL.Query()
+
+	q := models.SpendsSearch{OwnerID: params["owner_id"], Text: strings.TrimSpace(v.Get("q"))}
+	if q.Text == "" {
+		response.WriteHeader(http.StatusBadRequest)
+		response.Write([]byte(`{"message": "could not search spends", "details": "missing 'q' param"}`))
+		return
+	}
+
+	if v.Get("min_cost") != "" {
+		minCost, err := strconv.ParseFloat(v.Get("min_cost"), 64)
+		if err != nil {
+			response.WriteHeader(http.StatusBadRequest)
+			response.Write([]byte(`{"message": "could not search spends", "details": "invalid 'min_cost' value"}`))
+			return
+		}
+		q.MinCost = &minCost
+	}
+
+	if v.Get("max_cost") != "" {
+		maxCost, err := strconv.ParseFloat(v.Get("max_cost"), 64)
+		if err != nil {
+			response.WriteHeader(http.StatusBadRequest)
+			response.Write([]byte(`{"message": "could not search spends", "details": "invalid 'max_cost' value"}`))
+			return
+		}
+		q.MaxCost = &maxCost
+	}
+
+	loc, err := parseTimezoneParam(request)
+	if err != nil {
+		response.WriteHeader(http.StatusBadRequest)
+		response.Write([]byte(`{"message": "could not search spends", "details": "` + err.Error() + `"}`))
+		return
+	}
+
+	q.From, err = parseDateParam(v.Get("from"), false, loc)
+	if err != nil {
+		response.WriteHeader(http.StatusBadRequest)
+		response.Write([]byte(`{"message": "could not search spends", "details": "invalid 'from' date"}`))
+		return
+	}
+
+	q.To, err = parseDateParam(v.Get("to"), true, loc)
+	if err != nil {
+		response.WriteHeader(http.StatusBadRequest)
+		response.Write([]byte(`{"message": "could not search spends", "details": "invalid 'to' date"}`))
+		return
+	}
+
+	if v.Get("limit") != "" {
+		q.Limit, err = strconv.ParseInt(v.Get("limit"), 10, 64)
+		if err != nil || q.Limit <= 0 {
+			response.WriteHeader(http.StatusBadRequest)
+			response.Write([]byte(`{"message": "could not search spends", "details": "invalid 'limit' value"}`))
+			return
+		}
+	}
+
+	results, err := models.SearchSpends(request.Context(), q)
+	if err != nil {
+		response.WriteHeader(http.StatusInternalServerError)
+		response.Write([]byte(`{"message": "could not search spends", "details": "` + err.Error() + `"}`))
+		return
+	}
+
+	if len(results) == 0 {
+		response.Write([]byte(`[]`))
+		return
+	}
+
+	json.NewEncoder(response).Encode(results)
+}
@@ -0,0 +1,68 @@
+package controllers
+
+import (
+	"budget-tracker-api/models"
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// GetShareRequestsEndpoint will return the pending shares other users split with an user
+func GetShareRequestsEndpoint(response http.ResponseWriter, request *http.Request) {
+	response.Header().Add("content-type", "application/json")
+	response.Header().Add("backend", "budget-tracker")
+
+	params := mux.Vars(request)
+
+	requests, err := models.GetShareRequests(request.Context(), params["owner_id"])
+	if err != nil {
+		response.WriteHeader(http.StatusInternalServerError)
+		response.Write([]byte(`{"message": "could not get shares", "details": "` + err.Error() + `"}`))
+		return
+	}
+
+	json.NewEncoder(response).Encode(requests)
+}
+
+// AcceptShareEndpoint will accept the pending share of a spend, booking it to the user who owes it
+func AcceptShareEndpoint(response http.ResponseWriter, request *http.Request) {
+	answerShare(response, request, "accept", models.AcceptShare)
+}
+
+// DeclineShareEndpoint will decline the pending share of a spend, booking it back to the spend owner
+func DeclineShareEndpoint(response http.ResponseWriter, request *http.Request) {
+	answerShare(response, request, "decline", models.DeclineShare)
+}
+
+// answerShare will answer the pending share of a spend with a given models function, returning the
+// booked part
+func answerShare(response http.ResponseWriter, request *http.Request, action string, answer func(context.Context, string, string) (models.Spend, error)) {
+	response.Header().Add("content-type", "application/json")
+	response.Header().Add("backend", "budget-tracker")
+
+	params := mux.Vars(request)
+
+	part, err := answer(request.Context(), params["spend_id"], params["owner_id"])
+	if err == mongo.ErrNoDocuments {
+		response.WriteHeader(http.StatusNotFound)
+		response.Write([]byte(`{"message": "could not ` + action + ` share", "details": "non existent share"}`))
+		return
+	}
+
+	if err == models.ErrShareAnswered || err == models.ErrBalanceClosed {
+		response.WriteHeader(http.StatusConflict)
+		response.Write([]byte(`{"message": "could not ` + action + ` share", "details": "` + err.Error() + `"}`))
+		return
+	}
+
+	if err != nil {
+		response.WriteHeader(http.StatusInternalServerError)
+		response.Write([]byte(`{"message": "could not ` + action + ` share", "details": "` + err.Error() + `"}`))
+		return
+	}
+
+	json.NewEncoder(response).Encode(part)
+}
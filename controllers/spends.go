@@ -1,13 +1,59 @@
 package controllers
 
 import (
+	"budget-tracker-api/attachments"
 	"budget-tracker-api/models"
 	"encoding/json"
+	"errors"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gorilla/mux"
+	log "github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
+// parseTimezoneParam will parse the 'timezone' query param as an IANA timezone, defaulting to UTC
+func parseTimezoneParam(request *http.Request) (*time.Location, error) {
+	timezone := request.URL.Query().Get("timezone")
+	if timezone == "" {
+		return time.UTC, nil
+	}
+
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return nil, errors.New("invalid 'timezone' value")
+	}
+
+	return loc, nil
+}
+
+// parseDateParam will parse a date query param either as RFC3339 or as a plain date (YYYY-MM-DD) at a
+// given timezone. Plain dates used as the end of a range will include the whole day
+func parseDateParam(value string, endOfDay bool, loc *time.Location) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+	if err == nil {
+		return t, nil
+	}
+
+	t, err = time.ParseInLocation("2006-01-02", value, loc)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	if endOfDay {
+		t = t.AddDate(0, 0, 1).Add(-time.Nanosecond)
+	}
+
+	return t, nil
+}
+
 // CreateSpendEndpoint will create a spend and add to the current month balance
 func CreateSpendEndpoint(response http.ResponseWriter, request *http.Request) {
 	response.Header().Add("content-type", "application/json")
@@ -17,39 +63,159 @@ func CreateSpendEndpoint(response http.ResponseWriter, request *http.Request) {
 
 	_ = json.NewDecoder(request.Body).Decode(&spend)
 
-	if spend.OwnerID.Hex() == "000000000000000000000000" {
-		response.WriteHeader(http.StatusBadRequest)
-		response.Write([]byte(`{"message": "could not create spend", "details": "missing owner ID"}`))
+	spend, booked, errs, err := models.RegisterSpend(request.Context(), spend)
+	if err == models.ErrBalanceClosed {
+		response.WriteHeader(http.StatusConflict)
+		response.Write([]byte(`{"message": "could not create spend", "details": "` + err.Error() + `"}`))
 		return
 	}
 
-	result, err := models.CreateSpend(request.Context(), spend)
 	if err != nil {
 		response.WriteHeader(http.StatusInternalServerError)
 		response.Write([]byte(`{"message": "could not create spend", "details": "` + err.Error() + `"}`))
 		return
 	}
 
-	// add spend to balance
+	if len(errs) > 0 {
+		writeValidationErrors(response, "could not create spend", errs)
+		return
+	}
+
+	message := "created spend to user '" + spend.OwnerID.Hex() + "'"
+	if spend.Installments != 0 {
+		message += " in " + strconv.Itoa(len(booked)) + " installments"
+	} else if len(spend.Lines) > 0 || len(spend.Shares) > 0 {
+		message += " split into " + strconv.Itoa(len(booked)) + " parts"
+		if len(spend.Shares) > 0 {
+			message += " and " + strconv.Itoa(len(spend.Shares)) + " pending shares"
+		}
+	}
 
 	response.WriteHeader(http.StatusCreated)
-	response.Write([]byte(`{"message": "created spend to user '` + spend.OwnerID.Hex() + `'", "id": "` + result + `"}`))
+	response.Write([]byte(`{"message": "` + message + `", "id": "` + spend.ID.Hex() + `"}`))
 }
 
-// GetSpendsEndpoint will return all spends from an user
-func GetSpendsEndpoint(response http.ResponseWriter, request *http.Request) {
+// GetInstallmentsEndpoint will return the remaining installments from an user grouped by card
+func GetInstallmentsEndpoint(response http.ResponseWriter, request *http.Request) {
 	response.Header().Add("content-type", "application/json")
 	response.Header().Add("backend", "budget-tracker")
 
 	params := mux.Vars(request)
 
-	spends, err := models.GetSpends(request.Context(), params["owner_id"])
+	cards, err := models.GetCardsInstallments(request.Context(), params["owner_id"], request.URL.Query().Get("card_id"))
+	if err != nil {
+		response.WriteHeader(http.StatusInternalServerError)
+		response.Write([]byte(`{"message": "` + err.Error() + `"}`))
+		return
+	}
+
+	if len(cards) == 0 {
+		response.Write([]byte(`[]`))
+		return
+	}
+
+	json.NewEncoder(response).Encode(cards)
+}
+
+// parseSpendsQuery will build a spends query based on request URL params
+func parseSpendsQuery(request *http.Request) (q models.SpendsQuery, err error) {
+	params := mux.Vars(request)
+	v := request.URL.Query()
+
+	q.OwnerID = params["owner_id"]
+	q.Categories = v["category"]
+	q.CategoryIDs = v["category_id"]
+	q.Tags = v["tag"]
+	q.Type = v.Get("type")
+	q.PaymentMethod = v.Get("payment_method")
+
+	q.CardID = v.Get("card_id")
+	q.Description = v.Get("description")
+	q.Cursor = v.Get("cursor")
+
+	if v.Get("min_cost") != "" {
+		minCost, err := strconv.ParseFloat(v.Get("min_cost"), 64)
+		if err != nil {
+			return q, errors.New("invalid 'min_cost' value")
+		}
+		q.MinCost = &minCost
+	}
+
+	if v.Get("max_cost") != "" {
+		maxCost, err := strconv.ParseFloat(v.Get("max_cost"), 64)
+		if err != nil {
+			return q, errors.New("invalid 'max_cost' value")
+		}
+		q.MaxCost = &maxCost
+	}
+
+	loc, err := parseTimezoneParam(request)
+	if err != nil {
+		return q, err
+	}
+
+	q.From, err = parseDateParam(v.Get("from"), false, loc)
+	if err != nil {
+		return q, errors.New("invalid 'from' date")
+	}
+
+	q.To, err = parseDateParam(v.Get("to"), true, loc)
+	if err != nil {
+		return q, errors.New("invalid 'to' date")
+	}
+
+	// sorting by the most recent spends unless stated otherwise, a '-' prefix means descending order
+	sort := v.Get("sort")
+	if sort == "" {
+		sort = "-date"
+	}
+	q.SortDesc = strings.HasPrefix(sort, "-")
+	q.SortBy = strings.TrimPrefix(sort, "-")
+
+	if v.Get("limit") != "" {
+		q.Limit, err = strconv.ParseInt(v.Get("limit"), 10, 64)
+		if err != nil || q.Limit <= 0 {
+			return q, errors.New("invalid 'limit' value")
+		}
+	}
+
+	return q, models.ValidateSpendsQuery(q)
+}
+
+// nextPageLink will return the current request URL pointing to a given cursor
+func nextPageLink(request *http.Request, cursor string) string {
+	u := *request.URL
+	v := u.Query()
+	v.Set("cursor", cursor)
+	u.RawQuery = v.Encode()
+
+	return `<` + u.RequestURI() + `>; rel="next"`
+}
+
+// GetSpendsEndpoint will return a page of spends from an user, filtered and sorted by URL params.
+// A 'Link' header will point to the next page when there are more spends to be listed
+func GetSpendsEndpoint(response http.ResponseWriter, request *http.Request) {
+	response.Header().Add("content-type", "application/json")
+	response.Header().Add("backend", "budget-tracker")
+
+	q, err := parseSpendsQuery(request)
+	if err != nil {
+		response.WriteHeader(http.StatusBadRequest)
+		response.Write([]byte(`{"message": "could not list spends", "details": "` + err.Error() + `"}`))
+		return
+	}
+
+	spends, next, err := models.GetSpends(request.Context(), q)
 	if err != nil {
 		response.WriteHeader(http.StatusInternalServerError)
 		response.Write([]byte(`{"message": "` + err.Error() + `"}`))
 		return
 	}
 
+	if next != "" {
+		response.Header().Set("Link", nextPageLink(request, next))
+	}
+
 	if len(spends) == 0 {
 		response.Write([]byte(`[]`))
 		return
@@ -57,3 +223,113 @@ func GetSpendsEndpoint(response http.ResponseWriter, request *http.Request) {
 
 	json.NewEncoder(response).Encode(spends)
 }
+
+// DeleteSpendEndpoint will delete a spend given its ID, removing it from its balance along with its
+// attachments. Deleting an installment plan or a split spend deletes all its installments or parts
+func DeleteSpendEndpoint(response http.ResponseWriter, request *http.Request) {
+	response.Header().Add("content-type", "application/json")
+	response.Header().Add("backend", "budget-tracker")
+
+	params := mux.Vars(request)
+
+	deleted, err := models.DeleteSpend(request.Context(), params["id"])
+	if err == mongo.ErrNoDocuments {
+		response.WriteHeader(http.StatusNotFound)
+		response.Write([]byte(`{"message": "could not delete spend", "details": "non existent spend"}`))
+		return
+	}
+
+	if err == models.ErrBalanceClosed || err == models.ErrSpendRefunded || err == models.ErrSplitPart {
+		response.WriteHeader(http.StatusConflict)
+		response.Write([]byte(`{"message": "could not delete spend", "details": "` + err.Error() + `"}`))
+		return
+	}
+
+	if err != nil {
+		response.WriteHeader(http.StatusInternalServerError)
+		response.Write([]byte(`{"message": "could not delete spend", "details": "` + err.Error() + `"}`))
+		return
+	}
+
+	err = attachments.RemoveSpends(request.Context(), deleted)
+	if err != nil {
+		log.Errorln("could not delete attachments from spend", params["id"], ":", err)
+	}
+
+	response.WriteHeader(http.StatusOK)
+	response.Write([]byte(`{"message": "deleted spend '` + params["id"] + `'"}`))
+}
+
+// UpdateSpendEndpoint will update the tags and notes from a spend given its ID
+func UpdateSpendEndpoint(response http.ResponseWriter, request *http.Request) {
+	response.Header().Add("content-type", "application/json")
+	response.Header().Add("backend", "budget-tracker")
+
+	params := mux.Vars(request)
+
+	var update models.SpendUpdate
+
+	err := json.NewDecoder(request.Body).Decode(&update)
+	if err != nil {
+		response.WriteHeader(http.StatusBadRequest)
+		response.Write([]byte(`{"message": "could not update spend", "details": "malformed payload"}`))
+		return
+	}
+
+	errs := models.ValidateSpendUpdate(update)
+	if len(errs) > 0 {
+		writeValidationErrors(response, "could not update spend", errs)
+		return
+	}
+
+	err = models.UpdateSpend(request.Context(), params["id"], update)
+	if err == mongo.ErrNoDocuments {
+		response.WriteHeader(http.StatusNotFound)
+		response.Write([]byte(`{"message": "could not update spend", "details": "non existent spend"}`))
+		return
+	}
+
+	if err != nil {
+		response.WriteHeader(http.StatusInternalServerError)
+		response.Write([]byte(`{"message": "could not update spend", "details": "` + err.Error() + `"}`))
+		return
+	}
+
+	response.WriteHeader(http.StatusOK)
+	response.Write([]byte(`{"message": "updated spend '` + params["id"] + `'"}`))
+}
+
+// GetTagsEndpoint will return the tags from an user starting with the 'prefix' URL param, the most used
+// ones first, for autocompletion
+func GetTagsEndpoint(response http.ResponseWriter, request *http.Request) {
+	response.Header().Add("content-type", "application/json")
+	response.Header().Add("backend", "budget-tracker")
+
+	params := mux.Vars(request)
+	v := request.URL.Query()
+
+	var limit int64
+	var err error
+	if v.Get("limit") != "" {
+		limit, err = strconv.ParseInt(v.Get("limit"), 10, 64)
+		if err != nil || limit <= 0 {
+			response.WriteHeader(http.StatusBadRequest)
+			response.Write([]byte(`{"message": "could not list tags", "details": "invalid 'limit' value"}`))
+			return
+		}
+	}
+
+	tags, err := models.GetTags(request.Context(), params["owner_id"], v.Get("prefix"), limit)
+	if err != nil {
+		response.WriteHeader(http.StatusInternalServerError)
+		response.Write([]byte(`{"message": "` + err.Error() + `"}`))
+		return
+	}
+
+	if len(tags) == 0 {
+		response.Write([]byte(`[]`))
+		return
+	}
+
+	json.NewEncoder(response).Encode(tags)
+}
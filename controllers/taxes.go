@@ -0,0 +1,36 @@
+package controllers
+
+import (
+	"budget-tracker-api/taxes"
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+// CalculateNetIncomeEndpoint will derive a net salary from a gross one, withholding INSS and IRRF
+func CalculateNetIncomeEndpoint(response http.ResponseWriter, request *http.Request) {
+	response.Header().Add("content-type", "application/json")
+	response.Header().Add("backend", "budget-tracker")
+
+	var input taxes.Input
+
+	err := json.NewDecoder(request.Body).Decode(&input)
+	if err != nil {
+		response.WriteHeader(http.StatusBadRequest)
+		response.Write([]byte(`{"message": "could not calculate net income", "details": "malformed payload"}`))
+		return
+	}
+
+	if input.Year == 0 {
+		input.Year = time.Now().Year()
+	}
+
+	result, err := taxes.Calculate(input)
+	if err != nil {
+		response.WriteHeader(http.StatusBadRequest)
+		response.Write([]byte(`{"message": "could not calculate net income", "details": "` + err.Error() + `"}`))
+		return
+	}
+
+	json.NewEncoder(response).Encode(result)
+}
@@ -6,11 +6,14 @@ import (
 	"net/http"
 
 	"github.com/gorilla/mux"
+	log "github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 // CreateUserEndpoint creates an user
 func CreateUserEndpoint(response http.ResponseWriter, request *http.Request) {
 	response.Header().Add("content-type", "application/json")
+	response.Header().Add("backend", "budget-tracker")
 
 	var user models.User
 
@@ -23,6 +26,12 @@ func CreateUserEndpoint(response http.ResponseWriter, request *http.Request) {
 		return
 	}
 
+	oid, _ := primitive.ObjectIDFromHex(result)
+	err = models.SeedDefaultCategories(request.Context(), oid)
+	if err != nil {
+		log.Warnln("could not seed default categories to user", result, ":", err)
+	}
+
 	response.WriteHeader(http.StatusCreated)
 	response.Write([]byte(`{"message": "created user '` + user.Login + `'", "id": "` + result + `"}`))
 }
@@ -30,6 +39,7 @@ func CreateUserEndpoint(response http.ResponseWriter, request *http.Request) {
 // GetUsersEndpoint returns a collection of user
 func GetUsersEndpoint(response http.ResponseWriter, request *http.Request) {
 	response.Header().Add("content-type", "application/json")
+	response.Header().Add("backend", "budget-tracker")
 
 	users, err := models.GetUsers(request.Context())
 	if err != nil {
@@ -49,6 +59,7 @@ func GetUsersEndpoint(response http.ResponseWriter, request *http.Request) {
 // GetUserEndpoint an unique user
 func GetUserEndpoint(response http.ResponseWriter, request *http.Request) {
 	response.Header().Add("content-type", "application/json")
+	response.Header().Add("backend", "budget-tracker")
 	params := mux.Vars(request)
 
 	user, err := models.GetUser(request.Context(), params["id"])
@@ -64,6 +75,7 @@ func GetUserEndpoint(response http.ResponseWriter, request *http.Request) {
 // DeleteUserEndpoint deletes an user
 func DeleteUserEndpoint(response http.ResponseWriter, request *http.Request) {
 	response.Header().Add("content-type", "application/json")
+	response.Header().Add("backend", "budget-tracker")
 
 	params := mux.Vars(request)
 
@@ -0,0 +1,16 @@
+package controllers
+
+import (
+	"budget-tracker-api/models"
+	"encoding/json"
+	"net/http"
+)
+
+// writeValidationErrors will respond with all invalid attributes from a request payload
+func writeValidationErrors(response http.ResponseWriter, message string, errs []models.FieldError) {
+	response.WriteHeader(http.StatusUnprocessableEntity)
+	json.NewEncoder(response).Encode(models.ValidationErrors{
+		Message: message,
+		Errors:  errs,
+	})
+}
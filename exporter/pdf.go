@@ -0,0 +1,271 @@
+package exporter
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+const (
+	// A4 page size and margins, in points
+	pdfPageWidth  = 595.28
+	pdfPageHeight = 841.89
+	pdfMargin     = 40.0
+
+	pdfFontSize  = 9.0
+	pdfRowHeight = 14.0
+)
+
+// helveticaWidths defines the widths of Helvetica printable ASCII characters, in thousandths of the font size
+var helveticaWidths = []int{
+	278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+	1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+	333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+	556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+}
+
+// winAnsiExtras defines the characters of Windows-1252 outside of Latin-1
+var winAnsiExtras = map[rune]byte{
+	'€': 0x80, '‚': 0x82, '„': 0x84, '…': 0x85, '‘': 0x91, '’': 0x92, '“': 0x93, '”': 0x94, '•': 0x95, '–': 0x96, '—': 0x97,
+}
+
+// textWidth will approximate the width of a text written in Helvetica
+func textWidth(text string, size float64) float64 {
+	width := 0
+	for _, r := range text {
+		if r >= ' ' && r <= '~' {
+			width += helveticaWidths[r-' ']
+		} else {
+			width += 556
+		}
+	}
+	return float64(width) * size / 1000
+}
+
+// fitText will truncate a text so it fits a given width
+func fitText(text string, width float64, size float64) string {
+	if textWidth(text, size) <= width {
+		return text
+	}
+
+	runes := []rune(text)
+	for len(runes) > 0 && textWidth(string(runes)+"...", size) > width {
+		runes = runes[:len(runes)-1]
+	}
+	return string(runes) + "..."
+}
+
+// pdfString will encode a text as a PDF literal string in WinAnsiEncoding
+func pdfString(text string) string {
+	var b strings.Builder
+	b.WriteByte('(')
+	for _, r := range text {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r >= ' ' && r <= '~':
+			b.WriteRune(r)
+		case r >= 0xa0 && r <= 0xff:
+			fmt.Fprintf(&b, "\\%03o", r)
+		case winAnsiExtras[r] != 0:
+			fmt.Fprintf(&b, "\\%03o", winAnsiExtras[r])
+		default:
+			b.WriteByte('?')
+		}
+	}
+	b.WriteByte(')')
+	return b.String()
+}
+
+// pdfTable defines a PDF document listing rows in a table under a title and summary lines. Pages are
+// written as soon as they are filled, so only the current page is held in memory
+type pdfTable struct {
+	w       io.Writer
+	offset  int
+	objects map[int]int
+	pages   []int
+	next    int
+
+	title   string
+	summary []string
+	columns []string
+	widths  []float64
+
+	page *bytes.Buffer
+	y    float64
+}
+
+// NewPDF will start a PDF document whose first page has a title and summary lines, followed by a table
+// with the given columns. Column widths are fractions of the page width, amounts are right aligned
+func NewPDF(w io.Writer, title string, summary []string, columns []string, widths []float64) (Table, error) {
+	t := &pdfTable{
+		w:       w,
+		objects: map[int]int{},
+		// catalog and pages tree are the objects 1 and 2, fonts the objects 3 and 4
+		next:    5,
+		title:   title,
+		summary: summary,
+		columns: columns,
+		widths:  widths,
+	}
+
+	err := t.out("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	if err != nil {
+		return nil, err
+	}
+
+	err = t.object(3, "<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	if err != nil {
+		return nil, err
+	}
+
+	err = t.object(4, "<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+	if err != nil {
+		return nil, err
+	}
+
+	t.newPage()
+
+	t.text(pdfMargin, t.y, 16, true, title)
+	t.y -= 28
+	for _, line := range summary {
+		t.text(pdfMargin, t.y, 10, false, line)
+		t.y -= pdfRowHeight
+	}
+	t.y -= pdfRowHeight
+
+	t.tableHeader()
+	return t, nil
+}
+
+// out will write raw content to the document, keeping track of its offset
+func (t *pdfTable) out(content string) error {
+	n, err := io.WriteString(t.w, content)
+	t.offset += n
+	return err
+}
+
+// object will write an indirect object to the document
+func (t *pdfTable) object(id int, content string) error {
+	t.objects[id] = t.offset
+	return t.out(fmt.Sprintf("%d 0 obj\n%s\nendobj\n", id, content))
+}
+
+// text will add a text to the current page
+func (t *pdfTable) text(x float64, y float64, size float64, bold bool, text string) {
+	font := "/F1"
+	if bold {
+		font = "/F2"
+	}
+	fmt.Fprintf(t.page, "BT %s %.1f Tf %.2f %.2f Td %s Tj ET\n", font, size, x, y, pdfString(text))
+}
+
+// newPage will start a new page
+func (t *pdfTable) newPage() {
+	t.page = &bytes.Buffer{}
+	t.y = pdfPageHeight - pdfMargin - 16
+
+	number := fmt.Sprintf("Page %d", len(t.pages)+1)
+	t.text(pdfPageWidth-pdfMargin-textWidth(number, 8), pdfMargin/2, 8, false, number)
+}
+
+// tableHeader will write the table columns at the current page
+func (t *pdfTable) tableHeader() {
+	x := pdfMargin
+	for i, column := range t.columns {
+		width := t.widths[i] * (pdfPageWidth - 2*pdfMargin)
+		t.text(x, t.y, pdfFontSize, true, fitText(column, width-4, pdfFontSize))
+		x += width
+	}
+
+	fmt.Fprintf(t.page, "0.5 w %.2f %.2f m %.2f %.2f l S\n", pdfMargin, t.y-4, pdfPageWidth-pdfMargin, t.y-4)
+	t.y -= pdfRowHeight + 2
+}
+
+// flushPage will write the current page and its contents to the document
+func (t *pdfTable) flushPage() error {
+	contents, page := t.next, t.next+1
+	t.next += 2
+
+	err := t.object(contents, fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", t.page.Len(), t.page.String()))
+	if err != nil {
+		return err
+	}
+
+	err = t.object(page, fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.2f %.2f] /Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>", pdfPageWidth, pdfPageHeight, contents))
+	if err != nil {
+		return err
+	}
+
+	t.pages = append(t.pages, page)
+	return nil
+}
+
+// Write will add a table row, starting a new page when the current one is full
+func (t *pdfTable) Write(row []interface{}) error {
+	if t.y < pdfMargin+pdfRowHeight {
+		err := t.flushPage()
+		if err != nil {
+			return err
+		}
+
+		t.newPage()
+		t.tableHeader()
+	}
+
+	x := pdfMargin
+	for i, value := range row {
+		if i >= len(t.widths) {
+			break
+		}
+
+		width := t.widths[i] * (pdfPageWidth - 2*pdfMargin)
+		text := fitText(formatValue(value), width-4, pdfFontSize)
+		if _, amount := value.(float64); amount {
+			t.text(x+width-4-textWidth(text, pdfFontSize), t.y, pdfFontSize, false, text)
+		} else {
+			t.text(x, t.y, pdfFontSize, false, text)
+		}
+		x += width
+	}
+
+	t.y -= pdfRowHeight
+	return nil
+}
+
+// Close will write the last page, the pages tree and the cross-reference table, finishing the document
+func (t *pdfTable) Close() error {
+	err := t.flushPage()
+	if err != nil {
+		return err
+	}
+
+	kids := []string{}
+	for _, page := range t.pages {
+		kids = append(kids, fmt.Sprintf("%d 0 R", page))
+	}
+
+	err = t.object(2, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(t.pages)))
+	if err != nil {
+		return err
+	}
+
+	err = t.object(1, "<< /Type /Catalog /Pages 2 0 R >>")
+	if err != nil {
+		return err
+	}
+
+	xref := t.offset
+	var b strings.Builder
+	fmt.Fprintf(&b, "xref\n0 %d\n0000000000 65535 f \n", t.next)
+	for id := 1; id < t.next; id++ {
+		fmt.Fprintf(&b, "%010d 00000 n \n", t.objects[id])
+	}
+	fmt.Fprintf(&b, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", t.next, xref)
+
+	return t.out(b.String())
+}
@@ -0,0 +1,122 @@
+package exporter
+
+import (
+	"budget-tracker-api/models"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// SpendColumns defines the columns of spends exports
+var SpendColumns = []string{"Date", "Description", "Type", "Categories", "Payment method", "Card", "Installment", "Cost"}
+
+// StatementColumns defines the columns of spends at monthly statements, along with their widths
+var (
+	StatementColumns = []string{"Date", "Description", "Categories", "Payment method", "Cost"}
+	StatementWidths  = []float64{0.12, 0.38, 0.2, 0.15, 0.15}
+)
+
+// BalanceColumns defines the columns of balances exports
+var BalanceColumns = []string{"Month", "Year", "Currency", "Gross income", "Net income", "Previous month", "Fixed outcome", "Dynamic outcome", "Savings", "Spendable amount", "Closed"}
+
+// spendDate will format the date of a spend as seen from its timezone
+func spendDate(s models.Spend) string {
+	loc, err := time.LoadLocation(s.Timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+	return s.Date.In(loc).Format("2006-01-02")
+}
+
+// paymentMethod will describe the payment method of a spend, naming its card when paid with credit
+func paymentMethod(s models.Spend, cards map[primitive.ObjectID]string) (method string, card string) {
+	switch {
+	case s.PaymentMethod.Credit:
+		return "credit", cards[s.PaymentMethod.CardID]
+	case s.PaymentMethod.Debit:
+		return "debit", ""
+	case s.PaymentMethod.PaymentSlip:
+		return "payment slip", ""
+	}
+	return "", ""
+}
+
+// SpendRow will map a spend into a spends export row. Cards are named by their aliases
+func SpendRow(s models.Spend, cards map[primitive.ObjectID]string) []interface{} {
+	method, card := paymentMethod(s, cards)
+
+	installment := ""
+	if s.InstallmentNumber > 0 {
+		installment = strconv.Itoa(s.InstallmentNumber) + "/" + strconv.Itoa(s.Installments)
+	}
+
+	return []interface{}{spendDate(s), s.Description, s.Type, strings.Join(s.Categories, "; "), method, card, installment, s.Cost}
+}
+
+// StatementRow will map a spend into a monthly statement row
+func StatementRow(s models.Spend, cards map[primitive.ObjectID]string) []interface{} {
+	method, card := paymentMethod(s, cards)
+	if card != "" {
+		method = card
+	}
+
+	description := s.Description
+	if s.InstallmentNumber > 0 {
+		description += " (" + strconv.Itoa(s.InstallmentNumber) + "/" + strconv.Itoa(s.Installments) + ")"
+	}
+
+	return []interface{}{spendDate(s), description, strings.Join(s.Categories, ", "), method, s.Cost}
+}
+
+// BalanceRow will map a balance into a balances export row
+func BalanceRow(b models.Balance) []interface{} {
+	closed := "no"
+	if b.Closed {
+		closed = "yes"
+	}
+
+	return []interface{}{
+		strconv.FormatInt(b.Month, 10), strconv.FormatInt(b.Year, 10), b.Currency,
+		b.Income.GrossIncome, b.Income.NetIncome, b.Income.PreviousMonth,
+		b.Outcome.FixedOutcome, b.Outcome.DynamicOutcome, b.Outcome.Savings,
+		b.SpendableAmount, closed,
+	}
+}
+
+// StatementSummary will describe the incomes and outcomes of a month balance at its statement
+func StatementSummary(b models.Balance) []string {
+	amount := func(value float64) string {
+		text := strconv.FormatFloat(value, 'f', 2, 64)
+		if b.Currency != "" {
+			text = b.Currency + " " + text
+		}
+		return text
+	}
+
+	summary := []string{
+		"Gross income: " + amount(b.Income.GrossIncome),
+		"Net income: " + amount(b.Income.NetIncome),
+	}
+
+	if b.Income.PreviousMonth != 0 {
+		summary = append(summary, "Carried over from previous month: "+amount(b.Income.PreviousMonth))
+	}
+
+	summary = append(summary,
+		"Fixed outcome: "+amount(b.Outcome.FixedOutcome),
+		"Dynamic outcome: "+amount(b.Outcome.DynamicOutcome),
+	)
+
+	if b.Outcome.Savings != 0 {
+		summary = append(summary, "Savings: "+amount(b.Outcome.Savings))
+	}
+
+	summary = append(summary, "Spendable amount: "+amount(b.SpendableAmount))
+	if b.Closed {
+		summary = append(summary, "Closed at "+b.ClosedAt.Time().UTC().Format("2006-01-02")+" with a leftover of "+amount(b.Leftover))
+	}
+
+	return summary
+}
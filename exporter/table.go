@@ -0,0 +1,79 @@
+package exporter
+
+import (
+	"encoding/csv"
+	"errors"
+	"io"
+	"strconv"
+)
+
+// ContentTypes defines the content type of each export format
+var ContentTypes = map[string]string{
+	"csv":  "text/csv; charset=utf-8",
+	"xlsx": "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+	"pdf":  "application/pdf",
+}
+
+// Table defines an export written row by row, so rows don't need to be held in memory. Row values are
+// either strings or float64 amounts
+type Table interface {
+	Write(row []interface{}) error
+	Close() error
+}
+
+// New will start a CSV or XLSX table with the given columns
+func New(format string, w io.Writer, sheet string, columns []string) (Table, error) {
+	switch format {
+	case "csv":
+		return NewCSV(w, columns)
+	case "xlsx":
+		return NewXLSX(w, sheet, columns)
+	}
+
+	return nil, errors.New("invalid format '" + format + "'")
+}
+
+// formatValue will format a row value as text, amounts with two decimal places
+func formatValue(value interface{}) string {
+	switch v := value.(type) {
+	case float64:
+		return strconv.FormatFloat(v, 'f', 2, 64)
+	case string:
+		return v
+	}
+
+	return ""
+}
+
+// csvTable defines a CSV export
+type csvTable struct {
+	writer *csv.Writer
+}
+
+// NewCSV will start a CSV table, writing its header line
+func NewCSV(w io.Writer, columns []string) (Table, error) {
+	t := &csvTable{writer: csv.NewWriter(w)}
+
+	err := t.writer.Write(columns)
+	if err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
+
+// Write will write a CSV line
+func (t *csvTable) Write(row []interface{}) error {
+	line := make([]string, len(row))
+	for i, value := range row {
+		line[i] = formatValue(value)
+	}
+
+	return t.writer.Write(line)
+}
+
+// Close will flush the lines still buffered
+func (t *csvTable) Close() error {
+	t.writer.Flush()
+	return t.writer.Error()
+}
@@ -0,0 +1,157 @@
+package exporter
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/xml"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// xlsxParts defines the static parts of a single sheet workbook. Cells styles are the default one (0),
+// bold headers (1) and amounts with two decimal places (2)
+var xlsxParts = []struct{ name, content string }{
+	{"[Content_Types].xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+		`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+		`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>` +
+		`</Types>`},
+	{"_rels/.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+		`</Relationships>`},
+	{"xl/_rels/workbook.xml.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+		`<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>` +
+		`</Relationships>`},
+	{"xl/styles.xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+		`<fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts>` +
+		`<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>` +
+		`<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>` +
+		`<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>` +
+		`<cellXfs count="3"><xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/>` +
+		`<xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/>` +
+		`<xf numFmtId="4" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/></cellXfs>` +
+		`</styleSheet>`},
+}
+
+// xlsxTable defines a single sheet XLSX export, whose sheet is written to the zip archive as rows come
+type xlsxTable struct {
+	archive *zip.Writer
+	sheet   *bufio.Writer
+	row     int
+}
+
+// xlsxColumn will return the letters of a column given its index, such as A, Z or AA
+func xlsxColumn(i int) string {
+	name := ""
+	for i++; i > 0; i = (i - 1) / 26 {
+		name = string(rune('A'+(i-1)%26)) + name
+	}
+	return name
+}
+
+// xlsxEscape will escape a text for a sheet cell
+func xlsxEscape(value string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(value))
+	return b.String()
+}
+
+// NewXLSX will start a XLSX workbook with a single sheet, writing its header row
+func NewXLSX(w io.Writer, sheet string, columns []string) (Table, error) {
+	archive := zip.NewWriter(w)
+
+	for _, part := range xlsxParts {
+		f, err := archive.Create(part.name)
+		if err != nil {
+			return nil, err
+		}
+
+		_, err = io.WriteString(f, part.content)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	f, err := archive.Create("xl/workbook.xml")
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = io.WriteString(f, `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">`+
+		`<sheets><sheet name="`+xlsxEscape(sheet)+`" sheetId="1" r:id="rId1"/></sheets></workbook>`)
+	if err != nil {
+		return nil, err
+	}
+
+	// the sheet must be the last part written, as it is streamed
+	f, err = archive.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return nil, err
+	}
+
+	t := &xlsxTable{archive: archive, sheet: bufio.NewWriter(f)}
+	t.sheet.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+
+	header := make([]interface{}, len(columns))
+	for i, column := range columns {
+		header[i] = column
+	}
+
+	err = t.write(header, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
+
+// write will write a sheet row with the given text style
+func (t *xlsxTable) write(row []interface{}, style int) error {
+	t.row++
+	line := strconv.Itoa(t.row)
+
+	t.sheet.WriteString(`<row r="` + line + `">`)
+	for i, value := range row {
+		ref := xlsxColumn(i) + line
+
+		switch v := value.(type) {
+		case float64:
+			t.sheet.WriteString(`<c r="` + ref + `" s="2"><v>` + strconv.FormatFloat(v, 'f', -1, 64) + `</v></c>`)
+		case string:
+			if v == "" {
+				continue
+			}
+			t.sheet.WriteString(`<c r="` + ref + `" s="` + strconv.Itoa(style) + `" t="inlineStr"><is><t xml:space="preserve">` + xlsxEscape(v) + `</t></is></c>`)
+		}
+	}
+
+	_, err := t.sheet.WriteString(`</row>`)
+	return err
+}
+
+// Write will write a sheet row
+func (t *xlsxTable) Write(row []interface{}) error {
+	return t.write(row, 0)
+}
+
+// Close will finish the sheet and the workbook archive
+func (t *xlsxTable) Close() error {
+	t.sheet.WriteString(`</sheetData></worksheet>`)
+
+	err := t.sheet.Flush()
+	if err != nil {
+		return err
+	}
+
+	return t.archive.Close()
+}
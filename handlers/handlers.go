@@ -25,11 +25,90 @@ type Handlers struct {
 	DeleteCardHandler   http.Handler
 	GetCardsHandler     http.Handler
 
-	CreateBalanceHandler http.Handler
-	GetBalanceHandler    http.Handler
+	CreateBalanceHandler      http.Handler
+	GetBalanceHandler         http.Handler
+	SetBudgetsHandler         http.Handler
+	GetBudgetsProgressHandler http.Handler
+	CloseBalanceHandler       http.Handler
+	UpdateBalanceHandler      http.Handler
+	RecomputeBalanceHandler   http.Handler
+	GetYearlySummaryHandler   http.Handler
 
-	GetSpendsHandler   http.Handler
-	CreateSpendHandler http.Handler
+	GetSpendsHandler    http.Handler
+	CreateSpendHandler  http.Handler
+	UpdateSpendHandler  http.Handler
+	DeleteSpendHandler  http.Handler
+	GetTagsHandler      http.Handler
+	SearchSpendsHandler http.Handler
+
+	GetInstallmentsHandler http.Handler
+
+	CreateRecurringRuleHandler http.Handler
+	GetRecurringRulesHandler   http.Handler
+	UpdateRecurringRuleHandler http.Handler
+	PauseRecurringRuleHandler  http.Handler
+	ResumeRecurringRuleHandler http.Handler
+
+	CreateCategoryHandler        http.Handler
+	GetCategoriesHandler         http.Handler
+	SeedDefaultCategoriesHandler http.Handler
+	UpdateCategoryHandler        http.Handler
+	MergeCategoryHandler         http.Handler
+
+	CreateCategorizationRuleHandler   http.Handler
+	GetCategorizationRulesHandler     http.Handler
+	DeleteCategorizationRuleHandler   http.Handler
+	ReapplyCategorizationRulesHandler http.Handler
+
+	GetNotificationsHandler http.Handler
+	ReadNotificationHandler http.Handler
+
+	AddIncomeHandler           http.Handler
+	RemoveIncomeHandler        http.Handler
+	GetIncomesBreakdownHandler http.Handler
+
+	CalculateNetIncomeHandler http.Handler
+
+	CreateGoalHandler          http.Handler
+	GetGoalsHandler            http.Handler
+	UpdateGoalHandler          http.Handler
+	DeleteGoalHandler          http.Handler
+	AddGoalContributionHandler http.Handler
+
+	GetForecastHandler http.Handler
+
+	GetReportHandler     http.Handler
+	CompareReportHandler http.Handler
+
+	CreateImportProfileHandler http.Handler
+	GetImportProfilesHandler   http.Handler
+	DeleteImportProfileHandler http.Handler
+	ImportCSVHandler           http.Handler
+	ImportOFXHandler           http.Handler
+	ImportQIFHandler           http.Handler
+	GetImportJobHandler        http.Handler
+
+	ExportSpendsHandler    http.Handler
+	ExportBalancesHandler  http.Handler
+	ExportStatementHandler http.Handler
+
+	UploadAttachmentHandler   http.Handler
+	GetAttachmentsHandler     http.Handler
+	DownloadAttachmentHandler http.Handler
+	DeleteAttachmentHandler   http.Handler
+
+	CreateRefundHandler   http.Handler
+	GetRefundsHandler     http.Handler
+	GetReceivablesHandler http.Handler
+	ReceiveRefundHandler  http.Handler
+	DeleteRefundHandler   http.Handler
+
+	GetDebtsHandler    http.Handler
+	SettleDebtsHandler http.Handler
+
+	GetShareRequestsHandler http.Handler
+	AcceptShareHandler      http.Handler
+	DeclineShareHandler     http.Handler
 }
 
 // GetHandlers will return all backend handlers initialized
@@ -54,8 +133,87 @@ func GetHandlers() (h Handlers) {
 
 	h.CreateBalanceHandler = http.HandlerFunc(controllers.CreateBalanceEndpoint)
 	h.GetBalanceHandler = http.HandlerFunc(controllers.GetBalanceEndpoint)
+	h.SetBudgetsHandler = http.HandlerFunc(controllers.SetBudgetsEndpoint)
+	h.GetBudgetsProgressHandler = http.HandlerFunc(controllers.GetBudgetsProgressEndpoint)
+	h.CloseBalanceHandler = http.HandlerFunc(controllers.CloseBalanceEndpoint)
+	h.UpdateBalanceHandler = http.HandlerFunc(controllers.UpdateBalanceEndpoint)
+	h.RecomputeBalanceHandler = http.HandlerFunc(controllers.RecomputeBalanceEndpoint)
+	h.GetYearlySummaryHandler = http.HandlerFunc(controllers.GetYearlySummaryEndpoint)
 
 	h.GetSpendsHandler = http.HandlerFunc(controllers.GetSpendsEndpoint)
 	h.CreateSpendHandler = http.HandlerFunc(controllers.CreateSpendEndpoint)
+	h.UpdateSpendHandler = http.HandlerFunc(controllers.UpdateSpendEndpoint)
+	h.DeleteSpendHandler = http.HandlerFunc(controllers.DeleteSpendEndpoint)
+	h.GetTagsHandler = http.HandlerFunc(controllers.GetTagsEndpoint)
+	h.SearchSpendsHandler = http.HandlerFunc(controllers.SearchSpendsEndpoint)
+
+	h.GetInstallmentsHandler = http.HandlerFunc(controllers.GetInstallmentsEndpoint)
+
+	h.CreateRecurringRuleHandler = http.HandlerFunc(controllers.CreateRecurringRuleEndpoint)
+	h.GetRecurringRulesHandler = http.HandlerFunc(controllers.GetRecurringRulesEndpoint)
+	h.UpdateRecurringRuleHandler = http.HandlerFunc(controllers.UpdateRecurringRuleEndpoint)
+	h.PauseRecurringRuleHandler = http.HandlerFunc(controllers.PauseRecurringRuleEndpoint)
+	h.ResumeRecurringRuleHandler = http.HandlerFunc(controllers.ResumeRecurringRuleEndpoint)
+
+	h.CreateCategoryHandler = http.HandlerFunc(controllers.CreateCategoryEndpoint)
+	h.GetCategoriesHandler = http.HandlerFunc(controllers.GetCategoriesEndpoint)
+	h.SeedDefaultCategoriesHandler = http.HandlerFunc(controllers.SeedDefaultCategoriesEndpoint)
+	h.UpdateCategoryHandler = http.HandlerFunc(controllers.UpdateCategoryEndpoint)
+	h.MergeCategoryHandler = http.HandlerFunc(controllers.MergeCategoryEndpoint)
+
+	h.CreateCategorizationRuleHandler = http.HandlerFunc(controllers.CreateCategorizationRuleEndpoint)
+	h.GetCategorizationRulesHandler = http.HandlerFunc(controllers.GetCategorizationRulesEndpoint)
+	h.DeleteCategorizationRuleHandler = http.HandlerFunc(controllers.DeleteCategorizationRuleEndpoint)
+	h.ReapplyCategorizationRulesHandler = http.HandlerFunc(controllers.ReapplyCategorizationRulesEndpoint)
+
+	h.GetNotificationsHandler = http.HandlerFunc(controllers.GetNotificationsEndpoint)
+	h.ReadNotificationHandler = http.HandlerFunc(controllers.ReadNotificationEndpoint)
+
+	h.AddIncomeHandler = http.HandlerFunc(controllers.AddIncomeEndpoint)
+	h.RemoveIncomeHandler = http.HandlerFunc(controllers.RemoveIncomeEndpoint)
+	h.GetIncomesBreakdownHandler = http.HandlerFunc(controllers.GetIncomesBreakdownEndpoint)
+
+	h.CalculateNetIncomeHandler = http.HandlerFunc(controllers.CalculateNetIncomeEndpoint)
+
+	h.CreateGoalHandler = http.HandlerFunc(controllers.CreateGoalEndpoint)
+	h.GetGoalsHandler = http.HandlerFunc(controllers.GetGoalsEndpoint)
+	h.UpdateGoalHandler = http.HandlerFunc(controllers.UpdateGoalEndpoint)
+	h.DeleteGoalHandler = http.HandlerFunc(controllers.DeleteGoalEndpoint)
+	h.AddGoalContributionHandler = http.HandlerFunc(controllers.AddGoalContributionEndpoint)
+
+	h.GetForecastHandler = http.HandlerFunc(controllers.GetForecastEndpoint)
+
+	h.GetReportHandler = http.HandlerFunc(controllers.GetReportEndpoint)
+	h.CompareReportHandler = http.HandlerFunc(controllers.CompareReportEndpoint)
+
+	h.CreateImportProfileHandler = http.HandlerFunc(controllers.CreateImportProfileEndpoint)
+	h.GetImportProfilesHandler = http.HandlerFunc(controllers.GetImportProfilesEndpoint)
+	h.DeleteImportProfileHandler = http.HandlerFunc(controllers.DeleteImportProfileEndpoint)
+	h.ImportCSVHandler = http.HandlerFunc(controllers.ImportCSVEndpoint)
+	h.ImportOFXHandler = http.HandlerFunc(controllers.ImportOFXEndpoint)
+	h.ImportQIFHandler = http.HandlerFunc(controllers.ImportQIFEndpoint)
+	h.GetImportJobHandler = http.HandlerFunc(controllers.GetImportJobEndpoint)
+
+	h.ExportSpendsHandler = http.HandlerFunc(controllers.ExportSpendsEndpoint)
+	h.ExportBalancesHandler = http.HandlerFunc(controllers.ExportBalancesEndpoint)
+	h.ExportStatementHandler = http.HandlerFunc(controllers.ExportStatementEndpoint)
+
+	h.UploadAttachmentHandler = http.HandlerFunc(controllers.UploadAttachmentEndpoint)
+	h.GetAttachmentsHandler = http.HandlerFunc(controllers.GetAttachmentsEndpoint)
+	h.DownloadAttachmentHandler = http.HandlerFunc(controllers.DownloadAttachmentEndpoint)
+	h.DeleteAttachmentHandler = http.HandlerFunc(controllers.DeleteAttachmentEndpoint)
+
+	h.CreateRefundHandler = http.HandlerFunc(controllers.CreateRefundEndpoint)
+	h.GetRefundsHandler = http.HandlerFunc(controllers.GetRefundsEndpoint)
+	h.GetReceivablesHandler = http.HandlerFunc(controllers.GetReceivablesEndpoint)
+	h.ReceiveRefundHandler = http.HandlerFunc(controllers.ReceiveRefundEndpoint)
+	h.DeleteRefundHandler = http.HandlerFunc(controllers.DeleteRefundEndpoint)
+
+	h.GetDebtsHandler = http.HandlerFunc(controllers.GetDebtsEndpoint)
+	h.SettleDebtsHandler = http.HandlerFunc(controllers.SettleDebtsEndpoint)
+
+	h.GetShareRequestsHandler = http.HandlerFunc(controllers.GetShareRequestsEndpoint)
+	h.AcceptShareHandler = http.HandlerFunc(controllers.AcceptShareEndpoint)
+	h.DeclineShareHandler = http.HandlerFunc(controllers.DeclineShareEndpoint)
 	return h
 }
@@ -13,13 +13,15 @@ var mySigninKey = []byte("myhellokey")
 
 // Middlewares defines middlewares to intercept handlers
 type Middlewares struct {
-	Auth func(http.Handler) http.Handler
-	JSON func(http.Handler) http.Handler
+	Auth      func(http.Handler) http.Handler
+	JSON      func(http.Handler) http.Handler
+	Multipart func(http.Handler) http.Handler
 }
 
 // GetMiddlewares will return all middlewares handlers initialized
 func GetMiddlewares() (m Middlewares) {
 	m.JSON = RequireContentTypeJSON
+	m.Multipart = RequireContentTypeMultipart
 	m.Auth = RequireTokenAuthentication
 	return m
 }
@@ -55,6 +57,28 @@ func RequireContentTypeJSON(h http.Handler) http.Handler {
 	})
 }
 
+// RequireContentTypeMultipart enforces multipart/form-data content-type from file uploads
+func RequireContentTypeMultipart(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+		response.Header().Set("Access-Control-Allow-Origin", "*")
+
+		mt, _, err := mime.ParseMediaType(request.Header.Get("Content-Type"))
+		if err != nil {
+			response.WriteHeader(http.StatusBadRequest)
+			response.Write([]byte(`{"message": "malformed Content-Type header"}`))
+			return
+		}
+
+		if mt != "multipart/form-data" {
+			response.WriteHeader(http.StatusUnsupportedMediaType)
+			response.Write([]byte(`{"message": "content-Type header must be multipart/form-data"}`))
+			return
+		}
+
+		h.ServeHTTP(response, request)
+	})
+}
+
 // RequireTokenAuthentication enforces authentication token from requests
 func RequireTokenAuthentication(h http.Handler) http.Handler {
 	return http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
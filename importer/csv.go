@@ -0,0 +1,180 @@
+package importer
+
+import (
+	"budget-tracker-api/models"
+	"encoding/csv"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// dateLayout will translate a date format such as DD/MM/YYYY into a Go time layout
+func dateLayout(format string) string {
+	replacer := strings.NewReplacer("YYYY", "2006", "YY", "06", "MM", "01", "DD", "02", "hh", "15", "mm", "04", "ss", "05")
+	return replacer.Replace(format)
+}
+
+// ParseAmount will parse a monetary value as written in bank statements, such as "R$ 1.234,56",
+// "(12.50)" or "12.50-". Parentheses and trailing minus signs are negative values
+func ParseAmount(value string, decimalComma bool) (amount float64, err error) {
+	value = strings.TrimSpace(value)
+
+	negative := false
+	if strings.HasPrefix(value, "(") && strings.HasSuffix(value, ")") {
+		negative = true
+		value = strings.Trim(value, "()")
+	}
+
+	if strings.HasSuffix(value, "-") {
+		negative = true
+		value = strings.TrimSuffix(value, "-")
+	}
+
+	// leaving out currency symbols and spaces
+	value = strings.Map(func(r rune) rune {
+		if unicode.IsDigit(r) || r == '.' || r == ',' || r == '-' || r == '+' {
+			return r
+		}
+		return -1
+	}, value)
+
+	if decimalComma {
+		value = strings.ReplaceAll(value, ".", "")
+		value = strings.ReplaceAll(value, ",", ".")
+	} else {
+		value = strings.ReplaceAll(value, ",", "")
+	}
+
+	if value == "" {
+		return 0, errors.New("missing amount")
+	}
+
+	amount, err = strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, errors.New("invalid amount '" + value + "'")
+	}
+
+	if negative {
+		amount = -amount
+	}
+
+	return amount, nil
+}
+
+// ParseCSV will parse the spends from a bank statement CSV based on an import profile. Lines not
+// representing spends, according to the profile spend sign, are counted as skipped
+func ParseCSV(r io.Reader, p models.ImportProfile) (records []models.ImportRecord, skipped int, errs []models.ImportError, err error) {
+	errs = []models.ImportError{}
+
+	loc := time.UTC
+	if p.Timezone != "" {
+		loc, err = time.LoadLocation(p.Timezone)
+		if err != nil {
+			return records, 0, errs, err
+		}
+	}
+
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	if p.Delimiter != "" {
+		reader.Comma = []rune(p.Delimiter)[0]
+	}
+
+	line := 0
+	for ; line < p.SkipLines; line++ {
+		_, err = reader.Read()
+		if err != nil {
+			return records, 0, errs, errors.New("file has less lines than the ones to skip")
+		}
+	}
+
+	header, err := reader.Read()
+	if err != nil {
+		return records, 0, errs, errors.New("missing header line")
+	}
+	line++
+
+	columns := map[string]int{}
+	for i, name := range header {
+		name = strings.TrimPrefix(name, "\ufeff")
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+
+	indexes := []int{}
+	for _, name := range []string{p.DateColumn, p.DescriptionColumn, p.AmountColumn} {
+		i, ok := columns[strings.ToLower(strings.TrimSpace(name))]
+		if !ok {
+			return records, 0, errs, errors.New("missing column '" + name + "'")
+		}
+		indexes = append(indexes, i)
+	}
+
+	spendType := p.Type
+	if spendType == "" {
+		spendType = "dynamic"
+	}
+
+	layout := dateLayout(p.DateFormat)
+	for {
+		row, readErr := reader.Read()
+		if readErr == io.EOF {
+			break
+		}
+		line++
+
+		if readErr != nil {
+			errs = append(errs, models.ImportError{Line: line, Message: readErr.Error()})
+			continue
+		}
+
+		// blank lines at the end of statements
+		if len(row) == 1 && strings.TrimSpace(row[0]) == "" {
+			continue
+		}
+
+		if len(row) <= indexes[0] || len(row) <= indexes[1] || len(row) <= indexes[2] {
+			errs = append(errs, models.ImportError{Line: line, Message: "missing columns"})
+			continue
+		}
+
+		date, dateErr := time.ParseInLocation(layout, strings.TrimSpace(row[indexes[0]]), loc)
+		if dateErr != nil {
+			errs = append(errs, models.ImportError{Line: line, Message: "invalid date '" + row[indexes[0]] + "'"})
+			continue
+		}
+
+		amount, amountErr := ParseAmount(row[indexes[2]], p.DecimalComma)
+		if amountErr != nil {
+			errs = append(errs, models.ImportError{Line: line, Message: amountErr.Error()})
+			continue
+		}
+
+		if p.SpendSign != "positive" {
+			amount = -amount
+		}
+
+		if amount <= 0 {
+			skipped++
+			continue
+		}
+
+		records = append(records, models.ImportRecord{
+			Line: line,
+			Spend: &models.Spend{
+				OwnerID:       p.OwnerID,
+				Type:          spendType,
+				Description:   strings.TrimSpace(row[indexes[1]]),
+				Cost:          amount,
+				PaymentMethod: p.PaymentMethod,
+				Date:          date,
+				Timezone:      p.Timezone,
+			},
+		})
+	}
+
+	return records, skipped, errs, nil
+}
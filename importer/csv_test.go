@@ -0,0 +1,38 @@
+package importer
+
+import "testing"
+
+func TestParseAmount(t *testing.T) {
+	tests := []struct {
+		value        string
+		decimalComma bool
+		want         float64
+		wantErr      bool
+	}{
+		{"12.50", false, 12.50, false},
+		{"-12.50", false, -12.50, false},
+		{"+12.50", false, 12.50, false},
+		{"1,234.56", false, 1234.56, false},
+		{"R$ 1.234,56", true, 1234.56, false},
+		{"-1.234,56", true, -1234.56, false},
+		{"(12.50)", false, -12.50, false},
+		{"12.50-", false, -12.50, false},
+		{"(1.234,56)", true, -1234.56, false},
+		{"  $ 99 ", false, 99, false},
+		{"", false, 0, true},
+		{"R$", true, 0, true},
+		{"1.2.3", false, 0, true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseAmount(tt.value, tt.decimalComma)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseAmount(%q, %t) error = %v, wantErr %t", tt.value, tt.decimalComma, err, tt.wantErr)
+			continue
+		}
+
+		if got != tt.want {
+			t.Errorf("ParseAmount(%q, %t) = %.2f, want %.2f", tt.value, tt.decimalComma, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,174 @@
+package importer
+
+import (
+	"budget-tracker-api/models"
+	"context"
+	"fmt"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	// asyncThreshold defines from how many records imports are processed in background
+	asyncThreshold = 100
+
+	// previewLimit defines how many records are kept at dry runs previews
+	previewLimit = 500
+)
+
+// isDuplicate will check if the spend or income entry of an imported record was already registered
+func isDuplicate(ctx context.Context, ownerID primitive.ObjectID, record models.ImportRecord) (bool, error) {
+	if record.Income != nil {
+		return models.FindDuplicateIncome(ctx, ownerID, *record.Income)
+	}
+	return models.FindDuplicateSpend(ctx, *record.Spend)
+}
+
+// importIncome will add the income entry of an imported record to the balance of the month it was
+// received at, as seen from the statement timezone
+func importIncome(ctx context.Context, ownerID primitive.ObjectID, e models.IncomeEntry) (err error) {
+	errs := models.ValidateIncomeEntry("", e)
+	if len(errs) > 0 {
+		return models.JoinFieldErrors(errs)
+	}
+
+	_, err = models.AddIncomeToBalance(ctx, ownerID, int64(e.ReceivedAt.Month()), int64(e.ReceivedAt.Year()), e)
+	return err
+}
+
+// importRecord will register the spend of an imported record, booking it to its month balance
+func importRecord(ctx context.Context, ownerID primitive.ObjectID, record models.ImportRecord) (err error) {
+	if record.Income != nil {
+		return importIncome(ctx, ownerID, *record.Income)
+	}
+
+	_, _, errs, err := models.RegisterSpend(ctx, *record.Spend)
+	if err != nil {
+		return err
+	}
+
+	if len(errs) > 0 {
+		return models.JoinFieldErrors(errs)
+	}
+
+	return nil
+}
+
+// externalKey will return the key identifying the external ID of an imported record within its file,
+// empty for records without one
+func externalKey(record models.ImportRecord) string {
+	if record.Income != nil && record.Income.ExternalID != "" {
+		return "income:" + record.Income.ExternalID
+	}
+
+	if record.Spend != nil && record.Spend.ExternalID != "" {
+		return "spend:" + record.Spend.ExternalID
+	}
+
+	return ""
+}
+
+// Run will import the parsed records of a job, skipping duplicated ones, and keep the job status up to
+// date. Dry runs only flag duplicates and fill the job preview. Jobs interrupted by a panic are set as
+// failed
+func Run(ctx context.Context, job models.ImportJob, records []models.ImportRecord) (finished models.ImportJob) {
+	defer func() {
+		r := recover()
+		if r == nil {
+			return
+		}
+
+		log.Errorln("import job", job.ID.Hex(), "failed:", r)
+		job.Status = "failed"
+		job.Errors = append(job.Errors, models.ImportError{Message: fmt.Sprint("import interrupted: ", r)})
+		job.FinishedAt = primitive.NewDateTimeFromTime(time.Now())
+
+		err := models.UpdateImportJob(ctx, job)
+		if err != nil {
+			log.Errorln("could not update import job", job.ID.Hex(), ":", err)
+		}
+		finished = job
+	}()
+
+	job.Status = "running"
+	err := models.UpdateImportJob(ctx, job)
+	if err != nil {
+		log.Errorln("could not update import job", job.ID.Hex(), ":", err)
+	}
+
+	// duplicates are looked up before importing anything, so records are only matched against spends and
+	// incomes registered before the import. Identical records at different lines of the file, such as two
+	// equal purchases on the same day, are all imported, unless they repeat an external ID
+	checkErrs := make([]error, len(records))
+	seen := map[string]bool{}
+	for i := range records {
+		records[i].Duplicate, checkErrs[i] = isDuplicate(ctx, job.OwnerID, records[i])
+
+		key := externalKey(records[i])
+		if key == "" {
+			continue
+		}
+
+		if seen[key] {
+			records[i].Duplicate = true
+		}
+		seen[key] = true
+	}
+
+	for i, record := range records {
+		if checkErrs[i] != nil {
+			job.Failed++
+			job.Errors = append(job.Errors, models.ImportError{Line: record.Line, Message: checkErrs[i].Error()})
+			continue
+		}
+
+		if record.Duplicate {
+			job.Duplicates++
+		}
+
+		if job.DryRun {
+			if len(job.Preview) < previewLimit {
+				job.Preview = append(job.Preview, record)
+			}
+			continue
+		}
+
+		if record.Duplicate {
+			continue
+		}
+
+		err = importRecord(ctx, job.OwnerID, record)
+		if err != nil {
+			job.Failed++
+			job.Errors = append(job.Errors, models.ImportError{Line: record.Line, Message: err.Error()})
+			continue
+		}
+
+		job.Imported++
+	}
+
+	job.Status = "done"
+	job.FinishedAt = primitive.NewDateTimeFromTime(time.Now())
+
+	err = models.UpdateImportJob(ctx, job)
+	if err != nil {
+		log.Errorln("could not update import job", job.ID.Hex(), ":", err)
+	}
+
+	log.Infoln("import job", job.ID.Hex(), "imported", job.Imported, "records")
+	return job
+}
+
+// Start will run an import job right away for dry runs and small files, returning the finished job. Large
+// files are imported in background, returning the job still pending
+func Start(ctx context.Context, job models.ImportJob, records []models.ImportRecord) (started models.ImportJob, async bool) {
+	if job.DryRun || len(records) <= asyncThreshold {
+		return Run(ctx, job, records), false
+	}
+
+	// the request context is done as soon as the response is written
+	go Run(context.Background(), job, records)
+	return job, true
+}
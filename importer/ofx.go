@@ -0,0 +1,193 @@
+package importer
+
+import (
+	"budget-tracker-api/models"
+	"errors"
+	"io"
+	"io/ioutil"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ofxEntities defines the character entities escaped at OFX values
+var ofxEntities = strings.NewReplacer("&amp;", "&", "&lt;", "<", "&gt;", ">", "&quot;", `"`, "&apos;", "'", "&nbsp;", " ")
+
+// ofxTransaction defines the elements of an OFX STMTTRN aggregate
+type ofxTransaction struct {
+	line int
+	// ACCTID of the account or card statement the transaction belongs to
+	account  string
+	elements map[string]string
+}
+
+// parseOFXDate will parse an OFX datetime such as 20210510, 20210510183000 or
+// 20210510183000.000[-3:BRT]. Dates without an offset are taken at the given location
+func parseOFXDate(value string, loc *time.Location) (date time.Time, err error) {
+	value = strings.TrimSpace(value)
+
+	if i := strings.Index(value, "["); i >= 0 {
+		offset := strings.SplitN(strings.TrimSuffix(value[i+1:], "]"), ":", 2)[0]
+		value = value[:i]
+
+		hours, err := strconv.ParseFloat(offset, 64)
+		if err != nil {
+			return date, errors.New("invalid date offset '" + offset + "'")
+		}
+		loc = time.FixedZone("", int(hours*3600))
+	}
+
+	if i := strings.Index(value, "."); i >= 0 {
+		value = value[:i]
+	}
+
+	layouts := map[int]string{8: "20060102", 12: "200601021504", 14: "20060102150405"}
+	layout, ok := layouts[len(value)]
+	if !ok {
+		return date, errors.New("invalid date '" + value + "'")
+	}
+
+	date, err = time.ParseInLocation(layout, value, loc)
+	if err != nil {
+		return date, errors.New("invalid date '" + value + "'")
+	}
+
+	return date, nil
+}
+
+// scanOFX will collect the transactions from an OFX document, telling if it is a credit card statement.
+// Both the SGML variant, whose elements aren't closed, and the XML variant are supported
+func scanOFX(document string) (transactions []ofxTransaction, creditCard bool) {
+	var current *ofxTransaction
+	account := ""
+
+	line := 1
+	for i := 0; i < len(document); {
+		if document[i] != '<' {
+			if document[i] == '\n' {
+				line++
+			}
+			i++
+			continue
+		}
+
+		end := strings.IndexByte(document[i:], '>')
+		if end < 0 {
+			break
+		}
+
+		tag := strings.ToUpper(strings.TrimSpace(document[i+1 : i+end]))
+		i += end + 1
+
+		// XML declarations, processing instructions and comments
+		if strings.HasPrefix(tag, "?") || strings.HasPrefix(tag, "!") {
+			continue
+		}
+
+		if strings.HasPrefix(tag, "/") {
+			if tag == "/STMTTRN" && current != nil {
+				transactions = append(transactions, *current)
+				current = nil
+			}
+			continue
+		}
+
+		switch tag {
+		case "CCSTMTRS":
+			creditCard = true
+		case "STMTTRN":
+			if current != nil {
+				transactions = append(transactions, *current)
+			}
+			current = &ofxTransaction{line: line, account: account, elements: map[string]string{}}
+			continue
+		}
+
+		next := strings.IndexByte(document[i:], '<')
+		if next < 0 {
+			next = len(document) - i
+		}
+
+		value := strings.TrimSpace(document[i : i+next])
+		if value == "" {
+			continue
+		}
+
+		if current != nil {
+			current.elements[tag] = ofxEntities.Replace(value)
+		} else if tag == "ACCTID" {
+			account = ofxEntities.Replace(value)
+		}
+	}
+
+	if current != nil {
+		transactions = append(transactions, *current)
+	}
+
+	return transactions, creditCard
+}
+
+// ParseOFX will parse the transactions from an OFX bank or credit card statement. Transactions are
+// identified by their account ACCTID and FITID, used to tell already imported ones apart. Credit card
+// statements must be imported with the card they belong to
+func ParseOFX(r io.Reader, o Options) (records []models.ImportRecord, skipped int, errs []models.ImportError, err error) {
+	errs = []models.ImportError{}
+
+	loc := time.UTC
+	if o.Timezone != "" {
+		loc, err = time.LoadLocation(o.Timezone)
+		if err != nil {
+			return records, 0, errs, err
+		}
+	}
+
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return records, 0, errs, err
+	}
+
+	document := decodeStatement(data)
+	if !strings.Contains(strings.ToUpper(document), "<OFX>") {
+		return records, 0, errs, errors.New("not an OFX file")
+	}
+
+	transactions, creditCard := scanOFX(document)
+	if creditCard && !o.PaymentMethod.Credit {
+		return records, 0, errs, ErrMissingCard
+	}
+
+	for _, t := range transactions {
+		date, dateErr := parseOFXDate(t.elements["DTPOSTED"], loc)
+		if dateErr != nil {
+			errs = append(errs, models.ImportError{Line: t.line, Message: dateErr.Error()})
+			continue
+		}
+
+		amount, amountErr := ParseAmount(t.elements["TRNAMT"], hasDecimalComma(t.elements["TRNAMT"]))
+		if amountErr != nil {
+			errs = append(errs, models.ImportError{Line: t.line, Message: amountErr.Error()})
+			continue
+		}
+
+		description := t.elements["NAME"]
+		if description == "" {
+			description = t.elements["MEMO"]
+		}
+
+		// FITIDs are only unique within the account they come from
+		externalID := t.elements["FITID"]
+		if externalID != "" && t.account != "" {
+			externalID = t.account + ":" + externalID
+		}
+
+		record := statementRecord(t.line, o, creditCard, date.In(loc), description, amount, externalID)
+		if record == nil {
+			skipped++
+			continue
+		}
+
+		records = append(records, *record)
+	}
+
+	return records, skipped, errs, nil
+}
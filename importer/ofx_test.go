@@ -0,0 +1,46 @@
+package importer
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseOFXDate(t *testing.T) {
+	saoPaulo, err := time.LoadLocation("America/Sao_Paulo")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		value   string
+		loc     *time.Location
+		want    time.Time
+		wantErr bool
+	}{
+		{"20210510", time.UTC, time.Date(2021, time.May, 10, 0, 0, 0, 0, time.UTC), false},
+		{"202105101830", time.UTC, time.Date(2021, time.May, 10, 18, 30, 0, 0, time.UTC), false},
+		{"20210510183000", time.UTC, time.Date(2021, time.May, 10, 18, 30, 0, 0, time.UTC), false},
+		{"20210510183000.000", time.UTC, time.Date(2021, time.May, 10, 18, 30, 0, 0, time.UTC), false},
+		{"20210510", saoPaulo, time.Date(2021, time.May, 10, 3, 0, 0, 0, time.UTC), false},
+		{"20210510183000.000[-3:BRT]", time.UTC, time.Date(2021, time.May, 10, 21, 30, 0, 0, time.UTC), false},
+		{"20210510183000[+5.5:IST]", time.UTC, time.Date(2021, time.May, 10, 13, 0, 0, 0, time.UTC), false},
+		{"20210510183000[0:GMT]", saoPaulo, time.Date(2021, time.May, 10, 18, 30, 0, 0, time.UTC), false},
+		{" 20210510 ", time.UTC, time.Date(2021, time.May, 10, 0, 0, 0, 0, time.UTC), false},
+		{"2021051", time.UTC, time.Time{}, true},
+		{"20211310", time.UTC, time.Time{}, true},
+		{"20210510[BRT]", time.UTC, time.Time{}, true},
+		{"", time.UTC, time.Time{}, true},
+	}
+
+	for _, tt := range tests {
+		got, err := parseOFXDate(tt.value, tt.loc)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseOFXDate(%q) error = %v, wantErr %t", tt.value, err, tt.wantErr)
+			continue
+		}
+
+		if !tt.wantErr && !got.Equal(tt.want) {
+			t.Errorf("parseOFXDate(%q) = %s, want %s", tt.value, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package importer
+
+import (
+	"budget-tracker-api/models"
+	"errors"
+	"io"
+	"io/ioutil"
+	"strings"
+	"time"
+)
+
+// qifUnsupportedTypes defines QIF sections not holding bank or card transactions
+var qifUnsupportedTypes = []string{"invst", "cat", "class", "memorized", "prices", "security"}
+
+// qifDateLayouts will translate a date format such as DD/MM/YYYY into the Go time layouts of QIF dates,
+// whose days and months may have a single digit and years may have only two
+func qifDateLayouts(format string) []string {
+	if format == "" {
+		format = "DD/MM/YYYY"
+	}
+
+	layout := strings.NewReplacer("YYYY", "2006", "DD", "2", "MM", "1").Replace(format)
+	return []string{layout, strings.Replace(layout, "2006", "06", 1)}
+}
+
+// parseQIFDate will parse a QIF date such as 10/05/2021, 10/5'21 or 10/ 5/21
+func parseQIFDate(value string, layouts []string, loc *time.Location) (date time.Time, err error) {
+	value = strings.ReplaceAll(strings.ReplaceAll(strings.TrimSpace(value), "'", "/"), " ", "")
+
+	for _, layout := range layouts {
+		date, err = time.ParseInLocation(layout, value, loc)
+		if err == nil {
+			return date, nil
+		}
+	}
+
+	return date, errors.New("invalid date '" + value + "'")
+}
+
+// ParseQIF will parse the transactions from a QIF bank, cash or credit card statement. QIF transactions
+// carry no IDs, so already imported ones are told apart by their description, amount and day
+func ParseQIF(r io.Reader, o Options) (records []models.ImportRecord, skipped int, errs []models.ImportError, err error) {
+	errs = []models.ImportError{}
+
+	loc := time.UTC
+	if o.Timezone != "" {
+		loc, err = time.LoadLocation(o.Timezone)
+		if err != nil {
+			return records, 0, errs, err
+		}
+	}
+
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return records, 0, errs, err
+	}
+
+	lines := strings.Split(decodeStatement(data), "\n")
+	if !strings.HasPrefix(strings.TrimSpace(lines[0]), "!") {
+		return records, 0, errs, errors.New("not a QIF file")
+	}
+
+	layouts := qifDateLayouts(o.DateFormat)
+	creditCard := false
+	fields := map[byte]string{}
+	start := 0
+
+	for i, line := range lines {
+		line = strings.TrimRight(line, "\r")
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		if strings.HasPrefix(line, "!") {
+			header := strings.ToLower(strings.TrimSpace(line))
+			if strings.HasPrefix(header, "!type:") {
+				kind := strings.TrimPrefix(header, "!type:")
+				for _, unsupported := range qifUnsupportedTypes {
+					if kind == unsupported {
+						return records, 0, errs, errors.New("unsupported QIF type '" + kind + "'")
+					}
+				}
+				creditCard = kind == "ccard"
+				if creditCard && !o.PaymentMethod.Credit {
+					return records, 0, errs, ErrMissingCard
+				}
+			}
+			continue
+		}
+
+		if len(fields) == 0 {
+			start = i + 1
+		}
+
+		if line[0] != '^' {
+			// split lines (S, E and $) repeat for each split, keeping only the transaction ones
+			if _, ok := fields[line[0]]; !ok {
+				fields[line[0]] = strings.TrimSpace(line[1:])
+			}
+			continue
+		}
+
+		transaction := fields
+		fields = map[byte]string{}
+		if len(transaction) == 0 {
+			continue
+		}
+
+		date, dateErr := parseQIFDate(transaction['D'], layouts, loc)
+		if dateErr != nil {
+			errs = append(errs, models.ImportError{Line: start, Message: dateErr.Error()})
+			continue
+		}
+
+		value, ok := transaction['T']
+		if !ok {
+			value = transaction['U']
+		}
+
+		amount, amountErr := ParseAmount(value, hasDecimalComma(value))
+		if amountErr != nil {
+			errs = append(errs, models.ImportError{Line: start, Message: amountErr.Error()})
+			continue
+		}
+
+		description := transaction['P']
+		if description == "" {
+			description = transaction['M']
+		}
+
+		record := statementRecord(start, o, creditCard, date, description, amount, "")
+		if record == nil {
+			skipped++
+			continue
+		}
+
+		records = append(records, *record)
+	}
+
+	return records, skipped, errs, nil
+}
@@ -0,0 +1,164 @@
+package importer
+
+import (
+	"budget-tracker-api/models"
+	"bytes"
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Options defines how transactions from OFX and QIF statements are imported
+type Options struct {
+	OwnerID primitive.ObjectID
+	// payment method given to imported spends, the chosen card or the debit account
+	PaymentMethod models.PaymentMethod
+	// timezone of statements dates without an explicit offset
+	Timezone string
+	// date format of QIF statements, such as DD/MM/YYYY
+	DateFormat string
+}
+
+// ErrMissingCard is returned when importing a credit card statement without choosing the card it belongs to
+var ErrMissingCard = errors.New("credit card statements must be imported with a 'card_id'")
+
+// windows1252 maps the 0x80-0x9F range of Windows-1252 into Unicode, the remaining bytes match Latin-1.
+// Bytes left undefined by Windows-1252 are kept as the Latin-1 control characters
+var windows1252 = [32]rune{
+	'\u20ac', '\u0081', '\u201a', '\u0192', '\u201e', '\u2026', '\u2020', '\u2021',
+	'\u02c6', '\u2030', '\u0160', '\u2039', '\u0152', '\u008d', '\u017d', '\u008f',
+	'\u0090', '\u2018', '\u2019', '\u201c', '\u201d', '\u2022', '\u2013', '\u2014',
+	'\u02dc', '\u2122', '\u0161', '\u203a', '\u0153', '\u009d', '\u017e', '\u0178',
+}
+
+// windows1252Charsets defines the charsets decoded as Windows-1252. Statements declaring Latin-1 are
+// decoded as Windows-1252 as well, as browsers do, since banks exports use both names for it
+var windows1252Charsets = map[string]bool{
+	"1252":         true,
+	"CP1252":       true,
+	"WINDOWS-1252": true,
+	"ISO-8859-1":   true,
+	"ISO8859-1":    true,
+	"8859-1":       true,
+	"LATIN1":       true,
+	"LATIN-1":      true,
+}
+
+// maxHeaderSize defines up to how many bytes from the start of a statement are searched for its headers
+const maxHeaderSize = 4096
+
+var (
+	xmlEncodingHeader  = regexp.MustCompile(`ENCODING\s*=\s*["']([^"']+)["']`)
+	sgmlEncodingHeader = regexp.MustCompile(`(?m)^\s*ENCODING\s*:\s*(\S+)`)
+	sgmlCharsetHeader  = regexp.MustCompile(`(?m)^\s*CHARSET\s*:\s*(\S+)`)
+)
+
+// statementCharset will return, in upper case, the charset declared by an OFX statement header: the
+// encoding of its XML declaration or, for the SGML variant, its CHARSET header unless its ENCODING one
+// is UTF-8. QIF statements and OFX ones declaring none return an empty charset
+func statementCharset(data []byte) string {
+	// headers come before the OFX root element, at the start of the file
+	header := data
+	if len(header) > maxHeaderSize {
+		header = header[:maxHeaderSize]
+	}
+
+	if i := strings.Index(strings.ToUpper(string(header)), "<OFX>"); i >= 0 {
+		header = bytes.ToUpper(header[:i])
+	} else {
+		return ""
+	}
+
+	if m := xmlEncodingHeader.FindSubmatch(header); m != nil {
+		return string(m[1])
+	}
+
+	if m := sgmlEncodingHeader.FindSubmatch(header); m != nil && (string(m[1]) == "UTF-8" || string(m[1]) == "UTF8") {
+		return "UTF-8"
+	}
+
+	if m := sgmlCharsetHeader.FindSubmatch(header); m != nil {
+		return string(m[1])
+	}
+
+	return ""
+}
+
+// decodeWindows1252 will decode Windows-1252 text into UTF-8
+func decodeWindows1252(data []byte) string {
+	runes := make([]rune, len(data))
+	for i, b := range data {
+		runes[i] = rune(b)
+		if b >= 0x80 && b <= 0x9f {
+			runes[i] = windows1252[b-0x80]
+		}
+	}
+	return string(runes)
+}
+
+// decodeStatement will decode a statement file based on the charset declared by its header. Files
+// declaring no charset are taken as UTF-8 when valid and as Windows-1252, the one used by most banks
+// exports, otherwise
+func decodeStatement(data []byte) string {
+	data = bytes.TrimPrefix(data, []byte("\ufeff"))
+
+	charset := statementCharset(data)
+	switch {
+	case charset == "UTF-8" || charset == "UTF8":
+		return strings.ToValidUTF8(string(data), "\ufffd")
+	case windows1252Charsets[charset]:
+		return decodeWindows1252(data)
+	case utf8.Valid(data):
+		return string(data)
+	}
+
+	return decodeWindows1252(data)
+}
+
+// hasDecimalComma will tell if an amount from a statement uses comma as its decimal separator, which
+// is the case when its last separator is a comma not followed by a thousands group
+func hasDecimalComma(value string) bool {
+	value = strings.TrimSpace(value)
+	i := strings.LastIndexAny(value, ".,")
+	return i >= 0 && value[i] == ',' && len(value)-i-1 != 3
+}
+
+// statementRecord will map a statement transaction into an import record. Debits are spends and
+// credits are income entries, except for credit card statements where credits are card payments or
+// refunds and are skipped
+func statementRecord(line int, o Options, creditCard bool, date time.Time, description string, amount float64, externalID string) (record *models.ImportRecord) {
+	switch {
+	case amount < 0:
+		return &models.ImportRecord{
+			Line: line,
+			Spend: &models.Spend{
+				OwnerID:       o.OwnerID,
+				Type:          "dynamic",
+				Description:   description,
+				Cost:          -amount,
+				PaymentMethod: o.PaymentMethod,
+				Date:          date,
+				Timezone:      o.Timezone,
+				ExternalID:    externalID,
+			},
+		}
+	case amount > 0 && !creditCard && !o.PaymentMethod.Credit:
+		return &models.ImportRecord{
+			Line: line,
+			Income: &models.IncomeEntry{
+				Source:      "other",
+				Description: description,
+				GrossIncome: amount,
+				NetIncome:   amount,
+				ReceivedAt:  date,
+				ExternalID:  externalID,
+			},
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,30 @@
+package importer
+
+import "testing"
+
+func TestDecodeStatement(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want string
+	}{
+		{"utf-8", "Caf\xc3\xa9", "Café"},
+		{"utf-8 with bom", "\xef\xbb\xbfCaf\xc3\xa9", "Café"},
+		{"undeclared latin-1", "Caf\xe9", "Café"},
+		{"undeclared windows-1252", "\x93quoted\x94 \x80 10", "“quoted” € 10"},
+		{"sgml charset 1252", "OFXHEADER:100\r\nENCODING:USASCII\r\nCHARSET:1252\r\n\r\n<OFX><NAME>P\xe3o \x96 \x80", "OFXHEADER:100\r\nENCODING:USASCII\r\nCHARSET:1252\r\n\r\n<OFX><NAME>Pão – €"},
+		{"sgml charset latin-1", "CHARSET:ISO-8859-1\n<OFX><NAME>\x80", "CHARSET:ISO-8859-1\n<OFX><NAME>€"},
+		{"sgml encoding utf-8", "ENCODING:UTF-8\nCHARSET:NONE\n<OFX><NAME>\xc3\xa9", "ENCODING:UTF-8\nCHARSET:NONE\n<OFX><NAME>é"},
+		{"sgml charset 1252 looking like utf-8", "CHARSET:1252\n<OFX><NAME>\xc3\xa9", "CHARSET:1252\n<OFX><NAME>Ã©"},
+		{"xml windows-1252", `<?xml version="1.0" encoding="windows-1252"?><OFX><NAME>` + "\x99", `<?xml version="1.0" encoding="windows-1252"?><OFX><NAME>™`},
+		{"xml utf-8 with invalid bytes", `<?xml version="1.0" encoding="UTF-8"?><OFX><NAME>` + "\xe9", `<?xml version="1.0" encoding="UTF-8"?><OFX><NAME>` + "�"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := decodeStatement([]byte(tt.data)); got != tt.want {
+				t.Errorf("decodeStatement(%q) = %q, want %q", tt.data, got, tt.want)
+			}
+		})
+	}
+}
@@ -27,10 +27,18 @@
 package main
 
 import (
+	"budget-tracker-api/attachments"
+	"budget-tracker-api/models"
+	"budget-tracker-api/notifications"
 	"budget-tracker-api/observability"
 	"budget-tracker-api/routes"
+	"budget-tracker-api/scheduler"
 	"budget-tracker-api/server"
+	"budget-tracker-api/taxes"
+	"context"
 	"crypto/tls"
+	"os"
+	"time"
 
 	"github.com/gorilla/mux"
 	log "github.com/sirupsen/logrus"
@@ -39,6 +47,8 @@ import (
 const (
 	port    = ":5000"
 	service = "budget-tracker-api"
+
+	schedulerInterval = 15 * time.Minute
 )
 
 func init() {
@@ -61,6 +71,49 @@ func main() {
 	observability.InitGlobalTrace(p.Jaeger)
 	observability.InitMetrics()
 
+	// the in-app inbox is always enabled, email and webhook notifications only when configured
+	notifiers := []notifications.Notifier{notifications.InboxNotifier{}}
+	if os.Getenv("SMTP_HOST") != "" {
+		notifiers = append(notifiers, notifications.SMTPNotifier{
+			Host:     os.Getenv("SMTP_HOST"),
+			Port:     os.Getenv("SMTP_PORT"),
+			Username: os.Getenv("SMTP_USERNAME"),
+			Password: os.Getenv("SMTP_PASSWORD"),
+			From:     os.Getenv("SMTP_FROM"),
+		})
+	}
+	if os.Getenv("NOTIFICATIONS_WEBHOOK_URL") != "" {
+		notifiers = append(notifiers, notifications.WebhookNotifier{URL: os.Getenv("NOTIFICATIONS_WEBHOOK_URL")})
+	}
+	notifications.Init(notifiers...)
+
+	// every spend booked to a balance, whether created, imported or recurring, may trigger notifications
+	models.OnSpendBooked(notifications.CheckSpendEvents)
+
+	// tax tables from other years, or fixing the built in ones
+	if os.Getenv("TAX_TABLES_FILE") != "" {
+		err = taxes.LoadTables(os.Getenv("TAX_TABLES_FILE"))
+		if err != nil {
+			log.Errorln("could not load tax tables:", err)
+		}
+	}
+
+	// attachments are kept at a S3-compatible bucket when configured, at the local filesystem otherwise
+	if os.Getenv("ATTACHMENTS_S3_BUCKET") != "" {
+		attachments.Init(attachments.S3{
+			Endpoint:  os.Getenv("ATTACHMENTS_S3_ENDPOINT"),
+			Region:    os.Getenv("ATTACHMENTS_S3_REGION"),
+			Bucket:    os.Getenv("ATTACHMENTS_S3_BUCKET"),
+			AccessKey: os.Getenv("ATTACHMENTS_S3_ACCESS_KEY"),
+			SecretKey: os.Getenv("ATTACHMENTS_S3_SECRET_KEY"),
+		})
+	} else if os.Getenv("ATTACHMENTS_DIR") != "" {
+		attachments.Init(attachments.FileSystem{Root: os.Getenv("ATTACHMENTS_DIR")})
+	}
+
+	// materializing recurring spends and incomes in background
+	go scheduler.Run(context.Background(), schedulerInterval)
+
 	router := mux.NewRouter()
 	routes.InitRoutes(service, router)
 
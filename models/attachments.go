@@ -0,0 +1,209 @@
+package models
+
+import (
+	"budget-tracker-api/observability"
+	"budget-tracker-api/services"
+	"context"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/x/bsonx"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// CreateAttachment registers an attachment of a spend whose file was already stored
+func CreateAttachment(parentCtx context.Context, a Attachment) (id string, err error) {
+	spanTags := []attribute.KeyValue{
+		attribute.Key("attachment.spend.id").String(a.SpendID.Hex()),
+	}
+
+	ctx, span := observability.Span(parentCtx, "mongodb", "CreateAttachment", spanTags)
+	defer span.End()
+
+	dbClient, err := services.InitDatabase()
+	if err != nil {
+		return "", err
+	}
+
+	col := dbClient.Database(mongodbDatabase).Collection(mongodbAttachmentsCollection)
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+
+	_, err = col.Indexes().CreateOne(
+		ctx,
+		mongo.IndexModel{
+			Keys: bsonx.Doc{{Key: "spend_id", Value: bsonx.Int32(1)}, {Key: "created_at", Value: bsonx.Int32(1)}},
+		},
+	)
+
+	// adding timestamp to creationDate
+	a.CreatedAt = primitive.NewDateTimeFromTime(time.Now())
+
+	r, err := col.InsertOne(ctx, a)
+	if err != nil {
+		cancel()
+		return "", err
+	}
+
+	defer cancel()
+
+	log.Infoln("created attachment", a.Filename, "to spend", a.SpendID.Hex())
+	return r.InsertedID.(primitive.ObjectID).Hex(), nil
+}
+
+// GetAttachment will return an attachment given an ID
+func GetAttachment(parentCtx context.Context, id string) (attachment *Attachment, err error) {
+	spanTags := []attribute.KeyValue{
+		attribute.Key("attachment.id").String(id),
+	}
+
+	ctx, span := observability.Span(parentCtx, "mongodb", "GetAttachment", spanTags)
+	defer span.End()
+
+	aid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return &Attachment{}, err
+	}
+
+	dbClient, err := services.InitDatabase()
+	if err != nil {
+		return &Attachment{}, err
+	}
+
+	col := dbClient.Database(mongodbDatabase).Collection(mongodbAttachmentsCollection)
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+
+	err = col.FindOne(ctx, bson.M{"_id": aid}).Decode(&attachment)
+	if err != nil {
+		cancel()
+		return &Attachment{}, err
+	}
+
+	defer cancel()
+	return attachment, nil
+}
+
+// GetSpendAttachments will return all attachments from a spend, oldest first
+func GetSpendAttachments(parentCtx context.Context, spendID string) (attachments []Attachment, err error) {
+	spanTags := []attribute.KeyValue{
+		attribute.Key("attachment.spend.id").String(spendID),
+	}
+
+	ctx, span := observability.Span(parentCtx, "mongodb", "GetSpendAttachments", spanTags)
+	defer span.End()
+
+	sid, err := primitive.ObjectIDFromHex(spendID)
+	if err != nil {
+		return []Attachment{}, err
+	}
+
+	dbClient, err := services.InitDatabase()
+	if err != nil {
+		return []Attachment{}, err
+	}
+
+	col := dbClient.Database(mongodbDatabase).Collection(mongodbAttachmentsCollection)
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	cursor, err := col.Find(ctx, bson.M{"spend_id": sid}, options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}}))
+	if err != nil {
+		cancel()
+		return []Attachment{}, err
+	}
+
+	defer cursor.Close(ctx)
+	defer cancel()
+
+	for cursor.Next(ctx) {
+		var attachment Attachment
+		cursor.Decode(&attachment)
+		attachments = append(attachments, attachment)
+	}
+
+	if err := cursor.Err(); err != nil {
+		cancel()
+		return []Attachment{}, err
+	}
+
+	return attachments, nil
+}
+
+// DeleteAttachment deletes an attachment given an ID, returning it so its file can be removed
+func DeleteAttachment(parentCtx context.Context, id string) (attachment *Attachment, err error) {
+	spanTags := []attribute.KeyValue{
+		attribute.Key("attachment.id").String(id),
+	}
+
+	ctx, span := observability.Span(parentCtx, "mongodb", "DeleteAttachment", spanTags)
+	defer span.End()
+
+	aid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return &Attachment{}, err
+	}
+
+	dbClient, err := services.InitDatabase()
+	if err != nil {
+		return &Attachment{}, err
+	}
+
+	col := dbClient.Database(mongodbDatabase).Collection(mongodbAttachmentsCollection)
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+
+	err = col.FindOneAndDelete(ctx, bson.M{"_id": aid}).Decode(&attachment)
+	if err != nil {
+		cancel()
+		return &Attachment{}, err
+	}
+
+	defer cancel()
+
+	log.Infoln("deleted attachment", id)
+	return attachment, nil
+}
+
+// DeleteSpendsAttachments deletes all attachments from the given spends, returning them so their files
+// can be removed
+func DeleteSpendsAttachments(parentCtx context.Context, spendIDs []primitive.ObjectID) (attachments []Attachment, err error) {
+	spanTags := []attribute.KeyValue{
+		attribute.Key("attachment.spends").Int(len(spendIDs)),
+	}
+
+	ctx, span := observability.Span(parentCtx, "mongodb", "DeleteSpendsAttachments", spanTags)
+	defer span.End()
+
+	dbClient, err := services.InitDatabase()
+	if err != nil {
+		return []Attachment{}, err
+	}
+
+	col := dbClient.Database(mongodbDatabase).Collection(mongodbAttachmentsCollection)
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{"spend_id": bson.M{"$in": spendIDs}}
+
+	cursor, err := col.Find(ctx, filter)
+	if err != nil {
+		return []Attachment{}, err
+	}
+
+	err = cursor.All(ctx, &attachments)
+	if err != nil {
+		return []Attachment{}, err
+	}
+
+	if len(attachments) == 0 {
+		return []Attachment{}, nil
+	}
+
+	_, err = col.DeleteMany(ctx, filter)
+	if err != nil {
+		return []Attachment{}, err
+	}
+
+	log.Infoln("deleted", len(attachments), "attachments from", len(spendIDs), "spends")
+	return attachments, nil
+}
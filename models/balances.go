@@ -4,6 +4,7 @@ import (
 	"budget-tracker-api/observability"
 	"budget-tracker-api/services"
 	"context"
+	"errors"
 	"time"
 
 	log "github.com/sirupsen/logrus"
@@ -15,6 +16,16 @@ import (
 	"go.opentelemetry.io/otel/attribute"
 )
 
+// balancesPeriodIndex keeps a single balance per owner and month
+var balancesPeriodIndex = mongo.IndexModel{
+	Keys: bsonx.Doc{
+		{Key: "owner_id", Value: bsonx.Int32(1)},
+		{Key: "month", Value: bsonx.Int32(1)},
+		{Key: "year", Value: bsonx.Int32(1)},
+	},
+	Options: options.Index().SetUnique(true),
+}
+
 // CreateBalance creates a balance for a given owner_id
 func CreateBalance(parentCtx context.Context, b Balance) (id string, err error) {
 	spanTags := []attribute.KeyValue{
@@ -32,25 +43,32 @@ func CreateBalance(parentCtx context.Context, b Balance) (id string, err error)
 	col := dbClient.Database(mongodbDatabase).Collection(mongodbBalanceCollection)
 	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 
-	_, err = col.Indexes().CreateOne(
-		ctx,
-		mongo.IndexModel{
-			Keys: bsonx.Doc{
-				{Key: "owner_id", Value: bsonx.Int32(1)},
-				{Key: "month", Value: bsonx.Int32(1)},
-				{Key: "year", Value: bsonx.Int32(1)},
-			},
-			Options: options.Index().SetUnique(true),
-		},
-	)
+	_, err = col.Indexes().CreateOne(ctx, balancesPeriodIndex)
 
 	// adding timestamp to creationDate
 	t := time.Now()
 	b.CreatedAt = primitive.NewDateTimeFromTime(t)
 	b.UpdatedAt = primitive.NewDateTimeFromTime(t)
-	b.SpendableAmount = b.Income.NetIncome
 	b.Historic = []Spend{}
 
+	// balances informing only income totals get them as a single entry
+	if len(b.Incomes) == 0 && (b.Income.GrossIncome != 0 || b.Income.NetIncome != 0) {
+		b.Incomes = []IncomeEntry{legacyIncomeEntry(b.Income)}
+	}
+
+	if b.Incomes == nil {
+		b.Incomes = []IncomeEntry{}
+	}
+
+	for i := range b.Incomes {
+		b.Incomes[i].ID = primitive.NewObjectID()
+	}
+
+	totals := IncomeTotals(b.Incomes)
+	b.Income.GrossIncome = totals.GrossIncome
+	b.Income.NetIncome = totals.NetIncome
+	b.SpendableAmount = b.Income.NetIncome + b.Income.PreviousMonth
+
 	r, err := col.InsertOne(ctx, b)
 	if err != nil {
 		cancel()
@@ -65,6 +83,38 @@ func CreateBalance(parentCtx context.Context, b Balance) (id string, err error)
 	return r.InsertedID.(primitive.ObjectID).Hex(), nil
 }
 
+// legacyIncomeEntry will return the single income entry standing for the income totals of a balance
+// created before income entries existed
+func legacyIncomeEntry(income Income) IncomeEntry {
+	return IncomeEntry{Source: "other", GrossIncome: income.GrossIncome, NetIncome: income.NetIncome}
+}
+
+// migrateLegacyIncome will turn the income totals of a balance created before income entries existed
+// into a single entry, so entries added later on add up to them
+func migrateLegacyIncome(ctx context.Context, col *mongo.Collection, ownerID primitive.ObjectID, month int64, year int64) (err error) {
+	_, err = col.UpdateOne(ctx,
+		bson.M{
+			"owner_id":  ownerID,
+			"month":     month,
+			"year":      year,
+			"closed":    bson.M{"$ne": true},
+			"incomes.0": bson.M{"$exists": false},
+			"$or": bson.A{
+				bson.M{"income.gross": bson.M{"$nin": bson.A{0, nil}}},
+				bson.M{"income.net": bson.M{"$nin": bson.A{0, nil}}},
+			},
+		},
+		bson.A{bson.M{"$set": bson.M{"incomes": bson.A{bson.M{
+			"_id":       primitive.NewObjectID(),
+			"source":    "other",
+			"gross":     bson.M{"$ifNull": bson.A{"$income.gross", 0}},
+			"net":       bson.M{"$ifNull": bson.A{"$income.net", 0}},
+			"recurring": false,
+		}}}}},
+	)
+	return err
+}
+
 // GetBalance will return a balance from an owner_id based on month and year
 func GetBalance(parentCtx context.Context, ownerID string, month int64, year int64) (balance *Balance, err error) {
 	spanTags := []attribute.KeyValue{
@@ -145,3 +195,376 @@ func GetAllBalances(parentCtx context.Context, ownerID string) (balances []Balan
 
 	return balances, nil
 }
+
+// AddSpendToBalance will add a spend to the balance of the month it belongs to, updating
+// its outcome and spendable amount. The balance is created when it doesn't exist yet
+func AddSpendToBalance(parentCtx context.Context, s Spend) (err error) {
+	spanTags := []attribute.KeyValue{
+		attribute.Key("balance.owner.id").String(s.OwnerID.String()),
+		attribute.Key("spend.id").String(s.ID.Hex()),
+	}
+
+	ctx, span := observability.Span(parentCtx, "mongodb", "AddSpendToBalance", spanTags)
+	defer span.End()
+
+	dbClient, err := services.InitDatabase()
+	if err != nil {
+		return err
+	}
+
+	outcomeField := "outcome.dynamic"
+	if s.Type == "fixed" {
+		outcomeField = "outcome.fixed"
+	}
+
+	col := dbClient.Database(mongodbDatabase).Collection(mongodbBalanceCollection)
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+
+	_, err = col.Indexes().CreateOne(ctx, balancesPeriodIndex)
+
+	// a closed balance isn't matched, so the upsert hits the unique index instead
+	t := primitive.NewDateTimeFromTime(time.Now())
+	_, err = col.UpdateOne(ctx,
+		bson.M{
+			"owner_id": s.OwnerID,
+			"month":    s.Month,
+			"year":     s.Year,
+			"closed":   bson.M{"$ne": true},
+		},
+		bson.M{
+			"$push": bson.M{"historic": s},
+			"$inc": bson.M{
+				outcomeField:       s.Cost,
+				"spendable_amount": -s.Cost,
+			},
+			"$set":         bson.M{"updated_at": t},
+			"$setOnInsert": emptyBalance(t, bson.M{"incomes": bson.A{}, "income": Income{}}),
+		},
+		options.Update().SetUpsert(true),
+	)
+	if mongo.IsDuplicateKeyError(err) {
+		cancel()
+		return ErrBalanceClosed
+	}
+
+	if err != nil {
+		cancel()
+		return err
+	}
+
+	defer cancel()
+
+	log.Infoln("added spend", s.ID.Hex(), "to balance")
+	return nil
+}
+
+// emptyBalance will return the attributes a balance upserted by a spend or income starts with, along
+// with the given ones. Owner, period and the upserted amounts come from the upsert itself
+func emptyBalance(t primitive.DateTime, fields bson.M) bson.M {
+	fields["currency"] = ""
+	fields["closed"] = false
+	fields["created_at"] = t
+	return fields
+}
+
+// RemoveSpendFromBalance will remove a spend from the balance of the month it belongs to, reverting its
+// outcome and spendable amount. Spends never added to a balance are left alone
+func RemoveSpendFromBalance(parentCtx context.Context, s Spend) (err error) {
+	spanTags := []attribute.KeyValue{
+		attribute.Key("balance.owner.id").String(s.OwnerID.String()),
+		attribute.Key("spend.id").String(s.ID.Hex()),
+	}
+
+	ctx, span := observability.Span(parentCtx, "mongodb", "RemoveSpendFromBalance", spanTags)
+	defer span.End()
+
+	dbClient, err := services.InitDatabase()
+	if err != nil {
+		return err
+	}
+
+	outcomeField := "outcome.dynamic"
+	if s.Type == "fixed" {
+		outcomeField = "outcome.fixed"
+	}
+
+	col := dbClient.Database(mongodbDatabase).Collection(mongodbBalanceCollection)
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+
+	result, err := col.UpdateOne(ctx,
+		bson.M{
+			"owner_id":     s.OwnerID,
+			"month":        s.Month,
+			"year":         s.Year,
+			"closed":       bson.M{"$ne": true},
+			"historic._id": s.ID,
+		},
+		bson.M{
+			"$pull": bson.M{"historic": bson.M{"_id": s.ID}},
+			"$inc": bson.M{
+				outcomeField:       -s.Cost,
+				"spendable_amount": s.Cost,
+			},
+			"$set": bson.M{"updated_at": primitive.NewDateTimeFromTime(time.Now())},
+		},
+	)
+	if err != nil {
+		cancel()
+		return err
+	}
+
+	defer cancel()
+
+	if result.ModifiedCount > 0 {
+		log.Infoln("removed spend", s.ID.Hex(), "from balance")
+	}
+	return nil
+}
+
+// AddIncomeToBalance will add an income entry to the balance of a given month, creating the balance
+// when it doesn't exist yet
+func AddIncomeToBalance(parentCtx context.Context, ownerID primitive.ObjectID, month int64, year int64, e IncomeEntry) (id string, err error) {
+	spanTags := []attribute.KeyValue{
+		attribute.Key("balance.owner.id").String(ownerID.String()),
+	}
+
+	ctx, span := observability.Span(parentCtx, "mongodb", "AddIncomeToBalance", spanTags)
+	defer span.End()
+
+	dbClient, err := services.InitDatabase()
+	if err != nil {
+		return "", err
+	}
+
+	col := dbClient.Database(mongodbDatabase).Collection(mongodbBalanceCollection)
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+
+	_, err = col.Indexes().CreateOne(ctx, balancesPeriodIndex)
+
+	err = migrateLegacyIncome(ctx, col, ownerID, month, year)
+	if err != nil {
+		cancel()
+		return "", err
+	}
+
+	// a closed balance isn't matched, so the upsert hits the unique index instead
+	e.ID = primitive.NewObjectID()
+	t := primitive.NewDateTimeFromTime(time.Now())
+	_, err = col.UpdateOne(ctx,
+		bson.M{
+			"owner_id": ownerID,
+			"month":    month,
+			"year":     year,
+			"closed":   bson.M{"$ne": true},
+		},
+		bson.M{
+			"$push": bson.M{"incomes": e},
+			"$inc": bson.M{
+				"income.gross":     e.GrossIncome,
+				"income.net":       e.NetIncome,
+				"spendable_amount": e.NetIncome,
+			},
+			"$set":         bson.M{"updated_at": t},
+			"$setOnInsert": emptyBalance(t, bson.M{"historic": bson.A{}, "outcome": Outcome{}}),
+		},
+		options.Update().SetUpsert(true),
+	)
+	if mongo.IsDuplicateKeyError(err) {
+		cancel()
+		return "", ErrBalanceClosed
+	}
+
+	if err != nil {
+		cancel()
+		return "", err
+	}
+
+	defer cancel()
+
+	log.Infoln("added income to balance", month, "/", year, "from", ownerID.Hex())
+	return e.ID.Hex(), nil
+}
+
+// UpdateBalance will update the incomes and currency from an owner_id month balance. Income changes are
+// reflected at the spendable amount
+func UpdateBalance(parentCtx context.Context, ownerID string, month int64, year int64, u BalanceUpdate) (err error) {
+	spanTags := []attribute.KeyValue{
+		attribute.Key("balance.owner.id").String(ownerID),
+	}
+
+	ctx, span := observability.Span(parentCtx, "mongodb", "UpdateBalance", spanTags)
+	defer span.End()
+
+	balance, err := GetBalance(ctx, ownerID, month, year)
+	if err != nil {
+		return err
+	}
+
+	if balance.Closed {
+		return ErrBalanceClosed
+	}
+
+	fields := bson.M{"updated_at": primitive.NewDateTimeFromTime(time.Now())}
+	inc := bson.M{}
+
+	if u.Incomes != nil {
+		incomes := *u.Incomes
+		for i := range incomes {
+			incomes[i].ID = primitive.NewObjectID()
+		}
+
+		totals := IncomeTotals(incomes)
+		fields["incomes"] = incomes
+		fields["income.gross"] = totals.GrossIncome
+		fields["income.net"] = totals.NetIncome
+		inc["spendable_amount"] = totals.NetIncome - balance.Income.NetIncome
+	}
+
+	if u.Currency != nil {
+		fields["currency"] = *u.Currency
+	}
+
+	update := bson.M{"$set": fields}
+	if len(inc) > 0 {
+		update["$inc"] = inc
+	}
+
+	dbClient, err := services.InitDatabase()
+	if err != nil {
+		return err
+	}
+
+	col := dbClient.Database(mongodbDatabase).Collection(mongodbBalanceCollection)
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+
+	// matching the income read above so concurrent income changes aren't overwritten
+	result, err := col.UpdateOne(ctx,
+		bson.M{"_id": balance.ID, "closed": bson.M{"$ne": true}, "income.net": balance.Income.NetIncome},
+		update,
+	)
+	if err != nil {
+		cancel()
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		cancel()
+		return errors.New("balance was changed concurrently, try again")
+	}
+
+	defer cancel()
+
+	log.Infoln("updated balance", month, "/", year, "from", ownerID)
+	return nil
+}
+
+// RecomputeBalance will rebuild the historic, income totals, outcome and spendable amount from an owner_id
+// month balance based on its income entries and the spends, goal contributions and refunds accounted to that
+// month, repairing any drifted data
+func RecomputeBalance(parentCtx context.Context, ownerID string, month int64, year int64) (balance *Balance, err error) {
+	spanTags := []attribute.KeyValue{
+		attribute.Key("balance.owner.id").String(ownerID),
+	}
+
+	ctx, span := observability.Span(parentCtx, "mongodb", "RecomputeBalance", spanTags)
+	defer span.End()
+
+	balance, err = GetBalance(ctx, ownerID, month, year)
+	if err != nil {
+		return &Balance{}, err
+	}
+
+	if balance.Closed {
+		return &Balance{}, ErrBalanceClosed
+	}
+
+	spends, err := GetMonthSpends(ctx, balance.OwnerID, month, year)
+	if err != nil {
+		return &Balance{}, err
+	}
+
+	outcome := Outcome{}
+	historic := []Spend{}
+	for _, s := range spends {
+		if s.Type == "fixed" {
+			outcome.FixedOutcome += s.Cost
+		} else {
+			outcome.DynamicOutcome += s.Cost
+		}
+		historic = append(historic, s)
+	}
+
+	// balances created before income entries get their income totals as a single entry
+	incomes := balance.Incomes
+	if len(incomes) == 0 && (balance.Income.GrossIncome != 0 || balance.Income.NetIncome != 0) {
+		legacy := legacyIncomeEntry(balance.Income)
+		legacy.ID = primitive.NewObjectID()
+		incomes = []IncomeEntry{legacy}
+	}
+
+	if incomes == nil {
+		incomes = []IncomeEntry{}
+	}
+
+	income := balance.Income
+	totals := IncomeTotals(incomes)
+	income.GrossIncome = totals.GrossIncome
+	income.NetIncome = totals.NetIncome
+
+	contributions, err := GetMonthContributions(ctx, balance.OwnerID, month, year)
+	if err != nil {
+		return &Balance{}, err
+	}
+
+	for _, c := range contributions {
+		outcome.Savings += c.Amount
+	}
+
+	refunds, err := GetMonthRefunds(ctx, balance.OwnerID, month, year)
+	if err != nil {
+		return &Balance{}, err
+	}
+
+	for _, r := range refunds {
+		if r.SpendType == "fixed" {
+			outcome.FixedOutcome -= r.Amount
+		} else {
+			outcome.DynamicOutcome -= r.Amount
+		}
+	}
+
+	outcome.FixedOutcome = roundCents(outcome.FixedOutcome)
+	outcome.DynamicOutcome = roundCents(outcome.DynamicOutcome)
+	outcome.Savings = roundCents(outcome.Savings)
+	spendable := roundCents(income.NetIncome + income.PreviousMonth - outcome.FixedOutcome - outcome.DynamicOutcome - outcome.Savings)
+
+	dbClient, err := services.InitDatabase()
+	if err != nil {
+		return &Balance{}, err
+	}
+
+	col := dbClient.Database(mongodbDatabase).Collection(mongodbBalanceCollection)
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+
+	err = col.FindOneAndUpdate(ctx,
+		bson.M{"_id": balance.ID, "closed": bson.M{"$ne": true}},
+		bson.M{"$set": bson.M{
+			"historic":         historic,
+			"incomes":          incomes,
+			"income":           income,
+			"outcome":          outcome,
+			"spendable_amount": spendable,
+			"updated_at":       primitive.NewDateTimeFromTime(time.Now()),
+		}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&balance)
+	if err != nil {
+		cancel()
+		return &Balance{}, err
+	}
+
+	defer cancel()
+
+	log.Infoln("recomputed balance", month, "/", year, "from", ownerID, "with", len(historic), "spends")
+	return balance, nil
+}
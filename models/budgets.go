@@ -0,0 +1,284 @@
+package models
+
+import (
+	"budget-tracker-api/observability"
+	"budget-tracker-api/services"
+	"context"
+	"errors"
+	"math"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// previousPeriod will return the month and year right before a given one
+func previousPeriod(month int64, year int64) (int64, int64) {
+	if month == 1 {
+		return 12, year - 1
+	}
+	return month - 1, year
+}
+
+// roundCents will round a monetary value to its cents
+func roundCents(value float64) float64 {
+	return math.Round(value*100) / 100
+}
+
+// GetMonthSpends will return all spends from an owner_id accounted to a given month balance
+func GetMonthSpends(parentCtx context.Context, ownerID primitive.ObjectID, month int64, year int64) (spends []Spend, err error) {
+	spanTags := []attribute.KeyValue{
+		attribute.Key("spend.owner.id").String(ownerID.String()),
+	}
+
+	ctx, span := observability.Span(parentCtx, "mongodb", "GetMonthSpends", spanTags)
+	defer span.End()
+
+	dbClient, err := services.InitDatabase()
+	if err != nil {
+		return []Spend{}, err
+	}
+
+	filter := bookedSpendsFilter()
+	filter["owner_id"] = ownerID
+	filter["month"] = month
+	filter["year"] = year
+
+	col := dbClient.Database(mongodbDatabase).Collection(mongodbSpendsCollection)
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	cursor, err := col.Find(ctx, filter)
+	if err != nil {
+		cancel()
+		return []Spend{}, err
+	}
+
+	defer cursor.Close(ctx)
+	defer cancel()
+
+	for cursor.Next(ctx) {
+		var spend Spend
+		cursor.Decode(&spend)
+		spends = append(spends, spend)
+	}
+
+	if err := cursor.Err(); err != nil {
+		cancel()
+		return []Spend{}, err
+	}
+
+	return spends, nil
+}
+
+// categoryDescendants will return a category ID along with all its descendants IDs
+func categoryDescendants(categories []Category, id primitive.ObjectID) map[primitive.ObjectID]bool {
+	descendants := map[primitive.ObjectID]bool{id: true}
+
+	// categories may be listed before their parents, so it loops until no descendant is found
+	for found := true; found; {
+		found = false
+		for _, c := range categories {
+			if descendants[c.ParentID] && !descendants[c.ID] {
+				descendants[c.ID] = true
+				found = true
+			}
+		}
+	}
+
+	return descendants
+}
+
+// BudgetsProgress will compute how much was spent from each budget. A spend counts towards a budget
+// when it references the budget category or any of its descendants
+func BudgetsProgress(budgets []CategoryBudget, categories []Category, spends []Spend) (progress []BudgetProgress) {
+	progress = []BudgetProgress{}
+
+	for _, b := range budgets {
+		subtree := categoryDescendants(categories, b.CategoryID)
+
+		spent := 0.0
+		for _, s := range spends {
+			for _, id := range s.CategoryIDs {
+				if subtree[id] {
+					spent += s.Cost
+					break
+				}
+			}
+		}
+
+		budgeted := roundCents(b.Amount + b.CarriedOver)
+		p := BudgetProgress{
+			CategoryID: b.CategoryID,
+			Category:   b.Category,
+			Budgeted:   budgeted,
+			Spent:      roundCents(spent),
+			Remaining:  roundCents(budgeted - spent),
+			Overspent:  spent > budgeted,
+		}
+
+		if budgeted > 0 {
+			p.Percentage = math.Round(spent/budgeted*10000) / 100
+		}
+
+		progress = append(progress, p)
+	}
+
+	return progress
+}
+
+// GetBudgetsProgress will return the progress of every category budget from a month balance
+func GetBudgetsProgress(parentCtx context.Context, ownerID string, month int64, year int64) (progress []BudgetProgress, err error) {
+	spanTags := []attribute.KeyValue{
+		attribute.Key("balance.owner.id").String(ownerID),
+	}
+
+	ctx, span := observability.Span(parentCtx, "mongodb", "GetBudgetsProgress", spanTags)
+	defer span.End()
+
+	balance, err := GetBalance(ctx, ownerID, month, year)
+	if err != nil {
+		return []BudgetProgress{}, err
+	}
+
+	return balanceBudgetsProgress(ctx, *balance)
+}
+
+// balanceBudgetsProgress will return the progress of every category budget from a given balance
+func balanceBudgetsProgress(ctx context.Context, balance Balance) (progress []BudgetProgress, err error) {
+	if len(balance.Budgets) == 0 {
+		return []BudgetProgress{}, nil
+	}
+
+	categories, err := GetCategories(ctx, balance.OwnerID.Hex())
+	if err != nil {
+		return []BudgetProgress{}, err
+	}
+
+	spends, err := GetMonthSpends(ctx, balance.OwnerID, balance.Month, balance.Year)
+	if err != nil {
+		return []BudgetProgress{}, err
+	}
+
+	// refunds received within the month give back to the budgets of the refunded spends
+	refunds, err := GetMonthRefunds(ctx, balance.OwnerID, balance.Month, balance.Year)
+	if err != nil {
+		return []BudgetProgress{}, err
+	}
+
+	credits, err := refundSpends(ctx, refunds)
+	if err != nil {
+		return []BudgetProgress{}, err
+	}
+
+	spends = append(spends, credits...)
+	return BudgetsProgress(balance.Budgets, categories, spends), nil
+}
+
+// SetBalanceBudgets will replace the category budgets from a month balance. Budgets with carry over
+// enabled at the previous month will receive their unspent amount
+func SetBalanceBudgets(parentCtx context.Context, ownerID string, month int64, year int64, budgets []CategoryBudget) (err error) {
+	spanTags := []attribute.KeyValue{
+		attribute.Key("balance.owner.id").String(ownerID),
+	}
+
+	ctx, span := observability.Span(parentCtx, "mongodb", "SetBalanceBudgets", spanTags)
+	defer span.End()
+
+	oid, err := primitive.ObjectIDFromHex(ownerID)
+	if err != nil {
+		return err
+	}
+
+	carried := map[primitive.ObjectID]float64{}
+
+	prevMonth, prevYear := previousPeriod(month, year)
+	previous, err := GetBalance(ctx, ownerID, prevMonth, prevYear)
+	if err != nil && err != mongo.ErrNoDocuments {
+		return err
+	}
+
+	if err == nil {
+		progress, err := balanceBudgetsProgress(ctx, *previous)
+		if err != nil {
+			return err
+		}
+
+		for i, b := range previous.Budgets {
+			if b.CarryOver && progress[i].Remaining > 0 {
+				carried[b.CategoryID] = progress[i].Remaining
+			}
+		}
+	}
+
+	for i := range budgets {
+		budgets[i].CarriedOver = carried[budgets[i].CategoryID]
+	}
+
+	dbClient, err := services.InitDatabase()
+	if err != nil {
+		return err
+	}
+
+	col := dbClient.Database(mongodbDatabase).Collection(mongodbBalanceCollection)
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+
+	result, err := col.UpdateOne(ctx,
+		bson.M{"owner_id": oid, "month": month, "year": year, "closed": bson.M{"$ne": true}},
+		bson.M{"$set": bson.M{
+			"budgets":    budgets,
+			"updated_at": primitive.NewDateTimeFromTime(time.Now()),
+		}},
+	)
+	if err != nil {
+		cancel()
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		cancel()
+		return errors.New("non existent or closed balance")
+	}
+
+	defer cancel()
+
+	log.Infoln("set", len(budgets), "budgets to balance from", ownerID)
+	return nil
+}
+
+// ValidateBudgets will validate if all budgets have positive amounts and reference distinct categories
+// from the balance owner, filling their category names
+func ValidateBudgets(ctx context.Context, ownerID primitive.ObjectID, budgets []CategoryBudget) (validated []CategoryBudget, errs []FieldError, err error) {
+	errs = []FieldError{}
+
+	ids := []primitive.ObjectID{}
+	seen := map[primitive.ObjectID]bool{}
+	for _, b := range budgets {
+		if b.Amount <= 0 {
+			errs = append(errs, FieldError{Field: "amount", Message: "budget amount for category '" + b.CategoryID.Hex() + "' must be greater than zero"})
+		}
+
+		if seen[b.CategoryID] {
+			errs = append(errs, FieldError{Field: "category_id", Message: "duplicated budget for category '" + b.CategoryID.Hex() + "'"})
+		}
+		seen[b.CategoryID] = true
+		ids = append(ids, b.CategoryID)
+	}
+
+	resolved, categoriesErrs, err := ResolveSpendCategories(ctx, Spend{OwnerID: ownerID, CategoryIDs: ids})
+	if err != nil {
+		return budgets, errs, err
+	}
+
+	errs = append(errs, categoriesErrs...)
+	if len(errs) > 0 {
+		return budgets, errs, nil
+	}
+
+	for i := range budgets {
+		budgets[i].Category = resolved.Categories[i]
+	}
+
+	return budgets, errs, nil
+}
@@ -9,6 +9,7 @@ import (
 
 	log "github.com/sirupsen/logrus"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
@@ -16,6 +17,42 @@ import (
 	"go.opentelemetry.io/otel/attribute"
 )
 
+// UnmarshalBSON will decode a payment method, including the ones stored before cards were referenced by
+// ID, which embedded the whole card as 'credit'. Those embedded an empty card even when not credit
+func (p *PaymentMethod) UnmarshalBSON(data []byte) error {
+	var raw struct {
+		Credit      bson.RawValue      `bson:"credit"`
+		CardID      primitive.ObjectID `bson:"card_id"`
+		Debit       bool               `bson:"debit"`
+		PaymentSlip bool               `bson:"payment_slip"`
+	}
+
+	err := bson.Unmarshal(data, &raw)
+	if err != nil {
+		return err
+	}
+
+	*p = PaymentMethod{CardID: raw.CardID, Debit: raw.Debit, PaymentSlip: raw.PaymentSlip}
+
+	switch raw.Credit.Type {
+	case bsontype.Boolean:
+		p.Credit = raw.Credit.Boolean()
+	case bsontype.EmbeddedDocument:
+		var card CreditCard
+		err = raw.Credit.Unmarshal(&card)
+		if err != nil {
+			return err
+		}
+
+		p.Credit = !card.ID.IsZero() || card.Alias != "" || card.Network != "" || card.LastDigits != 0
+		if p.CardID.IsZero() {
+			p.CardID = card.ID
+		}
+	}
+
+	return nil
+}
+
 // CreateCard creates a card for a given owner_id
 func CreateCard(parentCtx context.Context, c CreditCard) (id string, err error) {
 	spanTags := []attribute.KeyValue{
@@ -95,6 +132,38 @@ func GetAllCards(parentCtx context.Context) (cards []CreditCard, err error) {
 	return cards, nil
 }
 
+// GetCard will return a single card based on its ID
+func GetCard(parentCtx context.Context, id string) (card *CreditCard, err error) {
+	spanTags := []attribute.KeyValue{
+		attribute.Key("card.id").String(id),
+	}
+
+	ctx, span := observability.Span(parentCtx, "mongodb", "GetCard", spanTags)
+	defer span.End()
+
+	cid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return &CreditCard{}, err
+	}
+
+	dbClient, err := services.InitDatabase()
+	if err != nil {
+		return &CreditCard{}, err
+	}
+
+	col := dbClient.Database(mongodbDatabase).Collection(mongodbCardsCollection)
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+
+	err = col.FindOne(ctx, bson.M{"_id": cid}).Decode(&card)
+	if err != nil {
+		cancel()
+		return &CreditCard{}, err
+	}
+
+	defer cancel()
+	return card, nil
+}
+
 // GetCards will return a list of cards from a owner_id
 func GetCards(parentCtx context.Context, ownerID string) (cards []CreditCard, err error) {
 	spanTags := []attribute.KeyValue{
@@ -140,6 +209,63 @@ func GetCards(parentCtx context.Context, ownerID string) (cards []CreditCard, er
 	return cards, nil
 }
 
+// GetCardStatement will return the total charged at a card statement of a given month, net of the
+// merchant refunds credited to it
+func GetCardStatement(parentCtx context.Context, card CreditCard, month int64, year int64) (total float64, err error) {
+	spanTags := []attribute.KeyValue{
+		attribute.Key("card.id").String(card.ID.Hex()),
+	}
+
+	ctx, span := observability.Span(parentCtx, "mongodb", "GetCardStatement", spanTags)
+	defer span.End()
+
+	dbClient, err := services.InitDatabase()
+	if err != nil {
+		return 0, err
+	}
+
+	statement := bson.M{"owner_id": card.OwnerID, "payment_method.card_id": card.ID, "month": month, "year": year}
+	refunds := bson.M{"kind": "refund", "status": "received"}
+	for k, v := range statement {
+		refunds[k] = v
+	}
+
+	col := dbClient.Database(mongodbDatabase).Collection(mongodbSpendsCollection)
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	cursor, err := col.Aggregate(ctx, bson.A{
+		bson.M{"$match": bson.M{"$and": bson.A{cardChargesFilter(), statement}}},
+		bson.M{"$project": bson.M{"cost": 1}},
+		bson.M{"$unionWith": bson.M{"coll": mongodbRefundsCollection, "pipeline": bson.A{
+			bson.M{"$match": refunds},
+			bson.M{"$project": bson.M{"cost": bson.M{"$multiply": bson.A{-1, "$amount"}}}},
+		}}},
+		bson.M{"$group": bson.M{"_id": nil, "total": bson.M{"$sum": "$cost"}}},
+	})
+	if err != nil {
+		cancel()
+		return 0, err
+	}
+
+	defer cursor.Close(ctx)
+	defer cancel()
+
+	var result struct {
+		Total float64 `bson:"total"`
+	}
+	if cursor.Next(ctx) {
+		err = cursor.Decode(&result)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	if err := cursor.Err(); err != nil {
+		return 0, err
+	}
+
+	return roundCents(result.Total), nil
+}
+
 // DeleteCard creates an user based on request body payload
 func DeleteCard(parentCtx context.Context, id string) (err error) {
 	spanTags := []attribute.KeyValue{
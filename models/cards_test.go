@@ -0,0 +1,48 @@
+package models
+
+import (
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestPaymentMethodUnmarshalBSON(t *testing.T) {
+	cardID := primitive.NewObjectID()
+
+	tests := []struct {
+		name   string
+		stored bson.M
+		want   PaymentMethod
+	}{
+		{"credit referencing a card", bson.M{"credit": true, "card_id": cardID}, PaymentMethod{Credit: true, CardID: cardID}},
+		{"debit", bson.M{"debit": true}, PaymentMethod{Debit: true}},
+		{"payment slip", bson.M{"payment_slip": true}, PaymentMethod{PaymentSlip: true}},
+		{"legacy embedded card", bson.M{"credit": bson.M{"_id": cardID, "alias": "My Platinum Card", "network": "VISA", "last_digits": int32(1234)}}, PaymentMethod{Credit: true, CardID: cardID}},
+		{"legacy embedded card without ID", bson.M{"credit": bson.M{"alias": "My Platinum Card", "network": "VISA"}}, PaymentMethod{Credit: true}},
+		{"legacy empty card with debit", bson.M{"credit": bson.M{"alias": "", "network": "", "color": "", "last_digits": int32(0)}, "debit": true}, PaymentMethod{Debit: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := bson.Marshal(bson.M{"description": "groceries", "payment_method": tt.stored})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var s Spend
+			err = bson.Unmarshal(data, &s)
+			if err != nil {
+				t.Fatalf("bson.Unmarshal() error = %v", err)
+			}
+
+			if s.PaymentMethod != tt.want {
+				t.Errorf("PaymentMethod = %+v, want %+v", s.PaymentMethod, tt.want)
+			}
+
+			if s.Description != "groceries" {
+				t.Errorf("Description = %q, want %q", s.Description, "groceries")
+			}
+		})
+	}
+}
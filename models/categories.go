@@ -0,0 +1,717 @@
+package models
+
+import (
+	"budget-tracker-api/observability"
+	"budget-tracker-api/services"
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/x/bsonx"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// defaultCategory defines a category seeded to every user
+type defaultCategory struct {
+	name     string
+	icon     string
+	color    string
+	children []string
+}
+
+// defaultCategories defines the categories seeded to every user
+var defaultCategories = []defaultCategory{
+	{name: "housing", icon: "home", color: "#795548", children: []string{"rent", "utilities", "maintenance"}},
+	{name: "groceries", icon: "shopping-cart", color: "#4caf50"},
+	{name: "transportation", icon: "car", color: "#2196f3", children: []string{"fuel", "public transport", "ride hailing"}},
+	{name: "health", icon: "heart", color: "#f44336", children: []string{"pharmacy", "health insurance"}},
+	{name: "leisure", icon: "smile", color: "#ff9800", children: []string{"restaurants", "streaming", "travel"}},
+	{name: "education", icon: "book", color: "#9c27b0", children: []string{"personal development"}},
+	{name: "others", icon: "tag", color: "#9e9e9e"},
+}
+
+// categoriesCollection will return the categories collection along with its indexes
+func categoriesCollection(ctx context.Context) (col *mongo.Collection, err error) {
+	dbClient, err := services.InitDatabase()
+	if err != nil {
+		return nil, err
+	}
+
+	col = dbClient.Database(mongodbDatabase).Collection(mongodbCategoriesCollection)
+
+	_, err = col.Indexes().CreateOne(
+		ctx,
+		mongo.IndexModel{
+			Keys:    bsonx.Doc{{Key: "owner_id", Value: bsonx.Int32(1)}, {Key: "name", Value: bsonx.Int32(1)}},
+			Options: options.Index().SetUnique(true).SetCollation(&options.Collation{Locale: "en", Strength: 2}),
+		},
+	)
+
+	return col, nil
+}
+
+// SeedDefaultCategories will create the default set of categories to an owner_id which has no categories yet
+func SeedDefaultCategories(parentCtx context.Context, ownerID primitive.ObjectID) (err error) {
+	spanTags := []attribute.KeyValue{
+		attribute.Key("category.owner.id").String(ownerID.String()),
+	}
+
+	ctx, span := observability.Span(parentCtx, "mongodb", "SeedDefaultCategories", spanTags)
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	col, err := categoriesCollection(ctx)
+	if err != nil {
+		return err
+	}
+
+	count, err := col.CountDocuments(ctx, bson.M{"owner_id": ownerID})
+	if err != nil {
+		return err
+	}
+
+	if count > 0 {
+		return nil
+	}
+
+	t := primitive.NewDateTimeFromTime(time.Now())
+	docs := []interface{}{}
+	for _, d := range defaultCategories {
+		parent := Category{
+			ID:        primitive.NewObjectID(),
+			OwnerID:   ownerID,
+			Name:      d.name,
+			Icon:      d.icon,
+			Color:     d.color,
+			CreatedAt: t,
+			UpdatedAt: t,
+		}
+		docs = append(docs, parent)
+
+		for _, child := range d.children {
+			docs = append(docs, Category{
+				OwnerID:   ownerID,
+				ParentID:  parent.ID,
+				Name:      child,
+				Icon:      d.icon,
+				Color:     d.color,
+				CreatedAt: t,
+				UpdatedAt: t,
+			})
+		}
+	}
+
+	_, err = col.InsertMany(ctx, docs)
+	if err != nil {
+		return err
+	}
+
+	log.Infoln("seeded", len(docs), "default categories to", ownerID.Hex())
+	return nil
+}
+
+// CreateCategory creates a category for a given owner_id
+func CreateCategory(parentCtx context.Context, c Category) (id string, err error) {
+	spanTags := []attribute.KeyValue{
+		attribute.Key("category.owner.id").String(c.OwnerID.String()),
+	}
+
+	ctx, span := observability.Span(parentCtx, "mongodb", "CreateCategory", spanTags)
+	defer span.End()
+
+	if c.Name == "" {
+		return "", errors.New("missing category name")
+	}
+
+	if !c.ParentID.IsZero() {
+		err = validateCategoryParent(ctx, c, c.ParentID)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	col, err := categoriesCollection(ctx)
+	if err != nil {
+		cancel()
+		return "", err
+	}
+
+	// adding timestamp to creationDate
+	t := time.Now()
+	c.CreatedAt = primitive.NewDateTimeFromTime(t)
+	c.UpdatedAt = primitive.NewDateTimeFromTime(t)
+
+	r, err := col.InsertOne(ctx, c)
+	if err != nil {
+		cancel()
+		return "", err
+	}
+
+	span.SetAttributes(attribute.Key("category.id").String(r.InsertedID.(primitive.ObjectID).Hex()))
+	defer cancel()
+
+	log.Infoln("created category", c.Name)
+	return r.InsertedID.(primitive.ObjectID).Hex(), nil
+}
+
+// GetCategory will return a single category based on its ID
+func GetCategory(parentCtx context.Context, id string) (category *Category, err error) {
+	spanTags := []attribute.KeyValue{
+		attribute.Key("category.id").String(id),
+	}
+
+	ctx, span := observability.Span(parentCtx, "mongodb", "GetCategory", spanTags)
+	defer span.End()
+
+	cid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return &Category{}, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	col, err := categoriesCollection(ctx)
+	if err != nil {
+		cancel()
+		return &Category{}, err
+	}
+
+	err = col.FindOne(ctx, bson.M{"_id": cid}).Decode(&category)
+	if err != nil {
+		cancel()
+		return &Category{}, err
+	}
+
+	defer cancel()
+	return category, nil
+}
+
+// GetCategories will return all categories from an owner_id
+func GetCategories(parentCtx context.Context, ownerID string) (categories []Category, err error) {
+	spanTags := []attribute.KeyValue{
+		attribute.Key("category.owner.id").String(ownerID),
+	}
+
+	ctx, span := observability.Span(parentCtx, "mongodb", "GetCategories", spanTags)
+	defer span.End()
+
+	oid, err := primitive.ObjectIDFromHex(ownerID)
+	if err != nil {
+		return []Category{}, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	col, err := categoriesCollection(ctx)
+	if err != nil {
+		cancel()
+		return []Category{}, err
+	}
+
+	cursor, err := col.Find(ctx, bson.M{"owner_id": oid}, options.Find().SetSort(bson.D{{Key: "name", Value: 1}}))
+	if err != nil {
+		cancel()
+		return []Category{}, err
+	}
+
+	defer cursor.Close(ctx)
+	defer cancel()
+
+	for cursor.Next(ctx) {
+		var category Category
+		cursor.Decode(&category)
+		categories = append(categories, category)
+	}
+
+	if err := cursor.Err(); err != nil {
+		cancel()
+		return []Category{}, err
+	}
+
+	return categories, nil
+}
+
+// CategoryTree will nest a flat list of categories under their parents, returning only top level ones
+func CategoryTree(categories []Category) (tree []Category) {
+	children := map[primitive.ObjectID][]Category{}
+	for _, c := range categories {
+		if !c.ParentID.IsZero() {
+			children[c.ParentID] = append(children[c.ParentID], c)
+		}
+	}
+
+	var nest func(c Category) Category
+	nest = func(c Category) Category {
+		for _, child := range children[c.ID] {
+			c.Children = append(c.Children, nest(child))
+		}
+		return c
+	}
+
+	for _, c := range categories {
+		if c.ParentID.IsZero() {
+			tree = append(tree, nest(c))
+		}
+	}
+
+	return tree
+}
+
+// validateCategoryParent will validate if a category can be nested under a given parent: both must be
+// owned by the same user and the parent can't be the category itself or one of its descendants
+func validateCategoryParent(ctx context.Context, c Category, parentID primitive.ObjectID) (err error) {
+	categories, err := GetCategories(ctx, c.OwnerID.Hex())
+	if err != nil {
+		return err
+	}
+
+	parents := map[primitive.ObjectID]primitive.ObjectID{}
+	for _, category := range categories {
+		parents[category.ID] = category.ParentID
+	}
+
+	if _, ok := parents[parentID]; !ok {
+		return errors.New("non existent parent category")
+	}
+
+	for id := parentID; !id.IsZero(); id = parents[id] {
+		if id == c.ID {
+			return errors.New("a category can't be nested under itself")
+		}
+	}
+
+	return nil
+}
+
+// UpdateCategory will update the given attributes of a category. Renaming a category will also rename
+// it on every spend and balance referencing it
+func UpdateCategory(parentCtx context.Context, id string, u CategoryUpdate) (err error) {
+	spanTags := []attribute.KeyValue{
+		attribute.Key("category.id").String(id),
+	}
+
+	ctx, span := observability.Span(parentCtx, "mongodb", "UpdateCategory", spanTags)
+	defer span.End()
+
+	category, err := GetCategory(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	fields := bson.M{"updated_at": primitive.NewDateTimeFromTime(time.Now())}
+	unset := bson.M{}
+
+	if u.Name != nil {
+		if *u.Name == "" {
+			return errors.New("missing category name")
+		}
+		fields["name"] = *u.Name
+	}
+
+	if u.Icon != nil {
+		fields["icon"] = *u.Icon
+	}
+
+	if u.Color != nil {
+		fields["color"] = *u.Color
+	}
+
+	if u.ParentID != nil {
+		if *u.ParentID == "" {
+			unset["parent_id"] = ""
+		} else {
+			pid, err := primitive.ObjectIDFromHex(*u.ParentID)
+			if err != nil {
+				return err
+			}
+
+			err = validateCategoryParent(ctx, *category, pid)
+			if err != nil {
+				return err
+			}
+			fields["parent_id"] = pid
+		}
+	}
+
+	update := bson.M{"$set": fields}
+	if len(unset) > 0 {
+		update["$unset"] = unset
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	col, err := categoriesCollection(ctx)
+	if err != nil {
+		return err
+	}
+
+	_, err = col.UpdateOne(ctx, bson.M{"_id": category.ID}, update)
+	if err != nil {
+		return err
+	}
+
+	if u.Name != nil && *u.Name != category.Name {
+		err = renameCategoryReferences(ctx, *category, *u.Name)
+		if err != nil {
+			return err
+		}
+	}
+
+	log.Infoln("updated category", id)
+	return nil
+}
+
+// renameCategoryReferences will rename a category on every spend, split spend line, balance historic,
+// budget and recurring rule template referencing it. Categorization rules reference categories by ID only
+func renameCategoryReferences(ctx context.Context, c Category, name string) (err error) {
+	dbClient, err := services.InitDatabase()
+	if err != nil {
+		return err
+	}
+
+	spends := dbClient.Database(mongodbDatabase).Collection(mongodbSpendsCollection)
+	result, err := spends.UpdateMany(ctx,
+		bson.M{"owner_id": c.OwnerID, "category_ids": c.ID, "category": c.Name},
+		bson.M{"$set": bson.M{"category.$[c]": name}},
+		options.Update().SetArrayFilters(options.ArrayFilters{Filters: []interface{}{bson.M{"c": c.Name}}}),
+	)
+	if err != nil {
+		return err
+	}
+
+	_, err = spends.UpdateMany(ctx,
+		bson.M{"owner_id": c.OwnerID, "lines.category_ids": c.ID},
+		bson.M{"$set": bson.M{"lines.$[l].category.$[c]": name}},
+		options.Update().SetArrayFilters(options.ArrayFilters{Filters: []interface{}{
+			bson.M{"l.category_ids": c.ID, "l.category": c.Name},
+			bson.M{"c": c.Name},
+		}}),
+	)
+	if err != nil {
+		return err
+	}
+
+	balances := dbClient.Database(mongodbDatabase).Collection(mongodbBalanceCollection)
+	_, err = balances.UpdateMany(ctx,
+		bson.M{"owner_id": c.OwnerID, "historic.category_ids": c.ID},
+		bson.M{"$set": bson.M{"historic.$[h].category.$[c]": name}},
+		options.Update().SetArrayFilters(options.ArrayFilters{Filters: []interface{}{
+			bson.M{"h.category_ids": c.ID, "h.category": c.Name},
+			bson.M{"c": c.Name},
+		}}),
+	)
+	if err != nil {
+		return err
+	}
+
+	_, err = balances.UpdateMany(ctx,
+		bson.M{"owner_id": c.OwnerID, "budgets.category_id": c.ID},
+		bson.M{"$set": bson.M{"budgets.$[b].category": name}},
+		options.Update().SetArrayFilters(options.ArrayFilters{Filters: []interface{}{bson.M{"b.category_id": c.ID}}}),
+	)
+	if err != nil {
+		return err
+	}
+
+	recurring := dbClient.Database(mongodbDatabase).Collection(mongodbRecurringCollection)
+	_, err = recurring.UpdateMany(ctx,
+		bson.M{"owner_id": c.OwnerID, "spend.category_ids": c.ID, "spend.category": c.Name},
+		bson.M{"$set": bson.M{"spend.category.$[c]": name}},
+		options.Update().SetArrayFilters(options.ArrayFilters{Filters: []interface{}{bson.M{"c": c.Name}}}),
+	)
+	if err != nil {
+		return err
+	}
+
+	log.Infoln("renamed category", c.ID.Hex(), "on", result.ModifiedCount, "spends")
+	return nil
+}
+
+// replaceCategoryExpr will return an aggregation expression replacing an element from an array field
+func replaceCategoryExpr(field string, old interface{}, new interface{}) bson.M {
+	return bson.M{
+		"$setUnion": bson.A{
+			bson.M{"$setDifference": bson.A{bson.M{"$ifNull": bson.A{field, bson.A{}}}, bson.A{old}}},
+			bson.A{new},
+		},
+	}
+}
+
+// replaceCategoryInArrayExpr will return an aggregation expression replacing a category on every element
+// of an array of spends (or spend lines) referencing it
+func replaceCategoryInArrayExpr(field string, source Category, target Category) bson.M {
+	return bson.M{"$map": bson.M{
+		"input": bson.M{"$ifNull": bson.A{field, bson.A{}}},
+		"as":    "e",
+		"in": bson.M{"$cond": bson.A{
+			bson.M{"$in": bson.A{source.ID, bson.M{"$ifNull": bson.A{"$$e.category_ids", bson.A{}}}}},
+			bson.M{"$mergeObjects": bson.A{"$$e", bson.M{
+				"category_ids": replaceCategoryExpr("$$e.category_ids", source.ID, target.ID),
+				"category":     replaceCategoryExpr("$$e.category", source.Name, target.Name),
+			}}},
+			"$$e",
+		}},
+	}}
+}
+
+// mergeBudgets will move the budget of a category to another one, adding it up to the target category
+// budget when both are budgeted
+func mergeBudgets(budgets []CategoryBudget, source Category, target Category) (merged []CategoryBudget) {
+	merged = []CategoryBudget{}
+
+	var moved CategoryBudget
+	found := false
+	for _, b := range budgets {
+		if b.CategoryID == source.ID {
+			moved, found = b, true
+			continue
+		}
+		merged = append(merged, b)
+	}
+
+	if !found {
+		return merged
+	}
+
+	for i := range merged {
+		if merged[i].CategoryID == target.ID {
+			merged[i].Amount = roundCents(merged[i].Amount + moved.Amount)
+			merged[i].CarriedOver = roundCents(merged[i].CarriedOver + moved.CarriedOver)
+			merged[i].CarryOver = merged[i].CarryOver || moved.CarryOver
+			return merged
+		}
+	}
+
+	moved.CategoryID, moved.Category = target.ID, target.Name
+	return append(merged, moved)
+}
+
+// mergeCategoryBudgets will move the budgets of a category to another one on every balance budgeting it
+func mergeCategoryBudgets(ctx context.Context, col *mongo.Collection, source Category, target Category) (err error) {
+	cursor, err := col.Find(ctx, bson.M{"owner_id": source.OwnerID, "budgets.category_id": source.ID})
+	if err != nil {
+		return err
+	}
+
+	balances := []Balance{}
+	err = cursor.All(ctx, &balances)
+	if err != nil {
+		return err
+	}
+
+	for _, b := range balances {
+		_, err = col.UpdateOne(ctx,
+			bson.M{"_id": b.ID},
+			bson.M{"$set": bson.M{"budgets": mergeBudgets(b.Budgets, source, target)}},
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// MergeCategory will merge a category into another one: every spend, split line, balance historic and
+// budget, categorization rule, recurring template and child category referencing it will reference the
+// target category instead, and the merged category will be deleted. Categories can't be merged into
+// their own descendants
+func MergeCategory(parentCtx context.Context, id string, into string) (merged int64, err error) {
+	spanTags := []attribute.KeyValue{
+		attribute.Key("category.id").String(id),
+		attribute.Key("category.into.id").String(into),
+	}
+
+	ctx, span := observability.Span(parentCtx, "mongodb", "MergeCategory", spanTags)
+	defer span.End()
+
+	source, err := GetCategory(ctx, id)
+	if err != nil {
+		return 0, err
+	}
+
+	target, err := GetCategory(ctx, into)
+	if err != nil {
+		return 0, err
+	}
+
+	if source.OwnerID != target.OwnerID {
+		return 0, errors.New("categories must be owned by the same user")
+	}
+
+	if source.ID == target.ID {
+		return 0, errors.New("a category can't be merged into itself")
+	}
+
+	categories, err := GetCategories(ctx, source.OwnerID.Hex())
+	if err != nil {
+		return 0, err
+	}
+
+	parents := map[primitive.ObjectID]primitive.ObjectID{}
+	for _, c := range categories {
+		parents[c.ID] = c.ParentID
+	}
+
+	for id := target.ParentID; !id.IsZero(); id = parents[id] {
+		if id == source.ID {
+			return 0, errors.New("a category can't be merged into one of its descendants")
+		}
+	}
+
+	dbClient, err := services.InitDatabase()
+	if err != nil {
+		return 0, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	spends := dbClient.Database(mongodbDatabase).Collection(mongodbSpendsCollection)
+	result, err := spends.UpdateMany(ctx,
+		bson.M{"owner_id": source.OwnerID, "category_ids": source.ID},
+		bson.A{bson.M{"$set": bson.M{
+			"category_ids": replaceCategoryExpr("$category_ids", source.ID, target.ID),
+			"category":     replaceCategoryExpr("$category", source.Name, target.Name),
+		}}},
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	_, err = spends.UpdateMany(ctx,
+		bson.M{"owner_id": source.OwnerID, "lines.category_ids": source.ID},
+		bson.A{bson.M{"$set": bson.M{"lines": replaceCategoryInArrayExpr("$lines", *source, *target)}}},
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	balances := dbClient.Database(mongodbDatabase).Collection(mongodbBalanceCollection)
+	_, err = balances.UpdateMany(ctx,
+		bson.M{"owner_id": source.OwnerID, "historic.category_ids": source.ID},
+		bson.A{bson.M{"$set": bson.M{"historic": replaceCategoryInArrayExpr("$historic", *source, *target)}}},
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	err = mergeCategoryBudgets(ctx, balances, *source, *target)
+	if err != nil {
+		return 0, err
+	}
+
+	rules := dbClient.Database(mongodbDatabase).Collection(mongodbCategorizationRulesCollection)
+	_, err = rules.UpdateMany(ctx,
+		bson.M{"owner_id": source.OwnerID, "category_ids": source.ID},
+		bson.A{bson.M{"$set": bson.M{"category_ids": replaceCategoryExpr("$category_ids", source.ID, target.ID)}}},
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	recurring := dbClient.Database(mongodbDatabase).Collection(mongodbRecurringCollection)
+	_, err = recurring.UpdateMany(ctx,
+		bson.M{"owner_id": source.OwnerID, "spend.category_ids": source.ID},
+		bson.A{bson.M{"$set": bson.M{
+			"spend.category_ids": replaceCategoryExpr("$spend.category_ids", source.ID, target.ID),
+			"spend.category":     replaceCategoryExpr("$spend.category", source.Name, target.Name),
+		}}},
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	col, err := categoriesCollection(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	_, err = col.UpdateMany(ctx, bson.M{"parent_id": source.ID}, bson.M{"$set": bson.M{"parent_id": target.ID}})
+	if err != nil {
+		return 0, err
+	}
+
+	_, err = col.DeleteOne(ctx, bson.M{"_id": source.ID})
+	if err != nil {
+		return 0, err
+	}
+
+	log.Infoln("merged category", source.Name, "into", target.Name, "on", result.ModifiedCount, "spends")
+	return result.ModifiedCount, nil
+}
+
+// ResolveSpendCategories will fill both category IDs and names of a spend. Categories referenced by ID
+// must be owned by the spend owner, while categories referenced only by name are created when missing
+func ResolveSpendCategories(parentCtx context.Context, s Spend) (resolved Spend, errs []FieldError, err error) {
+	ctx, span := observability.Span(parentCtx, "mongodb", "ResolveSpendCategories", []attribute.KeyValue{})
+	defer span.End()
+
+	errs = []FieldError{}
+	if len(s.CategoryIDs) == 0 && len(s.Categories) == 0 {
+		return s, errs, nil
+	}
+
+	categories, err := GetCategories(ctx, s.OwnerID.Hex())
+	if err != nil {
+		return s, errs, err
+	}
+
+	byID := map[primitive.ObjectID]Category{}
+	for _, c := range categories {
+		byID[c.ID] = c
+	}
+
+	ids := []primitive.ObjectID{}
+	names := []string{}
+
+	if len(s.CategoryIDs) > 0 {
+		for _, id := range s.CategoryIDs {
+			c, ok := byID[id]
+			if !ok {
+				errs = append(errs, FieldError{Field: "category_ids", Message: "non existent category '" + id.Hex() + "'"})
+				continue
+			}
+			ids = append(ids, c.ID)
+			names = append(names, c.Name)
+		}
+
+		s.CategoryIDs, s.Categories = ids, names
+		return s, errs, nil
+	}
+
+	for _, name := range s.Categories {
+		var found *Category
+		for i := range categories {
+			if strings.EqualFold(categories[i].Name, name) {
+				found = &categories[i]
+				break
+			}
+		}
+
+		if found == nil {
+			id, err := CreateCategory(ctx, Category{OwnerID: s.OwnerID, Name: name})
+			if err != nil {
+				return s, errs, err
+			}
+
+			cid, _ := primitive.ObjectIDFromHex(id)
+			categories = append(categories, Category{ID: cid, OwnerID: s.OwnerID, Name: name})
+			found = &categories[len(categories)-1]
+		}
+
+		ids = append(ids, found.ID)
+		names = append(names, found.Name)
+	}
+
+	s.CategoryIDs, s.Categories = ids, names
+	return s, errs, nil
+}
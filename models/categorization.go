@@ -0,0 +1,426 @@
+package models
+
+import (
+	"budget-tracker-api/observability"
+	"budget-tracker-api/services"
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/x/bsonx"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// PaymentMethodName will return the name of the payment method used by a spend
+func PaymentMethodName(p PaymentMethod) string {
+	switch {
+	case p.Credit:
+		return "credit"
+	case p.Debit:
+		return "debit"
+	case p.PaymentSlip:
+		return "payment_slip"
+	}
+	return ""
+}
+
+// ValidateCategorizationRule will validate if a categorization rule has at least one condition and
+// only references categories from its owner
+func ValidateCategorizationRule(ctx context.Context, r CategorizationRule) (errs []FieldError, err error) {
+	errs = []FieldError{}
+
+	if r.OwnerID.IsZero() {
+		errs = append(errs, FieldError{Field: "owner_id", Message: "missing owner ID"})
+	}
+
+	if r.DescriptionContains == "" && r.DescriptionRegex == "" && r.MinCost == nil && r.MaxCost == nil && r.PaymentMethod == "" {
+		errs = append(errs, FieldError{Field: "rule", Message: "rules must have at least one condition"})
+	}
+
+	if r.DescriptionRegex != "" {
+		_, reErr := regexp.Compile(r.DescriptionRegex)
+		if reErr != nil {
+			errs = append(errs, FieldError{Field: "description_regex", Message: reErr.Error()})
+		}
+	}
+
+	if r.MinCost != nil && r.MaxCost != nil && *r.MinCost > *r.MaxCost {
+		errs = append(errs, FieldError{Field: "max_cost", Message: "max cost must be greater than min cost"})
+	}
+
+	switch r.PaymentMethod {
+	case "", "credit", "debit", "payment_slip":
+	default:
+		errs = append(errs, FieldError{Field: "payment_method", Message: "payment method must be one of 'credit', 'debit' or 'payment_slip'"})
+	}
+
+	if len(r.CategoryIDs) == 0 {
+		errs = append(errs, FieldError{Field: "category_ids", Message: "rules must assign at least one category"})
+	}
+
+	if r.OwnerID.IsZero() || len(r.CategoryIDs) == 0 {
+		return errs, nil
+	}
+
+	_, categoriesErrs, err := ResolveSpendCategories(ctx, Spend{OwnerID: r.OwnerID, CategoryIDs: r.CategoryIDs})
+	if err != nil {
+		return errs, err
+	}
+
+	return append(errs, categoriesErrs...), nil
+}
+
+// compileDescriptionRegex will compile the description regex of a categorization rule, which matches
+// case insensitively
+func compileDescriptionRegex(pattern string) (*regexp.Regexp, error) {
+	return regexp.Compile("(?i)" + pattern)
+}
+
+// MatchCategorizationRule will check if a spend matches every condition of a categorization rule
+func MatchCategorizationRule(r CategorizationRule, s Spend) bool {
+	if r.DescriptionContains != "" && !strings.Contains(strings.ToLower(s.Description), strings.ToLower(r.DescriptionContains)) {
+		return false
+	}
+
+	if r.DescriptionRegex != "" {
+		re := r.descriptionRegexp
+		if re == nil {
+			var err error
+			re, err = compileDescriptionRegex(r.DescriptionRegex)
+			if err != nil {
+				return false
+			}
+		}
+
+		if !re.MatchString(s.Description) {
+			return false
+		}
+	}
+
+	if r.MinCost != nil && s.Cost < *r.MinCost {
+		return false
+	}
+
+	if r.MaxCost != nil && s.Cost > *r.MaxCost {
+		return false
+	}
+
+	if r.PaymentMethod != "" && r.PaymentMethod != PaymentMethodName(s.PaymentMethod) {
+		return false
+	}
+
+	return true
+}
+
+// ApplyCategorizationRules will assign to a spend the categories of every matching rule, following the
+// rules order. Both category IDs and names are filled based on the given owner categories
+func ApplyCategorizationRules(rules []CategorizationRule, categories []Category, s Spend) Spend {
+	names := map[primitive.ObjectID]string{}
+	for _, c := range categories {
+		names[c.ID] = c.Name
+	}
+
+	ids := []primitive.ObjectID{}
+	assigned := map[primitive.ObjectID]bool{}
+	for _, r := range rules {
+		if !MatchCategorizationRule(r, s) {
+			continue
+		}
+
+		for _, id := range r.CategoryIDs {
+			if _, ok := names[id]; !ok || assigned[id] {
+				continue
+			}
+			assigned[id] = true
+			ids = append(ids, id)
+		}
+	}
+
+	if len(ids) == 0 {
+		return s
+	}
+
+	s.CategoryIDs = ids
+	s.Categories = []string{}
+	for _, id := range ids {
+		s.Categories = append(s.Categories, names[id])
+	}
+
+	return s
+}
+
+// CategorizeSpend will apply the owner's categorization rules to a spend without categories
+func CategorizeSpend(parentCtx context.Context, s Spend) (categorized Spend, err error) {
+	if len(s.CategoryIDs) > 0 || len(s.Categories) > 0 {
+		return s, nil
+	}
+
+	ctx, span := observability.Span(parentCtx, "mongodb", "CategorizeSpend", []attribute.KeyValue{})
+	defer span.End()
+
+	rules, err := GetCategorizationRules(ctx, s.OwnerID.Hex())
+	if err != nil {
+		return s, err
+	}
+
+	if len(rules) == 0 {
+		return s, nil
+	}
+
+	categories, err := GetCategories(ctx, s.OwnerID.Hex())
+	if err != nil {
+		return s, err
+	}
+
+	return ApplyCategorizationRules(rules, categories, s), nil
+}
+
+// CreateCategorizationRule creates a categorization rule for a given owner_id
+func CreateCategorizationRule(parentCtx context.Context, r CategorizationRule) (id string, err error) {
+	spanTags := []attribute.KeyValue{
+		attribute.Key("categorization.owner.id").String(r.OwnerID.String()),
+	}
+
+	ctx, span := observability.Span(parentCtx, "mongodb", "CreateCategorizationRule", spanTags)
+	defer span.End()
+
+	dbClient, err := services.InitDatabase()
+	if err != nil {
+		return "", err
+	}
+
+	col := dbClient.Database(mongodbDatabase).Collection(mongodbCategorizationRulesCollection)
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+
+	_, err = col.Indexes().CreateOne(
+		ctx,
+		mongo.IndexModel{
+			Keys: bsonx.Doc{{Key: "owner_id", Value: bsonx.Int32(1)}, {Key: "priority", Value: bsonx.Int32(1)}},
+		},
+	)
+
+	// adding timestamp to creationDate
+	r.CreatedAt = primitive.NewDateTimeFromTime(time.Now())
+
+	result, err := col.InsertOne(ctx, r)
+	if err != nil {
+		cancel()
+		return "", err
+	}
+
+	span.SetAttributes(attribute.Key("categorization.id").String(result.InsertedID.(primitive.ObjectID).Hex()))
+	defer cancel()
+
+	log.Infoln("created categorization rule", result.InsertedID.(primitive.ObjectID).Hex())
+	return result.InsertedID.(primitive.ObjectID).Hex(), nil
+}
+
+// GetCategorizationRules will return all categorization rules from an owner_id sorted by priority
+func GetCategorizationRules(parentCtx context.Context, ownerID string) (rules []CategorizationRule, err error) {
+	spanTags := []attribute.KeyValue{
+		attribute.Key("categorization.owner.id").String(ownerID),
+	}
+
+	ctx, span := observability.Span(parentCtx, "mongodb", "GetCategorizationRules", spanTags)
+	defer span.End()
+
+	oid, err := primitive.ObjectIDFromHex(ownerID)
+	if err != nil {
+		return []CategorizationRule{}, err
+	}
+
+	dbClient, err := services.InitDatabase()
+	if err != nil {
+		return []CategorizationRule{}, err
+	}
+
+	col := dbClient.Database(mongodbDatabase).Collection(mongodbCategorizationRulesCollection)
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+
+	opts := options.Find().SetSort(bson.D{{Key: "priority", Value: 1}, {Key: "_id", Value: 1}})
+	cursor, err := col.Find(ctx, bson.M{"owner_id": oid}, opts)
+	if err != nil {
+		cancel()
+		return []CategorizationRule{}, err
+	}
+
+	defer cursor.Close(ctx)
+	defer cancel()
+
+	for cursor.Next(ctx) {
+		var rule CategorizationRule
+		cursor.Decode(&rule)
+		if rule.DescriptionRegex != "" {
+			rule.descriptionRegexp, _ = compileDescriptionRegex(rule.DescriptionRegex)
+		}
+		rules = append(rules, rule)
+	}
+
+	if err := cursor.Err(); err != nil {
+		cancel()
+		return []CategorizationRule{}, err
+	}
+
+	return rules, nil
+}
+
+// DeleteCategorizationRule deletes a categorization rule given an ID
+func DeleteCategorizationRule(parentCtx context.Context, id string) (err error) {
+	spanTags := []attribute.KeyValue{
+		attribute.Key("categorization.id").String(id),
+	}
+
+	ctx, span := observability.Span(parentCtx, "mongodb", "DeleteCategorizationRule", spanTags)
+	defer span.End()
+
+	rid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+
+	dbClient, err := services.InitDatabase()
+	if err != nil {
+		return err
+	}
+
+	col := dbClient.Database(mongodbDatabase).Collection(mongodbCategorizationRulesCollection)
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+
+	result, err := col.DeleteOne(ctx, bson.M{"_id": rid})
+	if err != nil {
+		cancel()
+		return err
+	}
+
+	if result.DeletedCount == 0 {
+		cancel()
+		return errors.New("non existent categorization rule")
+	}
+
+	defer cancel()
+
+	log.Infoln("deleted categorization rule", id)
+	return nil
+}
+
+// ReapplyCategorizationRules will recategorize past spends from an owner_id, along with their copies at
+// balance historics, based on its current rules. Spends which already have categories (either by ID or
+// just by name) are only recategorized when `overwrite` is set, and spends not matching any rule are
+// left untouched. Split spends are categorized per line, so they and their parts are left untouched too
+func ReapplyCategorizationRules(parentCtx context.Context, ownerID string, overwrite bool) (updated int64, err error) {
+	spanTags := []attribute.KeyValue{
+		attribute.Key("categorization.owner.id").String(ownerID),
+		attribute.Key("categorization.overwrite").Bool(overwrite),
+	}
+
+	ctx, span := observability.Span(parentCtx, "mongodb", "ReapplyCategorizationRules", spanTags)
+	defer span.End()
+
+	oid, err := primitive.ObjectIDFromHex(ownerID)
+	if err != nil {
+		return 0, err
+	}
+
+	rules, err := GetCategorizationRules(ctx, ownerID)
+	if err != nil {
+		return 0, err
+	}
+
+	if len(rules) == 0 {
+		return 0, nil
+	}
+
+	categories, err := GetCategories(ctx, ownerID)
+	if err != nil {
+		return 0, err
+	}
+
+	// split spends and their parts keep the categories of their lines
+	filter := bson.M{
+		"owner_id": oid,
+		"lines":    bson.M{"$exists": false},
+		"shares":   bson.M{"$exists": false},
+		"split_id": bson.M{"$exists": false},
+	}
+	if !overwrite {
+		filter["category_ids"] = bson.M{"$in": bson.A{nil, bson.A{}}}
+		filter["category"] = bson.M{"$in": bson.A{nil, bson.A{}}}
+	}
+
+	dbClient, err := services.InitDatabase()
+	if err != nil {
+		return 0, err
+	}
+
+	col := dbClient.Database(mongodbDatabase).Collection(mongodbSpendsCollection)
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	cursor, err := col.Find(ctx, filter)
+	if err != nil {
+		return 0, err
+	}
+
+	defer cursor.Close(ctx)
+
+	writes := []mongo.WriteModel{}
+	historicWrites := []mongo.WriteModel{}
+	for cursor.Next(ctx) {
+		var spend Spend
+		cursor.Decode(&spend)
+
+		categorized := ApplyCategorizationRules(rules, categories, Spend{
+			Description:   spend.Description,
+			Cost:          spend.Cost,
+			PaymentMethod: spend.PaymentMethod,
+		})
+
+		if len(categorized.CategoryIDs) == 0 {
+			continue
+		}
+
+		writes = append(writes, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"_id": spend.ID}).
+			SetUpdate(bson.M{"$set": bson.M{
+				"category_ids": categorized.CategoryIDs,
+				"category":     categorized.Categories,
+			}}))
+
+		historicWrites = append(historicWrites, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"owner_id": spend.OwnerID, "month": spend.Month, "year": spend.Year, "historic._id": spend.ID}).
+			SetUpdate(bson.M{"$set": bson.M{
+				"historic.$.category_ids": categorized.CategoryIDs,
+				"historic.$.category":     categorized.Categories,
+			}}))
+	}
+
+	if err := cursor.Err(); err != nil {
+		return 0, err
+	}
+
+	if len(writes) == 0 {
+		return 0, nil
+	}
+
+	result, err := col.BulkWrite(ctx, writes)
+	if err != nil {
+		return 0, err
+	}
+
+	balances := dbClient.Database(mongodbDatabase).Collection(mongodbBalanceCollection)
+	_, err = balances.BulkWrite(ctx, historicWrites)
+	if err != nil {
+		return result.ModifiedCount, err
+	}
+
+	span.SetAttributes(attribute.Key("categorization.updated").Int64(result.ModifiedCount))
+	log.Infoln("recategorized", result.ModifiedCount, "spends from", ownerID)
+	return result.ModifiedCount, nil
+}
@@ -0,0 +1,179 @@
+package models
+
+import (
+	"budget-tracker-api/observability"
+	"budget-tracker-api/services"
+	"context"
+	"errors"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// ErrBalanceClosed is returned when changing a balance from a closed month
+var ErrBalanceClosed = errors.New("balance is closed")
+
+// nextPeriod will return the month and year right after a given one
+func nextPeriod(month int64, year int64) (int64, int64) {
+	if month == 12 {
+		return 1, year + 1
+	}
+	return month + 1, year
+}
+
+// IsBalanceClosed will check if the balance from an owner_id month was closed. Non existent balances
+// are considered open
+func IsBalanceClosed(parentCtx context.Context, ownerID primitive.ObjectID, month int64, year int64) (closed bool, err error) {
+	spanTags := []attribute.KeyValue{
+		attribute.Key("balance.owner.id").String(ownerID.String()),
+	}
+
+	ctx, span := observability.Span(parentCtx, "mongodb", "IsBalanceClosed", spanTags)
+	defer span.End()
+
+	dbClient, err := services.InitDatabase()
+	if err != nil {
+		return false, err
+	}
+
+	col := dbClient.Database(mongodbDatabase).Collection(mongodbBalanceCollection)
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	count, err := col.CountDocuments(ctx, bson.M{
+		"owner_id": ownerID,
+		"month":    month,
+		"year":     year,
+		"closed":   true,
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+// CloseBalance will freeze the balance from an owner_id month, keeping its spendable amount as the final
+// leftover. When `carryOver` is set the leftover is added to the next month income as a surplus (or deficit)
+func CloseBalance(parentCtx context.Context, ownerID string, month int64, year int64, carryOver bool) (balance *Balance, err error) {
+	spanTags := []attribute.KeyValue{
+		attribute.Key("balance.owner.id").String(ownerID),
+		attribute.Key("balance.carry_over").Bool(carryOver),
+	}
+
+	ctx, span := observability.Span(parentCtx, "mongodb", "CloseBalance", spanTags)
+	defer span.End()
+
+	oid, err := primitive.ObjectIDFromHex(ownerID)
+	if err != nil {
+		return &Balance{}, err
+	}
+
+	dbClient, err := services.InitDatabase()
+	if err != nil {
+		return &Balance{}, err
+	}
+
+	col := dbClient.Database(mongodbDatabase).Collection(mongodbBalanceCollection)
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+
+	t := primitive.NewDateTimeFromTime(time.Now())
+	err = col.FindOneAndUpdate(ctx,
+		bson.M{"owner_id": oid, "month": month, "year": year, "closed": bson.M{"$ne": true}},
+		bson.A{bson.M{"$set": bson.M{
+			"closed":     true,
+			"closed_at":  t,
+			"updated_at": t,
+			"leftover":   "$spendable_amount",
+		}}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&balance)
+	if err == mongo.ErrNoDocuments {
+		cancel()
+
+		closed, closedErr := IsBalanceClosed(parentCtx, oid, month, year)
+		if closedErr != nil {
+			return &Balance{}, closedErr
+		}
+
+		if closed {
+			return &Balance{}, ErrBalanceClosed
+		}
+		return &Balance{}, err
+	}
+
+	if err != nil {
+		cancel()
+		return &Balance{}, err
+	}
+
+	defer cancel()
+
+	log.Infoln("closed balance", month, "/", year, "from", ownerID, "with leftover", balance.Leftover)
+
+	if carryOver {
+		err = carryLeftover(ctx, *balance)
+		if err != nil {
+			return balance, err
+		}
+	}
+
+	return balance, nil
+}
+
+// carryLeftover will set the leftover of a closed balance as the previous month line of the next month
+// income, replacing any amount carried before and creating the next balance when it doesn't exist yet
+func carryLeftover(ctx context.Context, closed Balance) (err error) {
+	month, year := nextPeriod(closed.Month, closed.Year)
+
+	next, err := GetBalance(ctx, closed.OwnerID.Hex(), month, year)
+	if err == mongo.ErrNoDocuments {
+		_, err = CreateBalance(ctx, Balance{
+			OwnerID:  closed.OwnerID,
+			Income:   Income{PreviousMonth: closed.Leftover},
+			Currency: closed.Currency,
+			Month:    month,
+			Year:     year,
+		})
+		return err
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if next.Closed {
+		return ErrBalanceClosed
+	}
+
+	dbClient, err := services.InitDatabase()
+	if err != nil {
+		return err
+	}
+
+	col := dbClient.Database(mongodbDatabase).Collection(mongodbBalanceCollection)
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	_, err = col.UpdateOne(ctx,
+		bson.M{"_id": next.ID},
+		bson.M{
+			"$inc": bson.M{"spendable_amount": closed.Leftover - next.Income.PreviousMonth},
+			"$set": bson.M{
+				"income.previous_month": closed.Leftover,
+				"updated_at":            primitive.NewDateTimeFromTime(time.Now()),
+			},
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	log.Infoln("carried", closed.Leftover, "over to balance", month, "/", year, "from", closed.OwnerID.Hex())
+	return nil
+}
@@ -0,0 +1,138 @@
+package models
+
+import (
+	"budget-tracker-api/observability"
+	"budget-tracker-api/services"
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// exportTimeout defines how long an export may take, as whole histories are read at once
+const exportTimeout = 5 * time.Minute
+
+// StreamSpends will call fn for every spend from a specific owner_id matching the given query, sorted
+// by date. Spends are read from the database cursor one by one, so large histories aren't loaded into
+// memory. Pagination options from the query are ignored
+func StreamSpends(parentCtx context.Context, q SpendsQuery, fn func(Spend) error) (err error) {
+	spanTags := []attribute.KeyValue{
+		attribute.Key("spend.owner.id").String(q.OwnerID),
+	}
+
+	ctx, span := observability.Span(parentCtx, "mongodb", "StreamSpends", spanTags)
+	defer span.End()
+
+	filter, err := spendsFilter(q)
+	if err != nil {
+		return err
+	}
+
+	sortField := "date"
+	if q.SortBy != "" {
+		field, ok := spendsSortFields[q.SortBy]
+		if !ok {
+			return errors.New("invalid sort field '" + q.SortBy + "'")
+		}
+		sortField = field
+	}
+
+	direction := 1
+	if q.SortDesc {
+		direction = -1
+	}
+
+	dbClient, err := services.InitDatabase()
+	if err != nil {
+		return err
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: sortField, Value: direction}, {Key: "_id", Value: direction}})
+
+	col := dbClient.Database(mongodbDatabase).Collection(mongodbSpendsCollection)
+	ctx, cancel := context.WithTimeout(ctx, exportTimeout)
+	cursor, err := col.Find(ctx, filter, opts)
+	if err != nil {
+		cancel()
+		return err
+	}
+
+	defer cursor.Close(ctx)
+	defer cancel()
+
+	for cursor.Next(ctx) {
+		var spend Spend
+		err = cursor.Decode(&spend)
+		if err != nil {
+			return err
+		}
+
+		err = fn(spend)
+		if err != nil {
+			return err
+		}
+	}
+
+	return cursor.Err()
+}
+
+// StreamBalances will call fn for every balance from an owner_id, sorted by period. Balances from
+// a single year are streamed when year is given
+func StreamBalances(parentCtx context.Context, ownerID string, year int64, fn func(Balance) error) (err error) {
+	spanTags := []attribute.KeyValue{
+		attribute.Key("balance.owner.id").String(ownerID),
+	}
+
+	ctx, span := observability.Span(parentCtx, "mongodb", "StreamBalances", spanTags)
+	defer span.End()
+
+	oid, err := primitive.ObjectIDFromHex(ownerID)
+	if err != nil {
+		return err
+	}
+
+	filter := bson.M{"owner_id": oid}
+	if year > 0 {
+		filter["year"] = year
+	}
+
+	dbClient, err := services.InitDatabase()
+	if err != nil {
+		return err
+	}
+
+	// leaving out the spends historic, which is exported on its own
+	opts := options.Find().
+		SetSort(bson.D{{Key: "year", Value: 1}, {Key: "month", Value: 1}}).
+		SetProjection(bson.M{"historic": 0})
+
+	col := dbClient.Database(mongodbDatabase).Collection(mongodbBalanceCollection)
+	ctx, cancel := context.WithTimeout(ctx, exportTimeout)
+	cursor, err := col.Find(ctx, filter, opts)
+	if err != nil {
+		cancel()
+		return err
+	}
+
+	defer cursor.Close(ctx)
+	defer cancel()
+
+	for cursor.Next(ctx) {
+		var balance Balance
+		err = cursor.Decode(&balance)
+		if err != nil {
+			return err
+		}
+
+		err = fn(balance)
+		if err != nil {
+			return err
+		}
+	}
+
+	return cursor.Err()
+}
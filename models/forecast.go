@@ -0,0 +1,277 @@
+package models
+
+import (
+	"budget-tracker-api/observability"
+	"context"
+	"sort"
+	"strconv"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// forecastAverageMonths defines how many past balances are used to average dynamic spends
+const forecastAverageMonths = 3
+
+// AverageDynamicSpends will return the average dynamic outcome per category from the most recent balances
+// before a given month. Recurring spends and installments are left out since they're forecasted by themselves
+func AverageDynamicSpends(balances []Balance, month int64, year int64, limit int) (lines []ForecastLine) {
+	past := []Balance{}
+	for _, b := range balances {
+		if b.Year < year || (b.Year == year && b.Month < month) {
+			past = append(past, b)
+		}
+	}
+
+	sort.Slice(past, func(i, j int) bool {
+		if past[i].Year != past[j].Year {
+			return past[i].Year > past[j].Year
+		}
+		return past[i].Month > past[j].Month
+	})
+
+	if len(past) > limit {
+		past = past[:limit]
+	}
+
+	if len(past) == 0 {
+		return []ForecastLine{}
+	}
+
+	totals := map[string]float64{}
+	categories := []string{}
+	for _, b := range past {
+		for _, s := range b.Historic {
+			if !isAveragedSpend(s) {
+				continue
+			}
+
+			category := forecastCategory(s.Categories)
+
+			if _, ok := totals[category]; !ok {
+				categories = append(categories, category)
+			}
+			totals[category] += s.Cost
+		}
+	}
+
+	sort.Strings(categories)
+
+	assumption := "average dynamic spend of the last " + strconv.Itoa(len(past)) + " months"
+	for _, category := range categories {
+		description := "dynamic spends"
+		if category != "" {
+			description = "dynamic spends on " + category
+		}
+
+		lines = append(lines, ForecastLine{
+			Kind:        "outcome",
+			Source:      "average",
+			Description: description,
+			Category:    category,
+			Amount:      roundCents(totals[category] / float64(len(past))),
+			Assumption:  assumption,
+		})
+	}
+
+	return lines
+}
+
+// isAveragedSpend will tell whether a spend is accounted by the average dynamic spends, as opposed to the
+// fixed, recurring and installment spends forecasted by themselves
+func isAveragedSpend(s Spend) bool {
+	return s.Type != "fixed" && s.RecurringRuleID.IsZero() && s.ParentID.IsZero()
+}
+
+// forecastCategory will return the category a spend is forecasted under
+func forecastCategory(categories []string) string {
+	if len(categories) > 0 {
+		return categories[0]
+	}
+	return ""
+}
+
+// remainingAverages will deduct the dynamic spends already registered for a month from the average
+// dynamic spends of their categories, since those spends are forecasted by themselves. Categories
+// already spent beyond their average are left out
+func remainingAverages(averages []ForecastLine, spends []Spend) (lines []ForecastLine) {
+	registered := map[string]float64{}
+	for _, s := range spends {
+		if isAveragedSpend(s) {
+			registered[forecastCategory(s.Categories)] += s.Cost
+		}
+	}
+
+	lines = []ForecastLine{}
+	for _, line := range averages {
+		spent, ok := registered[line.Category]
+		if !ok {
+			lines = append(lines, line)
+			continue
+		}
+
+		line.Amount = roundCents(line.Amount - spent)
+		if line.Amount <= 0 {
+			continue
+		}
+
+		line.Assumption += " minus the spends already registered for the month"
+		lines = append(lines, line)
+	}
+
+	return lines
+}
+
+// referenceID will return a reference to a copy of an ID, so forecast lines don't share loop variables
+func referenceID(id primitive.ObjectID) *primitive.ObjectID {
+	return &id
+}
+
+// spendForecastLine will return the forecast line of a spend already booked to a future month
+func spendForecastLine(s Spend) ForecastLine {
+	line := ForecastLine{
+		Kind:        "outcome",
+		Source:      "scheduled",
+		Description: s.Description,
+		Amount:      s.Cost,
+		Category:    forecastCategory(s.Categories),
+		Assumption:  "spend already registered for the month",
+		ReferenceID: referenceID(s.ID),
+	}
+
+	if !s.ParentID.IsZero() {
+		line.Source = "installment"
+		line.Assumption = "installment " + strconv.Itoa(s.InstallmentNumber) + " of " + strconv.Itoa(s.Installments)
+	}
+
+	if !s.RecurringRuleID.IsZero() {
+		line.Source = "recurring"
+		line.ReferenceID = referenceID(s.RecurringRuleID)
+		line.Assumption = "recurring spend already registered for the month"
+	}
+
+	return line
+}
+
+// Forecast will project the income, outcome and spendable amount from an owner_id for the months after
+// `now`, based on its recurring rules, spends and incomes already registered for those months and the
+// average dynamic spend per category from past balances. Months are bounded at the location of `now`,
+// which should be UTC unless the owner's timezone is known, the same as SpendPeriod
+func Forecast(parentCtx context.Context, ownerID string, months int, now time.Time) (forecast []ForecastMonth, err error) {
+	spanTags := []attribute.KeyValue{
+		attribute.Key("forecast.owner.id").String(ownerID),
+		attribute.Key("forecast.months").Int(months),
+	}
+
+	ctx, span := observability.Span(parentCtx, "mongodb", "Forecast", spanTags)
+	defer span.End()
+
+	oid, err := primitive.ObjectIDFromHex(ownerID)
+	if err != nil {
+		return []ForecastMonth{}, err
+	}
+
+	rules, err := findRecurringRules(ctx, bson.M{"owner_id": oid, "paused": false})
+	if err != nil {
+		return []ForecastMonth{}, err
+	}
+
+	balances, err := GetAllBalances(ctx, ownerID)
+	if err != nil {
+		return []ForecastMonth{}, err
+	}
+
+	registered := map[[2]int64]Balance{}
+	for _, b := range balances {
+		registered[[2]int64{b.Month, b.Year}] = b
+	}
+
+	averages := AverageDynamicSpends(balances, int64(now.Month()), int64(now.Year()), forecastAverageMonths)
+
+	first := time.Date(now.Year(), now.Month()+1, 1, 0, 0, 0, 0, now.Location())
+	for i := 0; i < months; i++ {
+		start := first.AddDate(0, i, 0)
+		end := start.AddDate(0, 1, 0).Add(-time.Nanosecond)
+
+		f := ForecastMonth{Month: int64(start.Month()), Year: int64(start.Year()), Lines: []ForecastLine{}}
+
+		for _, r := range rules {
+			occurrences, err := RuleOccurrences(r, start.Add(-time.Nanosecond), end)
+			if err != nil {
+				continue
+			}
+
+			for range occurrences {
+				line := ForecastLine{
+					Kind:        "outcome",
+					Source:      "recurring",
+					Description: r.Spend.Description,
+					Amount:      r.Spend.Cost,
+					Assumption:  r.Frequency + " recurring spend",
+					ReferenceID: referenceID(r.ID),
+				}
+
+				if r.Kind == "income" {
+					line.Kind = "income"
+					line.Description = r.Income.Description
+					if line.Description == "" {
+						line.Description = r.Income.Source
+					}
+					line.Amount = r.Income.NetIncome
+					line.Assumption = r.Frequency + " recurring income"
+				} else if len(r.Spend.Categories) > 0 {
+					line.Category = r.Spend.Categories[0]
+				}
+
+				f.Lines = append(f.Lines, line)
+			}
+		}
+
+		if b, ok := registered[[2]int64{f.Month, f.Year}]; ok {
+			for _, income := range b.Incomes {
+				description := income.Description
+				if description == "" {
+					description = income.Source
+				}
+
+				f.Lines = append(f.Lines, ForecastLine{
+					Kind:        "income",
+					Source:      "registered_income",
+					Description: description,
+					Amount:      income.NetIncome,
+					Assumption:  "income already registered for the month",
+					ReferenceID: referenceID(income.ID),
+				})
+			}
+		}
+
+		spends, err := GetMonthSpends(ctx, oid, f.Month, f.Year)
+		if err != nil {
+			return []ForecastMonth{}, err
+		}
+
+		for _, s := range spends {
+			f.Lines = append(f.Lines, spendForecastLine(s))
+		}
+
+		f.Lines = append(f.Lines, remainingAverages(averages, spends)...)
+
+		for _, line := range f.Lines {
+			if line.Kind == "income" {
+				f.Income += line.Amount
+			} else {
+				f.Outcome += line.Amount
+			}
+		}
+
+		f.Income = roundCents(f.Income)
+		f.Outcome = roundCents(f.Outcome)
+		f.SpendableAmount = roundCents(f.Income - f.Outcome)
+
+		forecast = append(forecast, f)
+	}
+
+	return forecast, nil
+}
@@ -0,0 +1,468 @@
+package models
+
+import (
+	"budget-tracker-api/observability"
+	"budget-tracker-api/services"
+	"context"
+	"errors"
+	"math"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/x/bsonx"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// ValidateGoal will validate every attribute of a goal, returning all invalid ones
+func ValidateGoal(g Goal) (errs []FieldError) {
+	errs = []FieldError{}
+
+	if g.OwnerID.IsZero() {
+		errs = append(errs, FieldError{Field: "owner_id", Message: "missing owner ID"})
+	}
+
+	if g.Name == "" {
+		errs = append(errs, FieldError{Field: "name", Message: "missing name"})
+	}
+
+	if g.TargetAmount <= 0 {
+		errs = append(errs, FieldError{Field: "target_amount", Message: "target amount must be greater than zero"})
+	}
+
+	return errs
+}
+
+// monthsBetween will return how many calendar months there are from one date to another, both included
+func monthsBetween(from time.Time, to time.Time) int {
+	months := (to.Year()-from.Year())*12 + int(to.Month()) - int(from.Month()) + 1
+	if months < 1 {
+		return 1
+	}
+	return months
+}
+
+// ProjectGoal will compute the progress of a goal given its contributions. The projected completion
+// assumes the goal will keep receiving its average monthly contribution since the first one
+func ProjectGoal(g Goal, contributions []GoalContribution, now time.Time) (p GoalProgress) {
+	p = GoalProgress{Goal: g, Contributions: contributions}
+	if p.Contributions == nil {
+		p.Contributions = []GoalContribution{}
+	}
+
+	p.Remaining = roundCents(math.Max(g.TargetAmount-g.Saved, 0))
+	p.Percentage = math.Round(g.Saved/g.TargetAmount*10000) / 100
+
+	if p.Remaining == 0 {
+		p.OnTrack = true
+		return p
+	}
+
+	if len(contributions) == 0 {
+		return p
+	}
+
+	first := contributions[0].Date
+	for _, c := range contributions {
+		if c.Date.Before(first) {
+			first = c.Date
+		}
+	}
+
+	p.MonthlyRate = roundCents(g.Saved / float64(monthsBetween(first, now)))
+	if p.MonthlyRate <= 0 {
+		return p
+	}
+
+	months := int(math.Ceil(p.Remaining / p.MonthlyRate))
+	projected := now.AddDate(0, months, 0)
+	p.ProjectedCompletion = &projected
+	p.OnTrack = g.Deadline.IsZero() || !projected.After(g.Deadline)
+
+	return p
+}
+
+// CreateGoal creates a savings goal for a given owner_id
+func CreateGoal(parentCtx context.Context, g Goal) (id string, err error) {
+	spanTags := []attribute.KeyValue{
+		attribute.Key("goal.owner.id").String(g.OwnerID.String()),
+	}
+
+	ctx, span := observability.Span(parentCtx, "mongodb", "CreateGoal", spanTags)
+	defer span.End()
+
+	dbClient, err := services.InitDatabase()
+	if err != nil {
+		return "", err
+	}
+
+	col := dbClient.Database(mongodbDatabase).Collection(mongodbGoalsCollection)
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+
+	_, err = col.Indexes().CreateOne(
+		ctx,
+		mongo.IndexModel{
+			Keys: bsonx.Doc{{Key: "owner_id", Value: bsonx.Int32(1)}},
+		},
+	)
+
+	// adding timestamp to creationDate
+	t := time.Now()
+	g.CreatedAt = primitive.NewDateTimeFromTime(t)
+	g.UpdatedAt = primitive.NewDateTimeFromTime(t)
+	g.Saved = 0
+
+	r, err := col.InsertOne(ctx, g)
+	if err != nil {
+		cancel()
+		return "", err
+	}
+
+	span.SetAttributes(attribute.Key("goal.id").String(r.InsertedID.(primitive.ObjectID).Hex()))
+	defer cancel()
+
+	log.Infoln("created goal", r.InsertedID.(primitive.ObjectID).Hex())
+	return r.InsertedID.(primitive.ObjectID).Hex(), nil
+}
+
+// GetGoal will return a single goal based on its ID
+func GetGoal(parentCtx context.Context, id string) (goal *Goal, err error) {
+	spanTags := []attribute.KeyValue{
+		attribute.Key("goal.id").String(id),
+	}
+
+	ctx, span := observability.Span(parentCtx, "mongodb", "GetGoal", spanTags)
+	defer span.End()
+
+	gid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return &Goal{}, err
+	}
+
+	dbClient, err := services.InitDatabase()
+	if err != nil {
+		return &Goal{}, err
+	}
+
+	col := dbClient.Database(mongodbDatabase).Collection(mongodbGoalsCollection)
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+
+	err = col.FindOne(ctx, bson.M{"_id": gid}).Decode(&goal)
+	if err != nil {
+		cancel()
+		return &Goal{}, err
+	}
+
+	defer cancel()
+	return goal, nil
+}
+
+// GetGoalsProgress will return the progress of every goal from an owner_id
+func GetGoalsProgress(parentCtx context.Context, ownerID string) (progress []GoalProgress, err error) {
+	spanTags := []attribute.KeyValue{
+		attribute.Key("goal.owner.id").String(ownerID),
+	}
+
+	ctx, span := observability.Span(parentCtx, "mongodb", "GetGoalsProgress", spanTags)
+	defer span.End()
+
+	oid, err := primitive.ObjectIDFromHex(ownerID)
+	if err != nil {
+		return []GoalProgress{}, err
+	}
+
+	dbClient, err := services.InitDatabase()
+	if err != nil {
+		return []GoalProgress{}, err
+	}
+
+	col := dbClient.Database(mongodbDatabase).Collection(mongodbGoalsCollection)
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	cursor, err := col.Find(ctx, bson.M{"owner_id": oid}, options.Find().SetSort(bson.D{{Key: "deadline", Value: 1}}))
+	if err != nil {
+		cancel()
+		return []GoalProgress{}, err
+	}
+
+	defer cursor.Close(ctx)
+	defer cancel()
+
+	goals := []Goal{}
+	for cursor.Next(ctx) {
+		var goal Goal
+		cursor.Decode(&goal)
+		goals = append(goals, goal)
+	}
+
+	if err := cursor.Err(); err != nil {
+		cancel()
+		return []GoalProgress{}, err
+	}
+
+	now := time.Now()
+	for _, goal := range goals {
+		contributions, err := findGoalContributions(ctx, bson.M{"goal_id": goal.ID})
+		if err != nil {
+			return []GoalProgress{}, err
+		}
+		progress = append(progress, ProjectGoal(goal, contributions, now))
+	}
+
+	return progress, nil
+}
+
+// UpdateGoal will update the editable attributes of a goal given its ID
+func UpdateGoal(parentCtx context.Context, id string, u GoalUpdate) (err error) {
+	spanTags := []attribute.KeyValue{
+		attribute.Key("goal.id").String(id),
+	}
+
+	ctx, span := observability.Span(parentCtx, "mongodb", "UpdateGoal", spanTags)
+	defer span.End()
+
+	gid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+
+	fields := bson.M{"updated_at": primitive.NewDateTimeFromTime(time.Now())}
+
+	if u.Name != nil {
+		if *u.Name == "" {
+			return errors.New("missing goal name")
+		}
+		fields["name"] = *u.Name
+	}
+
+	if u.TargetAmount != nil {
+		if *u.TargetAmount <= 0 {
+			return errors.New("target amount must be greater than zero")
+		}
+		fields["target_amount"] = *u.TargetAmount
+	}
+
+	if u.Deadline != nil {
+		fields["deadline"] = *u.Deadline
+	}
+
+	dbClient, err := services.InitDatabase()
+	if err != nil {
+		return err
+	}
+
+	col := dbClient.Database(mongodbDatabase).Collection(mongodbGoalsCollection)
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+
+	result, err := col.UpdateOne(ctx, bson.M{"_id": gid}, bson.M{"$set": fields})
+	if err != nil {
+		cancel()
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		cancel()
+		return mongo.ErrNoDocuments
+	}
+
+	defer cancel()
+
+	log.Infoln("updated goal", id)
+	return nil
+}
+
+// DeleteGoal deletes a goal given an ID. Its contributions are kept, since the money they moved out of
+// the balances was still saved
+func DeleteGoal(parentCtx context.Context, id string) (err error) {
+	spanTags := []attribute.KeyValue{
+		attribute.Key("goal.id").String(id),
+	}
+
+	ctx, span := observability.Span(parentCtx, "mongodb", "DeleteGoal", spanTags)
+	defer span.End()
+
+	gid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+
+	dbClient, err := services.InitDatabase()
+	if err != nil {
+		return err
+	}
+
+	col := dbClient.Database(mongodbDatabase).Collection(mongodbGoalsCollection)
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+
+	result, err := col.DeleteOne(ctx, bson.M{"_id": gid})
+	if err != nil {
+		cancel()
+		return err
+	}
+
+	if result.DeletedCount == 0 {
+		cancel()
+		return mongo.ErrNoDocuments
+	}
+
+	defer cancel()
+
+	log.Infoln("deleted goal", id)
+	return nil
+}
+
+// AddGoalContribution will save an amount to a goal, accounting it as savings outcome of the balance
+// from the contribution month (as seen from its timezone), creating the balance when it doesn't exist
+// yet. Contributions without a date are made now. The contribution is recorded first and undone when the balance or the goal can't be updated
+func AddGoalContribution(parentCtx context.Context, goalID string, c GoalContribution) (id string, err error) {
+	spanTags := []attribute.KeyValue{
+		attribute.Key("goal.id").String(goalID),
+	}
+
+	ctx, span := observability.Span(parentCtx, "mongodb", "AddGoalContribution", spanTags)
+	defer span.End()
+
+	if c.Amount <= 0 {
+		return "", errors.New("contribution amount must be greater than zero")
+	}
+
+	goal, err := GetGoal(ctx, goalID)
+	if err != nil {
+		return "", err
+	}
+
+	if c.Date.IsZero() {
+		c.Date = time.Now()
+	}
+
+	c.GoalID = goal.ID
+	c.OwnerID = goal.OwnerID
+	c.Month, c.Year, err = SpendPeriod(Spend{Date: c.Date, Timezone: c.Timezone})
+	if err != nil {
+		return "", err
+	}
+
+	dbClient, err := services.InitDatabase()
+	if err != nil {
+		return "", err
+	}
+
+	contributions := dbClient.Database(mongodbDatabase).Collection(mongodbGoalContributionsCollection)
+	balances := dbClient.Database(mongodbDatabase).Collection(mongodbBalanceCollection)
+	goals := dbClient.Database(mongodbDatabase).Collection(mongodbGoalsCollection)
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	_, err = contributions.Indexes().CreateMany(
+		ctx,
+		[]mongo.IndexModel{
+			{Keys: bsonx.Doc{{Key: "goal_id", Value: bsonx.Int32(1)}, {Key: "date", Value: bsonx.Int32(1)}}},
+			{Keys: bsonx.Doc{{Key: "owner_id", Value: bsonx.Int32(1)}, {Key: "year", Value: bsonx.Int32(1)}, {Key: "month", Value: bsonx.Int32(1)}}},
+		},
+	)
+
+	c.CreatedAt = primitive.NewDateTimeFromTime(time.Now())
+	r, err := contributions.InsertOne(ctx, c)
+	if err != nil {
+		return "", err
+	}
+
+	c.ID = r.InsertedID.(primitive.ObjectID)
+	undoContribution := func() {
+		_, err := contributions.DeleteOne(ctx, bson.M{"_id": c.ID})
+		if err != nil {
+			log.Errorln("could not delete contribution", c.ID.Hex(), "to goal", goalID, ":", err)
+		}
+	}
+
+	_, err = balances.Indexes().CreateOne(ctx, balancesPeriodIndex)
+
+	// a closed balance isn't matched, so the upsert hits the unique index instead
+	t := primitive.NewDateTimeFromTime(time.Now())
+	balanceFilter := bson.M{"owner_id": c.OwnerID, "month": c.Month, "year": c.Year, "closed": bson.M{"$ne": true}}
+	_, err = balances.UpdateOne(ctx,
+		balanceFilter,
+		bson.M{
+			"$inc": bson.M{
+				"outcome.savings":  c.Amount,
+				"spendable_amount": -c.Amount,
+			},
+			"$set":         bson.M{"updated_at": t},
+			"$setOnInsert": emptyBalance(t, bson.M{"historic": bson.A{}, "incomes": bson.A{}, "income": Income{}}),
+		},
+		options.Update().SetUpsert(true),
+	)
+	if mongo.IsDuplicateKeyError(err) {
+		err = ErrBalanceClosed
+	}
+
+	if err != nil {
+		undoContribution()
+		return "", err
+	}
+
+	_, err = goals.UpdateOne(ctx,
+		bson.M{"_id": goal.ID},
+		bson.M{
+			"$inc": bson.M{"saved": c.Amount},
+			"$set": bson.M{"updated_at": primitive.NewDateTimeFromTime(time.Now())},
+		},
+	)
+	if err != nil {
+		_, undoErr := balances.UpdateOne(ctx, balanceFilter, bson.M{"$inc": bson.M{
+			"outcome.savings":  -c.Amount,
+			"spendable_amount": c.Amount,
+		}})
+		if undoErr != nil {
+			log.Errorln("could not remove contribution", c.ID.Hex(), "from balance:", undoErr)
+		}
+
+		undoContribution()
+		return "", err
+	}
+
+	log.Infoln("added contribution to goal", goalID)
+	return c.ID.Hex(), nil
+}
+
+// GetMonthContributions will return all goal contributions from an owner_id made in a given month
+func GetMonthContributions(ctx context.Context, ownerID primitive.ObjectID, month int64, year int64) (contributions []GoalContribution, err error) {
+	return findGoalContributions(ctx, bson.M{"owner_id": ownerID, "month": month, "year": year})
+}
+
+// findGoalContributions will return all goal contributions matching a filter sorted by date
+func findGoalContributions(parentCtx context.Context, filter bson.M) (contributions []GoalContribution, err error) {
+	ctx, span := observability.Span(parentCtx, "mongodb", "findGoalContributions", []attribute.KeyValue{})
+	defer span.End()
+
+	dbClient, err := services.InitDatabase()
+	if err != nil {
+		return []GoalContribution{}, err
+	}
+
+	col := dbClient.Database(mongodbDatabase).Collection(mongodbGoalContributionsCollection)
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	cursor, err := col.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "date", Value: 1}}))
+	if err != nil {
+		cancel()
+		return []GoalContribution{}, err
+	}
+
+	defer cursor.Close(ctx)
+	defer cancel()
+
+	for cursor.Next(ctx) {
+		var contribution GoalContribution
+		cursor.Decode(&contribution)
+		contributions = append(contributions, contribution)
+	}
+
+	if err := cursor.Err(); err != nil {
+		cancel()
+		return []GoalContribution{}, err
+	}
+
+	return contributions, nil
+}
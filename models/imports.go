@@ -0,0 +1,411 @@
+package models
+
+import (
+	"budget-tracker-api/observability"
+	"budget-tracker-api/services"
+	"context"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/x/bsonx"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// ValidateImportProfile will validate every attribute of an import profile, returning all invalid ones.
+// The spend attributes given by the profile are validated as a spend would
+func ValidateImportProfile(ctx context.Context, p ImportProfile) (errs []FieldError, err error) {
+	errs = []FieldError{}
+
+	if p.Name == "" {
+		errs = append(errs, FieldError{Field: "name", Message: "missing name"})
+	}
+
+	for field, column := range map[string]string{
+		"date_column":        p.DateColumn,
+		"description_column": p.DescriptionColumn,
+		"amount_column":      p.AmountColumn,
+		"date_format":        p.DateFormat,
+	} {
+		if column == "" {
+			errs = append(errs, FieldError{Field: field, Message: "missing " + field})
+		}
+	}
+
+	if len([]rune(p.Delimiter)) > 1 {
+		errs = append(errs, FieldError{Field: "delimiter", Message: "delimiter must be a single character"})
+	}
+
+	if p.SpendSign != "" && p.SpendSign != "negative" && p.SpendSign != "positive" {
+		errs = append(errs, FieldError{Field: "spend_sign", Message: "spend sign must be one of 'negative' or 'positive'"})
+	}
+
+	if p.Type == "" {
+		p.Type = "dynamic"
+	}
+
+	// description and cost come from the imported file
+	spendErrs, err := ValidateSpend(ctx, Spend{
+		OwnerID:       p.OwnerID,
+		Type:          p.Type,
+		Description:   p.Name,
+		Cost:          1,
+		PaymentMethod: p.PaymentMethod,
+		Timezone:      p.Timezone,
+	})
+	if err != nil {
+		return errs, err
+	}
+
+	return append(errs, spendErrs...), nil
+}
+
+// CreateImportProfile creates an import profile for a given owner_id
+func CreateImportProfile(parentCtx context.Context, p ImportProfile) (id string, err error) {
+	spanTags := []attribute.KeyValue{
+		attribute.Key("import.owner.id").String(p.OwnerID.String()),
+	}
+
+	ctx, span := observability.Span(parentCtx, "mongodb", "CreateImportProfile", spanTags)
+	defer span.End()
+
+	dbClient, err := services.InitDatabase()
+	if err != nil {
+		return "", err
+	}
+
+	col := dbClient.Database(mongodbDatabase).Collection(mongodbImportProfilesCollection)
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+
+	_, err = col.Indexes().CreateOne(
+		ctx,
+		mongo.IndexModel{
+			Keys:    bsonx.Doc{{Key: "owner_id", Value: bsonx.Int32(1)}, {Key: "name", Value: bsonx.Int32(1)}},
+			Options: options.Index().SetUnique(true),
+		},
+	)
+
+	// adding timestamp to creationDate
+	p.CreatedAt = primitive.NewDateTimeFromTime(time.Now())
+
+	r, err := col.InsertOne(ctx, p)
+	if err != nil {
+		cancel()
+		return "", err
+	}
+
+	span.SetAttributes(attribute.Key("import.profile.id").String(r.InsertedID.(primitive.ObjectID).Hex()))
+	defer cancel()
+
+	log.Infoln("created import profile", p.Name)
+	return r.InsertedID.(primitive.ObjectID).Hex(), nil
+}
+
+// GetImportProfile will return a single import profile based on its ID
+func GetImportProfile(parentCtx context.Context, id string) (profile *ImportProfile, err error) {
+	spanTags := []attribute.KeyValue{
+		attribute.Key("import.profile.id").String(id),
+	}
+
+	ctx, span := observability.Span(parentCtx, "mongodb", "GetImportProfile", spanTags)
+	defer span.End()
+
+	pid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return &ImportProfile{}, err
+	}
+
+	dbClient, err := services.InitDatabase()
+	if err != nil {
+		return &ImportProfile{}, err
+	}
+
+	col := dbClient.Database(mongodbDatabase).Collection(mongodbImportProfilesCollection)
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+
+	err = col.FindOne(ctx, bson.M{"_id": pid}).Decode(&profile)
+	if err != nil {
+		cancel()
+		return &ImportProfile{}, err
+	}
+
+	defer cancel()
+	return profile, nil
+}
+
+// GetImportProfiles will return all import profiles from an owner_id
+func GetImportProfiles(parentCtx context.Context, ownerID string) (profiles []ImportProfile, err error) {
+	spanTags := []attribute.KeyValue{
+		attribute.Key("import.owner.id").String(ownerID),
+	}
+
+	ctx, span := observability.Span(parentCtx, "mongodb", "GetImportProfiles", spanTags)
+	defer span.End()
+
+	oid, err := primitive.ObjectIDFromHex(ownerID)
+	if err != nil {
+		return []ImportProfile{}, err
+	}
+
+	dbClient, err := services.InitDatabase()
+	if err != nil {
+		return []ImportProfile{}, err
+	}
+
+	col := dbClient.Database(mongodbDatabase).Collection(mongodbImportProfilesCollection)
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	cursor, err := col.Find(ctx, bson.M{"owner_id": oid}, options.Find().SetSort(bson.D{{Key: "name", Value: 1}}))
+	if err != nil {
+		cancel()
+		return []ImportProfile{}, err
+	}
+
+	defer cursor.Close(ctx)
+	defer cancel()
+
+	for cursor.Next(ctx) {
+		var profile ImportProfile
+		cursor.Decode(&profile)
+		profiles = append(profiles, profile)
+	}
+
+	if err := cursor.Err(); err != nil {
+		cancel()
+		return []ImportProfile{}, err
+	}
+
+	return profiles, nil
+}
+
+// DeleteImportProfile deletes an import profile given an ID
+func DeleteImportProfile(parentCtx context.Context, id string) (err error) {
+	spanTags := []attribute.KeyValue{
+		attribute.Key("import.profile.id").String(id),
+	}
+
+	ctx, span := observability.Span(parentCtx, "mongodb", "DeleteImportProfile", spanTags)
+	defer span.End()
+
+	pid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+
+	dbClient, err := services.InitDatabase()
+	if err != nil {
+		return err
+	}
+
+	col := dbClient.Database(mongodbDatabase).Collection(mongodbImportProfilesCollection)
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+
+	result, err := col.DeleteOne(ctx, bson.M{"_id": pid})
+	if err != nil {
+		cancel()
+		return err
+	}
+
+	if result.DeletedCount == 0 {
+		cancel()
+		return mongo.ErrNoDocuments
+	}
+
+	defer cancel()
+
+	log.Infoln("deleted import profile", id)
+	return nil
+}
+
+// CreateImportJob creates a pending import job, returning its ID
+func CreateImportJob(parentCtx context.Context, job ImportJob) (id string, err error) {
+	spanTags := []attribute.KeyValue{
+		attribute.Key("import.owner.id").String(job.OwnerID.String()),
+	}
+
+	ctx, span := observability.Span(parentCtx, "mongodb", "CreateImportJob", spanTags)
+	defer span.End()
+
+	dbClient, err := services.InitDatabase()
+	if err != nil {
+		return "", err
+	}
+
+	col := dbClient.Database(mongodbDatabase).Collection(mongodbImportJobsCollection)
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+
+	job.Status = "pending"
+	if job.Errors == nil {
+		job.Errors = []ImportError{}
+	}
+	job.CreatedAt = primitive.NewDateTimeFromTime(time.Now())
+
+	r, err := col.InsertOne(ctx, job)
+	if err != nil {
+		cancel()
+		return "", err
+	}
+
+	defer cancel()
+
+	log.Infoln("created import job", r.InsertedID.(primitive.ObjectID).Hex())
+	return r.InsertedID.(primitive.ObjectID).Hex(), nil
+}
+
+// UpdateImportJob will replace the status and counters of an import job
+func UpdateImportJob(parentCtx context.Context, job ImportJob) (err error) {
+	spanTags := []attribute.KeyValue{
+		attribute.Key("import.job.id").String(job.ID.Hex()),
+	}
+
+	ctx, span := observability.Span(parentCtx, "mongodb", "UpdateImportJob", spanTags)
+	defer span.End()
+
+	dbClient, err := services.InitDatabase()
+	if err != nil {
+		return err
+	}
+
+	col := dbClient.Database(mongodbDatabase).Collection(mongodbImportJobsCollection)
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	_, err = col.ReplaceOne(ctx, bson.M{"_id": job.ID}, job)
+	return err
+}
+
+// GetImportJob will return a single import job based on its ID
+func GetImportJob(parentCtx context.Context, id string) (job *ImportJob, err error) {
+	spanTags := []attribute.KeyValue{
+		attribute.Key("import.job.id").String(id),
+	}
+
+	ctx, span := observability.Span(parentCtx, "mongodb", "GetImportJob", spanTags)
+	defer span.End()
+
+	jid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return &ImportJob{}, err
+	}
+
+	dbClient, err := services.InitDatabase()
+	if err != nil {
+		return &ImportJob{}, err
+	}
+
+	col := dbClient.Database(mongodbDatabase).Collection(mongodbImportJobsCollection)
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+
+	err = col.FindOne(ctx, bson.M{"_id": jid}).Decode(&job)
+	if err != nil {
+		cancel()
+		return &ImportJob{}, err
+	}
+
+	defer cancel()
+	return job, nil
+}
+
+// FindDuplicateSpend will check if an owner_id already has a spend with the same external ID and card of
+// a given spend or, for spends without one, with the same description and cost made on the same day
+func FindDuplicateSpend(parentCtx context.Context, s Spend) (duplicate bool, err error) {
+	spanTags := []attribute.KeyValue{
+		attribute.Key("spend.owner.id").String(s.OwnerID.String()),
+	}
+
+	ctx, span := observability.Span(parentCtx, "mongodb", "FindDuplicateSpend", spanTags)
+	defer span.End()
+
+	loc := time.UTC
+	if s.Timezone != "" {
+		loc, err = time.LoadLocation(s.Timezone)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	date := s.Date.In(loc)
+	day := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, loc)
+
+	dbClient, err := services.InitDatabase()
+	if err != nil {
+		return false, err
+	}
+
+	col := dbClient.Database(mongodbDatabase).Collection(mongodbSpendsCollection)
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{
+		"owner_id":    s.OwnerID,
+		"description": s.Description,
+		"cost":        s.Cost,
+		"date":        bson.M{"$gte": day, "$lt": day.AddDate(0, 0, 1)},
+	}
+
+	// external IDs are only unique within the card, or the debit account, the spend was imported from
+	if s.ExternalID != "" {
+		filter = bson.M{
+			"owner_id":               s.OwnerID,
+			"external_id":            s.ExternalID,
+			"payment_method.card_id": bson.M{"$exists": false},
+		}
+
+		if s.PaymentMethod.Credit {
+			filter["payment_method.card_id"] = s.PaymentMethod.CardID
+		}
+	}
+
+	count, err := col.CountDocuments(ctx, filter, options.Count().SetLimit(1))
+	if err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+// FindDuplicateIncome will check if an owner_id already has an income entry with the same external ID of
+// a given entry or, for entries without one, with the same description and net income received on the
+// same day
+func FindDuplicateIncome(parentCtx context.Context, ownerID primitive.ObjectID, e IncomeEntry) (duplicate bool, err error) {
+	spanTags := []attribute.KeyValue{
+		attribute.Key("balance.owner.id").String(ownerID.String()),
+	}
+
+	ctx, span := observability.Span(parentCtx, "mongodb", "FindDuplicateIncome", spanTags)
+	defer span.End()
+
+	dbClient, err := services.InitDatabase()
+	if err != nil {
+		return false, err
+	}
+
+	col := dbClient.Database(mongodbDatabase).Collection(mongodbBalanceCollection)
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	date := e.ReceivedAt
+	day := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location())
+
+	filter := bson.M{
+		"owner_id": ownerID,
+		"incomes": bson.M{"$elemMatch": bson.M{
+			"description": e.Description,
+			"net":         e.NetIncome,
+			"received_at": bson.M{"$gte": day, "$lt": day.AddDate(0, 0, 1)},
+		}},
+	}
+
+	if e.ExternalID != "" {
+		filter = bson.M{"owner_id": ownerID, "incomes.external_id": e.ExternalID}
+	}
+
+	count, err := col.CountDocuments(ctx, filter, options.Count().SetLimit(1))
+	if err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
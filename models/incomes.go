@@ -0,0 +1,209 @@
+package models
+
+import (
+	"budget-tracker-api/observability"
+	"budget-tracker-api/services"
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// incomeSources defines the allowed income sources
+var incomeSources = []string{"salary", "freelance", "rental", "investment", "other"}
+
+// ValidateIncomeEntry will validate every attribute of an income entry, returning all invalid ones.
+// Fields are prefixed by `prefix` so entries from a list can be told apart
+func ValidateIncomeEntry(prefix string, e IncomeEntry) (errs []FieldError) {
+	errs = []FieldError{}
+
+	valid := false
+	for _, source := range incomeSources {
+		if e.Source == source {
+			valid = true
+		}
+	}
+
+	if !valid {
+		errs = append(errs, FieldError{Field: prefix + "source", Message: "source must be one of 'salary', 'freelance', 'rental', 'investment' or 'other'"})
+	}
+
+	if e.NetIncome <= 0 {
+		errs = append(errs, FieldError{Field: prefix + "net", Message: "net income must be greater than zero"})
+	}
+
+	if e.GrossIncome != 0 && e.GrossIncome < e.NetIncome {
+		errs = append(errs, FieldError{Field: prefix + "gross", Message: "gross income can't be lower than net income"})
+	}
+
+	return errs
+}
+
+// IncomeTotals will sum the gross and net incomes from a list of income entries
+func IncomeTotals(entries []IncomeEntry) (total Income) {
+	for _, e := range entries {
+		total.GrossIncome += e.GrossIncome
+		total.NetIncome += e.NetIncome
+	}
+
+	total.GrossIncome = roundCents(total.GrossIncome)
+	total.NetIncome = roundCents(total.NetIncome)
+	return total
+}
+
+// RemoveIncomeFromBalance will remove an income entry from an owner_id month balance, updating its
+// income totals and spendable amount
+func RemoveIncomeFromBalance(parentCtx context.Context, ownerID string, month int64, year int64, id string) (err error) {
+	spanTags := []attribute.KeyValue{
+		attribute.Key("balance.owner.id").String(ownerID),
+		attribute.Key("income.id").String(id),
+	}
+
+	ctx, span := observability.Span(parentCtx, "mongodb", "RemoveIncomeFromBalance", spanTags)
+	defer span.End()
+
+	iid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+
+	balance, err := GetBalance(ctx, ownerID, month, year)
+	if err != nil {
+		return err
+	}
+
+	if balance.Closed {
+		return ErrBalanceClosed
+	}
+
+	var entry *IncomeEntry
+	for i := range balance.Incomes {
+		if balance.Incomes[i].ID == iid {
+			entry = &balance.Incomes[i]
+		}
+	}
+
+	if entry == nil {
+		return errors.New("non existent income")
+	}
+
+	dbClient, err := services.InitDatabase()
+	if err != nil {
+		return err
+	}
+
+	col := dbClient.Database(mongodbDatabase).Collection(mongodbBalanceCollection)
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+
+	result, err := col.UpdateOne(ctx,
+		bson.M{"_id": balance.ID, "closed": bson.M{"$ne": true}, "incomes._id": iid},
+		bson.M{
+			"$pull": bson.M{"incomes": bson.M{"_id": iid}},
+			"$inc": bson.M{
+				"income.gross":     -entry.GrossIncome,
+				"income.net":       -entry.NetIncome,
+				"spendable_amount": -entry.NetIncome,
+			},
+			"$set": bson.M{"updated_at": primitive.NewDateTimeFromTime(time.Now())},
+		},
+	)
+	if err != nil {
+		cancel()
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		cancel()
+		return errors.New("non existent income")
+	}
+
+	defer cancel()
+
+	log.Infoln("removed income", id, "from balance", month, "/", year)
+	return nil
+}
+
+// GetIncomesBreakdown will return how much an owner_id received from each income source during a year,
+// month by month
+func GetIncomesBreakdown(parentCtx context.Context, ownerID string, year int64) (breakdown []IncomeBreakdown, err error) {
+	spanTags := []attribute.KeyValue{
+		attribute.Key("balance.owner.id").String(ownerID),
+	}
+
+	ctx, span := observability.Span(parentCtx, "mongodb", "GetIncomesBreakdown", spanTags)
+	defer span.End()
+
+	oid, err := primitive.ObjectIDFromHex(ownerID)
+	if err != nil {
+		return []IncomeBreakdown{}, err
+	}
+
+	dbClient, err := services.InitDatabase()
+	if err != nil {
+		return []IncomeBreakdown{}, err
+	}
+
+	col := dbClient.Database(mongodbDatabase).Collection(mongodbBalanceCollection)
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+
+	cursor, err := col.Aggregate(ctx, bson.A{
+		bson.M{"$match": bson.M{"owner_id": oid, "year": year}},
+		bson.M{"$unwind": "$incomes"},
+		bson.M{"$group": bson.M{
+			"_id":   bson.M{"source": "$incomes.source", "month": "$month"},
+			"gross": bson.M{"$sum": "$incomes.gross"},
+			"net":   bson.M{"$sum": "$incomes.net"},
+		}},
+		bson.M{"$sort": bson.D{{Key: "_id.source", Value: 1}, {Key: "_id.month", Value: 1}}},
+	})
+	if err != nil {
+		cancel()
+		return []IncomeBreakdown{}, err
+	}
+
+	defer cursor.Close(ctx)
+	defer cancel()
+
+	index := map[string]int{}
+	for cursor.Next(ctx) {
+		var group struct {
+			ID struct {
+				Source string `bson:"source"`
+				Month  int64  `bson:"month"`
+			} `bson:"_id"`
+			Gross float64 `bson:"gross"`
+			Net   float64 `bson:"net"`
+		}
+		cursor.Decode(&group)
+
+		i, ok := index[group.ID.Source]
+		if !ok {
+			breakdown = append(breakdown, IncomeBreakdown{Source: group.ID.Source, Months: []IncomeMonth{}})
+			i = len(breakdown) - 1
+			index[group.ID.Source] = i
+		}
+
+		breakdown[i].GrossIncome = roundCents(breakdown[i].GrossIncome + group.Gross)
+		breakdown[i].NetIncome = roundCents(breakdown[i].NetIncome + group.Net)
+		breakdown[i].Months = append(breakdown[i].Months, IncomeMonth{
+			Month:       group.ID.Month,
+			GrossIncome: roundCents(group.Gross),
+			NetIncome:   roundCents(group.Net),
+		})
+	}
+
+	if err := cursor.Err(); err != nil {
+		cancel()
+		return []IncomeBreakdown{}, err
+	}
+
+	// biggest sources first
+	sort.SliceStable(breakdown, func(i, j int) bool { return breakdown[i].NetIncome > breakdown[j].NetIncome })
+
+	return breakdown, nil
+}
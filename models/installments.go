@@ -0,0 +1,255 @@
+package models
+
+import (
+	"budget-tracker-api/observability"
+	"budget-tracker-api/services"
+	"context"
+	"errors"
+	"math"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// bookedSpendsFilter will match only spends which are accounted to balances, leaving out
+// installment plans and split spends (only their installments and parts are booked)
+func bookedSpendsFilter() bson.M {
+	return bson.M{
+		"$or": bson.A{
+			bson.M{"installments": bson.M{"$exists": false}},
+			bson.M{"parent_id": bson.M{"$exists": true}},
+		},
+		"lines":  bson.M{"$exists": false},
+		"shares": bson.M{"$exists": false},
+	}
+}
+
+// isBooked will tell whether a spend is accounted to balances, the same way as bookedSpendsFilter
+func isBooked(s Spend) bool {
+	if len(s.Lines) > 0 || len(s.Shares) > 0 {
+		return false
+	}
+
+	return s.Installments == 0 || !s.ParentID.IsZero()
+}
+
+// cardChargesFilter will match the spends charged at card statements. Split spends are charged at their
+// full cost, so they are matched instead of their parts, while installment plans are charged through
+// their installments
+func cardChargesFilter() bson.M {
+	return bson.M{
+		"$or": bson.A{
+			bson.M{"installments": bson.M{"$exists": false}},
+			bson.M{"parent_id": bson.M{"$exists": true}},
+		},
+		"split_id": bson.M{"$exists": false},
+	}
+}
+
+// statementDate will return the date of the n-th (starting at 0) card statement in which a
+// purchase made at a given date will be charged. Statements due up to their closing day are
+// due on the month after they close
+func statementDate(purchase time.Time, card CreditCard, n int) time.Time {
+	offset := n
+	if card.ClosingDay > 0 && purchase.Day() >= card.ClosingDay {
+		offset++
+	}
+
+	if card.ClosingDay > 0 && card.DueDay > 0 && card.DueDay <= card.ClosingDay {
+		offset++
+	}
+
+	// without a closing day, purchases after the due day are charged at the next month
+	if card.ClosingDay == 0 && card.DueDay > 0 && card.DueDay < purchase.Day() {
+		offset++
+	}
+
+	day := purchase.Day()
+	if card.DueDay > 0 {
+		day = card.DueDay
+	}
+
+	first := time.Date(purchase.Year(), purchase.Month()+time.Month(offset), 1, purchase.Hour(), purchase.Minute(), purchase.Second(), 0, purchase.Location())
+	lastDay := first.AddDate(0, 1, -1).Day()
+	if day > lastDay {
+		day = lastDay
+	}
+
+	return first.AddDate(0, 0, day-1)
+}
+
+// SplitInstallments will generate the installment spends of an installment plan, one per statement
+// of the given card. The cents left by the division are charged at the first installment
+func SplitInstallments(plan Spend, card CreditCard) (installments []Spend, err error) {
+	if plan.Installments < 2 {
+		return []Spend{}, errors.New("installment plans must have at least 2 installments")
+	}
+
+	loc := time.UTC
+	if plan.Timezone != "" {
+		loc, err = time.LoadLocation(plan.Timezone)
+		if err != nil {
+			return []Spend{}, err
+		}
+	}
+
+	total := math.Round(plan.Cost * 100)
+	value := math.Floor(total/float64(plan.Installments)) / 100
+	first := roundCents((total - value*100*float64(plan.Installments-1)) / 100)
+
+	for n := 0; n < plan.Installments; n++ {
+		installment := plan
+		installment.ID = primitive.NilObjectID
+		installment.ParentID = plan.ID
+		installment.InstallmentNumber = n + 1
+		installment.Cost = value
+		if n == 0 {
+			installment.Cost = first
+		}
+
+		installment.Date = statementDate(plan.Date.In(loc), card, n)
+		installment.Month, installment.Year, err = SpendPeriod(installment)
+		if err != nil {
+			return []Spend{}, err
+		}
+
+		installments = append(installments, installment)
+	}
+
+	return installments, nil
+}
+
+// CreateInstallments creates an installment plan along with all its installments, returning the plan ID
+// and the created installments
+func CreateInstallments(parentCtx context.Context, plan Spend) (id string, installments []Spend, err error) {
+	spanTags := []attribute.KeyValue{
+		attribute.Key("spend.owner.id").String(plan.OwnerID.String()),
+		attribute.Key("spend.installments").Int(plan.Installments),
+	}
+
+	ctx, span := observability.Span(parentCtx, "mongodb", "CreateInstallments", spanTags)
+	defer span.End()
+
+	card, err := GetCard(ctx, plan.PaymentMethod.CardID.Hex())
+	if err != nil {
+		return "", []Spend{}, err
+	}
+
+	plan, err = prepareSpend(plan)
+	if err != nil {
+		return "", []Spend{}, err
+	}
+
+	// installments are inserted before their plan, so a failure never leaves a plan without installments
+	plan.ID = primitive.NewObjectID()
+	installments, err = SplitInstallments(plan, *card)
+	if err != nil {
+		return "", []Spend{}, err
+	}
+
+	installments, err = insertSpends(ctx, installments)
+	if err != nil {
+		return "", []Spend{}, err
+	}
+
+	id, err = CreateSpend(ctx, plan)
+	if err != nil {
+		rollbackErr := deleteSpends(ctx, spendIDs(installments))
+		if rollbackErr != nil {
+			log.Errorln("could not delete installments from plan", plan.ID.Hex(), ":", rollbackErr)
+		}
+		return "", []Spend{}, err
+	}
+
+	log.Infoln("created", len(installments), "installments for spend", id)
+	return id, installments, nil
+}
+
+// GetCardsInstallments will return the installments yet to be charged from an owner_id grouped by card.
+// An empty cardID will return installments from all cards
+func GetCardsInstallments(parentCtx context.Context, ownerID string, cardID string) (cards []CardInstallments, err error) {
+	spanTags := []attribute.KeyValue{
+		attribute.Key("spend.owner.id").String(ownerID),
+	}
+
+	ctx, span := observability.Span(parentCtx, "mongodb", "GetCardsInstallments", spanTags)
+	defer span.End()
+
+	oid, err := primitive.ObjectIDFromHex(ownerID)
+	if err != nil {
+		return []CardInstallments{}, err
+	}
+
+	now := time.Now()
+	filter := bson.M{
+		"owner_id":  oid,
+		"parent_id": bson.M{"$exists": true},
+		"$or": bson.A{
+			bson.M{"year": bson.M{"$gt": now.Year()}},
+			bson.M{"year": now.Year(), "month": bson.M{"$gte": int(now.Month())}},
+		},
+	}
+
+	if cardID != "" {
+		cid, err := primitive.ObjectIDFromHex(cardID)
+		if err != nil {
+			return []CardInstallments{}, err
+		}
+		filter["payment_method.card_id"] = cid
+	}
+
+	ownerCards, err := GetCards(ctx, ownerID)
+	if err != nil {
+		return []CardInstallments{}, err
+	}
+
+	aliases := map[primitive.ObjectID]string{}
+	for _, card := range ownerCards {
+		aliases[card.ID] = card.Alias
+	}
+
+	dbClient, err := services.InitDatabase()
+	if err != nil {
+		return []CardInstallments{}, err
+	}
+
+	col := dbClient.Database(mongodbDatabase).Collection(mongodbSpendsCollection)
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	cursor, err := col.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "date", Value: 1}}))
+	if err != nil {
+		cancel()
+		return []CardInstallments{}, err
+	}
+
+	defer cursor.Close(ctx)
+	defer cancel()
+
+	index := map[primitive.ObjectID]int{}
+	for cursor.Next(ctx) {
+		var installment Spend
+		cursor.Decode(&installment)
+
+		cid := installment.PaymentMethod.CardID
+		i, ok := index[cid]
+		if !ok {
+			cards = append(cards, CardInstallments{CardID: cid, Alias: aliases[cid], Remaining: []Spend{}})
+			i = len(cards) - 1
+			index[cid] = i
+		}
+
+		cards[i].Remaining = append(cards[i].Remaining, installment)
+		cards[i].RemainingCount++
+		cards[i].FutureCommitment = math.Round((cards[i].FutureCommitment+installment.Cost)*100) / 100
+	}
+
+	if err := cursor.Err(); err != nil {
+		cancel()
+		return []CardInstallments{}, err
+	}
+
+	return cards, nil
+}
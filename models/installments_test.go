@@ -0,0 +1,78 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStatementDate(t *testing.T) {
+	date := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 12, 0, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name     string
+		purchase time.Time
+		card     CreditCard
+		n        int
+		want     time.Time
+	}{
+		{"due after closing, before closing", date(2024, time.January, 10), CreditCard{ClosingDay: 3, DueDay: 10}, 0, date(2024, time.February, 10)},
+		{"due after closing, before closing day", date(2024, time.January, 2), CreditCard{ClosingDay: 3, DueDay: 10}, 0, date(2024, time.January, 10)},
+		{"due after closing, at closing day", date(2024, time.January, 3), CreditCard{ClosingDay: 3, DueDay: 10}, 0, date(2024, time.February, 10)},
+		{"due before closing, before closing day", date(2024, time.January, 10), CreditCard{ClosingDay: 25, DueDay: 5}, 0, date(2024, time.February, 5)},
+		{"due before closing, after closing day", date(2024, time.January, 26), CreditCard{ClosingDay: 25, DueDay: 5}, 0, date(2024, time.March, 5)},
+		{"due at closing day", date(2024, time.January, 10), CreditCard{ClosingDay: 15, DueDay: 15}, 0, date(2024, time.February, 15)},
+		{"later installment", date(2024, time.January, 10), CreditCard{ClosingDay: 25, DueDay: 5}, 2, date(2024, time.April, 5)},
+		{"installment crossing the year", date(2024, time.November, 28), CreditCard{ClosingDay: 20, DueDay: 28}, 1, date(2025, time.January, 28)},
+		{"due day past the end of month", date(2024, time.January, 10), CreditCard{ClosingDay: 20, DueDay: 31}, 1, date(2024, time.February, 29)},
+		{"card without closing day, before due day", date(2024, time.January, 2), CreditCard{DueDay: 5}, 0, date(2024, time.January, 5)},
+		{"card without closing day, at due day", date(2024, time.January, 5), CreditCard{DueDay: 5}, 0, date(2024, time.January, 5)},
+		{"card without closing day, after due day", date(2024, time.January, 10), CreditCard{DueDay: 5}, 0, date(2024, time.February, 5)},
+		{"card without closing day, later installment", date(2024, time.January, 10), CreditCard{DueDay: 5}, 1, date(2024, time.March, 5)},
+		{"card without due day", date(2024, time.January, 10), CreditCard{ClosingDay: 5}, 0, date(2024, time.February, 10)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := statementDate(tt.purchase, tt.card, tt.n)
+			if !got.Equal(tt.want) {
+				t.Errorf("statementDate(%s, %+v, %d) = %s, want %s", tt.purchase.Format("2006-01-02"), tt.card, tt.n, got.Format("2006-01-02"), tt.want.Format("2006-01-02"))
+			}
+		})
+	}
+}
+
+func TestSplitInstallments(t *testing.T) {
+	tests := []struct {
+		name         string
+		cost         float64
+		installments int
+		want         []float64
+	}{
+		{"even split", 90, 3, []float64{30, 30, 30}},
+		{"cents left charged first", 100, 3, []float64{33.34, 33.33, 33.33}},
+		{"cents of the cost", 100.01, 2, []float64{50.01, 50}},
+		{"float noise", 0.3, 3, []float64{0.1, 0.1, 0.1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plan := Spend{Cost: tt.cost, Installments: tt.installments, Date: time.Date(2024, time.January, 10, 12, 0, 0, 0, time.UTC)}
+			installments, err := SplitInstallments(plan, CreditCard{ClosingDay: 3, DueDay: 10})
+			if err != nil {
+				t.Fatalf("SplitInstallments() error = %v", err)
+			}
+
+			if len(installments) != len(tt.want) {
+				t.Fatalf("SplitInstallments() returned %d installments, want %d", len(installments), len(tt.want))
+			}
+
+			for i, installment := range installments {
+				if installment.Cost != tt.want[i] {
+					t.Errorf("installment %d cost = %v, want %v", i+1, installment.Cost, tt.want[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,282 @@
+package models
+
+import (
+	"budget-tracker-api/observability"
+	"budget-tracker-api/services"
+	"context"
+	"errors"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/x/bsonx"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// RegisterNotificationEvent will claim a notification key for an owner_id before the notification is
+// sent, so concurrent checks of the same event send it only once. It returns false when a notification
+// with the same key was already claimed before
+func RegisterNotificationEvent(parentCtx context.Context, n Notification) (registered bool, err error) {
+	spanTags := []attribute.KeyValue{
+		attribute.Key("notification.owner.id").String(n.OwnerID.String()),
+		attribute.Key("notification.key").String(n.Key),
+	}
+
+	ctx, span := observability.Span(parentCtx, "mongodb", "RegisterNotificationEvent", spanTags)
+	defer span.End()
+
+	dbClient, err := services.InitDatabase()
+	if err != nil {
+		return false, err
+	}
+
+	col := dbClient.Database(mongodbDatabase).Collection(mongodbNotificationEventsCollection)
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	_, err = col.Indexes().CreateOne(
+		ctx,
+		mongo.IndexModel{
+			Keys:    bsonx.Doc{{Key: "owner_id", Value: bsonx.Int32(1)}, {Key: "key", Value: bsonx.Int32(1)}},
+			Options: options.Index().SetUnique(true),
+		},
+	)
+
+	_, err = col.InsertOne(ctx, bson.M{
+		"owner_id":   n.OwnerID,
+		"key":        n.Key,
+		"event":      n.Event,
+		"created_at": primitive.NewDateTimeFromTime(time.Now()),
+	})
+	if mongo.IsDuplicateKeyError(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// ReleaseNotificationEvent will release the claim of a notification key which couldn't be sent, so it is
+// claimed again the next time its event is checked
+func ReleaseNotificationEvent(parentCtx context.Context, n Notification) (err error) {
+	spanTags := []attribute.KeyValue{
+		attribute.Key("notification.owner.id").String(n.OwnerID.String()),
+		attribute.Key("notification.key").String(n.Key),
+	}
+
+	ctx, span := observability.Span(parentCtx, "mongodb", "ReleaseNotificationEvent", spanTags)
+	defer span.End()
+
+	dbClient, err := services.InitDatabase()
+	if err != nil {
+		return err
+	}
+
+	col := dbClient.Database(mongodbDatabase).Collection(mongodbNotificationEventsCollection)
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	_, err = col.DeleteOne(ctx, bson.M{"owner_id": n.OwnerID, "key": n.Key})
+	return err
+}
+
+// RecordFailedNotifiers will record the notifiers a claimed notification couldn't be sent through, so
+// they are retried the next time its event is checked
+func RecordFailedNotifiers(parentCtx context.Context, n Notification, notifiers []string) (err error) {
+	spanTags := []attribute.KeyValue{
+		attribute.Key("notification.owner.id").String(n.OwnerID.String()),
+		attribute.Key("notification.key").String(n.Key),
+	}
+
+	ctx, span := observability.Span(parentCtx, "mongodb", "RecordFailedNotifiers", spanTags)
+	defer span.End()
+
+	dbClient, err := services.InitDatabase()
+	if err != nil {
+		return err
+	}
+
+	col := dbClient.Database(mongodbDatabase).Collection(mongodbNotificationEventsCollection)
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	_, err = col.UpdateOne(ctx,
+		bson.M{"owner_id": n.OwnerID, "key": n.Key},
+		bson.M{"$set": bson.M{"failed_notifiers": notifiers}},
+	)
+	return err
+}
+
+// ClaimFailedNotifiers will take the notifiers recorded as failed for a notification key, clearing them
+// so concurrent checks of the same event retry them only once
+func ClaimFailedNotifiers(parentCtx context.Context, n Notification) (notifiers []string, err error) {
+	spanTags := []attribute.KeyValue{
+		attribute.Key("notification.owner.id").String(n.OwnerID.String()),
+		attribute.Key("notification.key").String(n.Key),
+	}
+
+	ctx, span := observability.Span(parentCtx, "mongodb", "ClaimFailedNotifiers", spanTags)
+	defer span.End()
+
+	dbClient, err := services.InitDatabase()
+	if err != nil {
+		return []string{}, err
+	}
+
+	col := dbClient.Database(mongodbDatabase).Collection(mongodbNotificationEventsCollection)
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var event struct {
+		FailedNotifiers []string `bson:"failed_notifiers"`
+	}
+	err = col.FindOneAndUpdate(ctx,
+		bson.M{"owner_id": n.OwnerID, "key": n.Key, "failed_notifiers.0": bson.M{"$exists": true}},
+		bson.M{"$unset": bson.M{"failed_notifiers": ""}},
+		options.FindOneAndUpdate().SetReturnDocument(options.Before),
+	).Decode(&event)
+	if err == mongo.ErrNoDocuments {
+		return []string{}, nil
+	}
+	if err != nil {
+		return []string{}, err
+	}
+
+	return event.FailedNotifiers, nil
+}
+
+// CreateNotification stores a notification at the owner's in-app inbox
+func CreateNotification(parentCtx context.Context, n Notification) (id string, err error) {
+	spanTags := []attribute.KeyValue{
+		attribute.Key("notification.owner.id").String(n.OwnerID.String()),
+		attribute.Key("notification.event").String(n.Event),
+	}
+
+	ctx, span := observability.Span(parentCtx, "mongodb", "CreateNotification", spanTags)
+	defer span.End()
+
+	dbClient, err := services.InitDatabase()
+	if err != nil {
+		return "", err
+	}
+
+	col := dbClient.Database(mongodbDatabase).Collection(mongodbNotificationsCollection)
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+
+	_, err = col.Indexes().CreateOne(
+		ctx,
+		mongo.IndexModel{
+			Keys: bsonx.Doc{{Key: "owner_id", Value: bsonx.Int32(1)}, {Key: "read", Value: bsonx.Int32(1)}, {Key: "created_at", Value: bsonx.Int32(-1)}},
+		},
+	)
+
+	n.ID = primitive.NilObjectID
+	n.Read = false
+	if n.CreatedAt == 0 {
+		n.CreatedAt = primitive.NewDateTimeFromTime(time.Now())
+	}
+
+	r, err := col.InsertOne(ctx, n)
+	if err != nil {
+		cancel()
+		return "", err
+	}
+
+	defer cancel()
+
+	log.Infoln("created notification", n.Key, "to", n.OwnerID.Hex())
+	return r.InsertedID.(primitive.ObjectID).Hex(), nil
+}
+
+// GetNotifications will return the most recent notifications from an owner_id inbox
+func GetNotifications(parentCtx context.Context, ownerID string, unreadOnly bool) (notifications []Notification, err error) {
+	spanTags := []attribute.KeyValue{
+		attribute.Key("notification.owner.id").String(ownerID),
+	}
+
+	ctx, span := observability.Span(parentCtx, "mongodb", "GetNotifications", spanTags)
+	defer span.End()
+
+	oid, err := primitive.ObjectIDFromHex(ownerID)
+	if err != nil {
+		return []Notification{}, err
+	}
+
+	filter := bson.M{"owner_id": oid}
+	if unreadOnly {
+		filter["read"] = false
+	}
+
+	dbClient, err := services.InitDatabase()
+	if err != nil {
+		return []Notification{}, err
+	}
+
+	col := dbClient.Database(mongodbDatabase).Collection(mongodbNotificationsCollection)
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}).SetLimit(100)
+	cursor, err := col.Find(ctx, filter, opts)
+	if err != nil {
+		cancel()
+		return []Notification{}, err
+	}
+
+	defer cursor.Close(ctx)
+	defer cancel()
+
+	for cursor.Next(ctx) {
+		var notification Notification
+		cursor.Decode(&notification)
+		notifications = append(notifications, notification)
+	}
+
+	if err := cursor.Err(); err != nil {
+		cancel()
+		return []Notification{}, err
+	}
+
+	return notifications, nil
+}
+
+// MarkNotificationRead will mark a notification from the inbox as read
+func MarkNotificationRead(parentCtx context.Context, id string) (err error) {
+	spanTags := []attribute.KeyValue{
+		attribute.Key("notification.id").String(id),
+	}
+
+	ctx, span := observability.Span(parentCtx, "mongodb", "MarkNotificationRead", spanTags)
+	defer span.End()
+
+	nid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+
+	dbClient, err := services.InitDatabase()
+	if err != nil {
+		return err
+	}
+
+	col := dbClient.Database(mongodbDatabase).Collection(mongodbNotificationsCollection)
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+
+	result, err := col.UpdateOne(ctx, bson.M{"_id": nid}, bson.M{"$set": bson.M{"read": true}})
+	if err != nil {
+		cancel()
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		cancel()
+		return errors.New("non existent notification")
+	}
+
+	defer cancel()
+	return nil
+}
@@ -0,0 +1,522 @@
+package models
+
+import (
+	"budget-tracker-api/observability"
+	"budget-tracker-api/services"
+	"context"
+	"errors"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/x/bsonx"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// ValidateRecurringRule will validate if a recurring rule can be scheduled
+func ValidateRecurringRule(r RecurringRule) (err error) {
+	switch r.Kind {
+	case "spend":
+		if r.Spend.Cost <= 0 {
+			return errors.New("spend rules must have a positive 'cost'")
+		}
+	case "income":
+		errs := ValidateIncomeEntry("income.", r.Income)
+		if len(errs) > 0 {
+			return errors.New(errs[0].Field + ": " + errs[0].Message)
+		}
+	default:
+		return errors.New("invalid kind '" + r.Kind + "'")
+	}
+
+	switch r.Frequency {
+	case "weekly":
+	case "monthly", "yearly":
+		if r.DayOfMonth < 0 || r.DayOfMonth > 31 {
+			return errors.New("invalid day of month")
+		}
+	default:
+		return errors.New("invalid frequency '" + r.Frequency + "'")
+	}
+
+	if r.StartDate.IsZero() {
+		return errors.New("missing 'start_date'")
+	}
+
+	if !r.EndDate.IsZero() && r.EndDate.Before(r.StartDate) {
+		return errors.New("'end_date' must be after 'start_date'")
+	}
+
+	if r.Timezone != "" {
+		_, err = time.LoadLocation(r.Timezone)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// occurrenceAt will return the n-th (starting at 0) occurrence of a recurring rule
+func occurrenceAt(r RecurringRule, start time.Time, n int) time.Time {
+	switch r.Frequency {
+	case "weekly":
+		return start.AddDate(0, 0, 7*n)
+	case "yearly":
+		return dayOfMonth(start.Year()+n, start.Month(), r.DayOfMonth, start)
+	default:
+		return dayOfMonth(start.Year(), start.Month()+time.Month(n), r.DayOfMonth, start)
+	}
+}
+
+// dayOfMonth will return the given day of a month (or its last day for shorter months) keeping the
+// clock and location from a reference time. A zero day keeps the day from the reference time
+func dayOfMonth(year int, month time.Month, day int, ref time.Time) time.Time {
+	if day == 0 {
+		day = ref.Day()
+	}
+
+	first := time.Date(year, month, 1, ref.Hour(), ref.Minute(), ref.Second(), 0, ref.Location())
+	lastDay := first.AddDate(0, 1, -1).Day()
+	if day > lastDay {
+		day = lastDay
+	}
+
+	return first.AddDate(0, 0, day-1)
+}
+
+// RuleOccurrences will return all occurrences of a recurring rule after `from` up to `to` (inclusive)
+func RuleOccurrences(r RecurringRule, from time.Time, to time.Time) (occurrences []time.Time, err error) {
+	loc := time.UTC
+	if r.Timezone != "" {
+		loc, err = time.LoadLocation(r.Timezone)
+		if err != nil {
+			return []time.Time{}, err
+		}
+	}
+
+	if !r.EndDate.IsZero() && r.EndDate.Before(to) {
+		to = r.EndDate
+	}
+
+	start := r.StartDate.In(loc)
+	for n := 0; ; n++ {
+		occurrence := occurrenceAt(r, start, n)
+		if occurrence.After(to) {
+			break
+		}
+
+		if occurrence.Before(start) || !occurrence.After(from) {
+			continue
+		}
+
+		occurrences = append(occurrences, occurrence)
+	}
+
+	return occurrences, nil
+}
+
+// CreateRecurringRule creates a recurring rule for a given owner_id
+func CreateRecurringRule(parentCtx context.Context, r RecurringRule) (id string, err error) {
+	spanTags := []attribute.KeyValue{
+		attribute.Key("recurring.owner.id").String(r.OwnerID.String()),
+	}
+
+	ctx, span := observability.Span(parentCtx, "mongodb", "CreateRecurringRule", spanTags)
+	defer span.End()
+
+	dbClient, err := services.InitDatabase()
+	if err != nil {
+		return "", err
+	}
+
+	col := dbClient.Database(mongodbDatabase).Collection(mongodbRecurringCollection)
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+
+	_, err = col.Indexes().CreateOne(
+		ctx,
+		mongo.IndexModel{
+			Keys: bsonx.Doc{{Key: "owner_id", Value: bsonx.Int32(1)}, {Key: "paused", Value: bsonx.Int32(1)}},
+		},
+	)
+
+	// adding timestamp to creationDate
+	t := time.Now()
+	r.CreatedAt = primitive.NewDateTimeFromTime(t)
+	r.UpdatedAt = primitive.NewDateTimeFromTime(t)
+	r.Spend.OwnerID = r.OwnerID
+
+	result, err := col.InsertOne(ctx, r)
+	if err != nil {
+		cancel()
+		return "", err
+	}
+
+	span.SetAttributes(attribute.Key("recurring.id").String(result.InsertedID.(primitive.ObjectID).Hex()))
+	defer cancel()
+
+	observability.Metrics.Recurring.RulesCreated.Inc()
+	log.Infoln("created recurring rule", result.InsertedID.(primitive.ObjectID).Hex())
+	return result.InsertedID.(primitive.ObjectID).Hex(), nil
+}
+
+// GetRecurringRule will return a single recurring rule based on its ID
+func GetRecurringRule(parentCtx context.Context, id string) (rule *RecurringRule, err error) {
+	spanTags := []attribute.KeyValue{
+		attribute.Key("recurring.id").String(id),
+	}
+
+	ctx, span := observability.Span(parentCtx, "mongodb", "GetRecurringRule", spanTags)
+	defer span.End()
+
+	rid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return &RecurringRule{}, err
+	}
+
+	dbClient, err := services.InitDatabase()
+	if err != nil {
+		return &RecurringRule{}, err
+	}
+
+	col := dbClient.Database(mongodbDatabase).Collection(mongodbRecurringCollection)
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+
+	err = col.FindOne(ctx, bson.M{"_id": rid}).Decode(&rule)
+	if err != nil {
+		cancel()
+		return &RecurringRule{}, err
+	}
+
+	defer cancel()
+	return rule, nil
+}
+
+// GetRecurringRules will return all recurring rules from an owner_id
+func GetRecurringRules(parentCtx context.Context, ownerID string) (rules []RecurringRule, err error) {
+	spanTags := []attribute.KeyValue{
+		attribute.Key("recurring.owner.id").String(ownerID),
+	}
+
+	ctx, span := observability.Span(parentCtx, "mongodb", "GetRecurringRules", spanTags)
+	defer span.End()
+
+	oid, err := primitive.ObjectIDFromHex(ownerID)
+	if err != nil {
+		return []RecurringRule{}, err
+	}
+
+	return findRecurringRules(ctx, bson.M{"owner_id": oid})
+}
+
+// findRecurringRules will return all recurring rules matching a given filter
+func findRecurringRules(ctx context.Context, filter bson.M) (rules []RecurringRule, err error) {
+	dbClient, err := services.InitDatabase()
+	if err != nil {
+		return []RecurringRule{}, err
+	}
+
+	col := dbClient.Database(mongodbDatabase).Collection(mongodbRecurringCollection)
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	cursor, err := col.Find(ctx, filter)
+	if err != nil {
+		cancel()
+		return []RecurringRule{}, err
+	}
+
+	defer cursor.Close(ctx)
+	defer cancel()
+
+	for cursor.Next(ctx) {
+		var rule RecurringRule
+		cursor.Decode(&rule)
+		rules = append(rules, rule)
+	}
+
+	if err := cursor.Err(); err != nil {
+		cancel()
+		return []RecurringRule{}, err
+	}
+
+	return rules, nil
+}
+
+// UpdateRecurringRule will update the given attributes of a recurring rule. Occurrences already
+// materialized are kept as they are
+func UpdateRecurringRule(parentCtx context.Context, id string, u RecurringRuleUpdate) (err error) {
+	spanTags := []attribute.KeyValue{
+		attribute.Key("recurring.id").String(id),
+	}
+
+	ctx, span := observability.Span(parentCtx, "mongodb", "UpdateRecurringRule", spanTags)
+	defer span.End()
+
+	rule, err := GetRecurringRule(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if u.Frequency != nil {
+		rule.Frequency = *u.Frequency
+	}
+	if u.DayOfMonth != nil {
+		rule.DayOfMonth = *u.DayOfMonth
+	}
+	if u.EndDate != nil {
+		rule.EndDate = *u.EndDate
+	}
+	if u.Timezone != nil {
+		rule.Timezone = *u.Timezone
+	}
+	if u.Spend != nil {
+		rule.Spend = *u.Spend
+		rule.Spend.OwnerID = rule.OwnerID
+	}
+	if u.Income != nil {
+		rule.Income = *u.Income
+	}
+
+	err = ValidateRecurringRule(*rule)
+	if err != nil {
+		return err
+	}
+
+	return setRecurringRule(ctx, rule.ID, bson.M{
+		"frequency":    rule.Frequency,
+		"day_of_month": rule.DayOfMonth,
+		"end_date":     rule.EndDate,
+		"timezone":     rule.Timezone,
+		"spend":        rule.Spend,
+		"income":       rule.Income,
+	})
+}
+
+// PauseRecurringRule will pause or resume a recurring rule. Occurrences skipped while paused
+// won't be materialized once the rule is resumed
+func PauseRecurringRule(parentCtx context.Context, id string, paused bool) (err error) {
+	spanTags := []attribute.KeyValue{
+		attribute.Key("recurring.id").String(id),
+		attribute.Key("recurring.paused").Bool(paused),
+	}
+
+	ctx, span := observability.Span(parentCtx, "mongodb", "PauseRecurringRule", spanTags)
+	defer span.End()
+
+	rid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+
+	fields := bson.M{"paused": paused}
+	if !paused {
+		fields["last_run"] = time.Now()
+	}
+
+	return setRecurringRule(ctx, rid, fields)
+}
+
+// setRecurringRule will set the given fields of a recurring rule
+func setRecurringRule(ctx context.Context, id primitive.ObjectID, fields bson.M) (err error) {
+	dbClient, err := services.InitDatabase()
+	if err != nil {
+		return err
+	}
+
+	fields["updated_at"] = primitive.NewDateTimeFromTime(time.Now())
+
+	col := dbClient.Database(mongodbDatabase).Collection(mongodbRecurringCollection)
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+
+	result, err := col.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": fields})
+	if err != nil {
+		cancel()
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		cancel()
+		return errors.New("non existent recurring rule")
+	}
+
+	defer cancel()
+
+	log.Infoln("updated recurring rule", id.Hex())
+	return nil
+}
+
+// registerOccurrence will register that a recurring rule occurrence was materialized. It returns false
+// when the occurrence was already registered before
+func registerOccurrence(ctx context.Context, ruleID primitive.ObjectID, date time.Time) (registered bool, err error) {
+	dbClient, err := services.InitDatabase()
+	if err != nil {
+		return false, err
+	}
+
+	col := dbClient.Database(mongodbDatabase).Collection(mongodbRecurringOccurrencesCollection)
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	_, err = col.Indexes().CreateOne(
+		ctx,
+		mongo.IndexModel{
+			Keys:    bsonx.Doc{{Key: "rule_id", Value: bsonx.Int32(1)}, {Key: "date", Value: bsonx.Int32(1)}},
+			Options: options.Index().SetUnique(true),
+		},
+	)
+
+	_, err = col.InsertOne(ctx, bson.M{
+		"rule_id":    ruleID,
+		"date":       date,
+		"created_at": primitive.NewDateTimeFromTime(time.Now()),
+	})
+	if mongo.IsDuplicateKeyError(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// unregisterOccurrence will remove an occurrence registry so it can be materialized again
+func unregisterOccurrence(ctx context.Context, ruleID primitive.ObjectID, date time.Time) (err error) {
+	dbClient, err := services.InitDatabase()
+	if err != nil {
+		return err
+	}
+
+	col := dbClient.Database(mongodbDatabase).Collection(mongodbRecurringOccurrencesCollection)
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	_, err = col.DeleteOne(ctx, bson.M{"rule_id": ruleID, "date": date})
+	return err
+}
+
+// materializeOccurrence will create the spend or income of a single recurring rule occurrence
+func materializeOccurrence(ctx context.Context, r RecurringRule, date time.Time) (err error) {
+	if r.Kind == "income" {
+		income := r.Income
+		income.ReceivedAt = date
+		income.Recurring = true
+
+		// rules created before income sources existed
+		if income.Source == "" {
+			income.Source = "other"
+		}
+
+		_, err = AddIncomeToBalance(ctx, r.OwnerID, int64(date.Month()), int64(date.Year()), income)
+		return err
+	}
+
+	s := r.Spend
+	s.ID = primitive.NilObjectID
+	s.OwnerID = r.OwnerID
+	s.RecurringRuleID = r.ID
+	s.Date = date
+	s.Timezone = r.Timezone
+
+	s.Month, s.Year, err = SpendPeriod(s)
+	if err != nil {
+		return err
+	}
+
+	closed, err := IsBalanceClosed(ctx, s.OwnerID, s.Month, s.Year)
+	if err != nil {
+		return err
+	}
+
+	if closed {
+		return ErrBalanceClosed
+	}
+
+	_, _, errs, err := RegisterSpend(ctx, s)
+	if err != nil {
+		return err
+	}
+
+	if len(errs) > 0 {
+		return JoinFieldErrors(errs)
+	}
+
+	return nil
+}
+
+// MaterializeRecurringRules will create spends and incomes for every active recurring rule occurrence
+// up to a given time. Occurrences are registered so running it more than once won't duplicate them,
+// while failing ones are logged and retried on the next run
+func MaterializeRecurringRules(parentCtx context.Context, until time.Time) (materialized int, err error) {
+	ctx, span := observability.Span(parentCtx, "mongodb", "MaterializeRecurringRules", []attribute.KeyValue{})
+	defer span.End()
+
+	rules, err := findRecurringRules(ctx, bson.M{"paused": false})
+	if err != nil {
+		return 0, err
+	}
+
+	for _, rule := range rules {
+		from := rule.LastRun
+		if from.IsZero() {
+			// first run must include an occurrence at the start date itself
+			from = rule.StartDate.Add(-time.Nanosecond)
+		}
+
+		occurrences, err := RuleOccurrences(rule, from, until)
+		if err != nil {
+			log.Errorln("could not schedule recurring rule", rule.ID.Hex(), ":", err)
+			continue
+		}
+
+		// rules with failed occurrences keep their last run, so these are retried on the next run
+		failed := false
+		for _, occurrence := range occurrences {
+			registered, err := registerOccurrence(ctx, rule.ID, occurrence)
+			if err != nil {
+				log.Errorln("could not register recurring rule", rule.ID.Hex(), "occurrence at", occurrence, ":", err)
+				failed = true
+				continue
+			}
+
+			if !registered {
+				continue
+			}
+
+			err = materializeOccurrence(ctx, rule, occurrence)
+			if err == ErrBalanceClosed {
+				// occurrences from closed months are kept registered so they are skipped for good
+				log.Warnln("skipped recurring rule", rule.ID.Hex(), "occurrence at", occurrence, "from a closed balance")
+				continue
+			}
+
+			if err != nil {
+				log.Errorln("could not materialize recurring rule", rule.ID.Hex(), "occurrence at", occurrence, ":", err)
+				failed = true
+
+				err = unregisterOccurrence(ctx, rule.ID, occurrence)
+				if err != nil {
+					log.Errorln("could not unregister recurring rule", rule.ID.Hex(), "occurrence at", occurrence, ":", err)
+				}
+				continue
+			}
+
+			materialized++
+			observability.Metrics.Recurring.OccurrencesMaterialized.Inc()
+		}
+
+		if failed {
+			continue
+		}
+
+		err = setRecurringRule(ctx, rule.ID, bson.M{"last_run": until})
+		if err != nil {
+			log.Errorln("could not update recurring rule", rule.ID.Hex(), "last run:", err)
+		}
+	}
+
+	span.SetAttributes(attribute.Key("recurring.materialized").Int(materialized))
+	return materialized, nil
+}
@@ -0,0 +1,106 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRuleOccurrences(t *testing.T) {
+	saoPaulo, err := time.LoadLocation("America/Sao_Paulo")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	date := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 9, 0, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name string
+		rule RecurringRule
+		from time.Time
+		to   time.Time
+		want []time.Time
+	}{
+		{
+			name: "monthly keeps the last day of shorter months",
+			rule: RecurringRule{Frequency: "monthly", StartDate: date(2024, time.January, 31)},
+			from: date(2024, time.January, 31).Add(-time.Nanosecond),
+			to:   date(2024, time.April, 30),
+			want: []time.Time{date(2024, time.January, 31), date(2024, time.February, 29), date(2024, time.March, 31), date(2024, time.April, 30)},
+		},
+		{
+			name: "monthly skips a day of month before the start date",
+			rule: RecurringRule{Frequency: "monthly", DayOfMonth: 10, StartDate: date(2024, time.January, 15)},
+			from: date(2024, time.January, 15).Add(-time.Nanosecond),
+			to:   date(2024, time.March, 31),
+			want: []time.Time{date(2024, time.February, 10), date(2024, time.March, 10)},
+		},
+		{
+			name: "monthly excludes the occurrence at from",
+			rule: RecurringRule{Frequency: "monthly", StartDate: date(2024, time.January, 10)},
+			from: date(2024, time.February, 10),
+			to:   date(2024, time.April, 10),
+			want: []time.Time{date(2024, time.March, 10), date(2024, time.April, 10)},
+		},
+		{
+			name: "monthly stops at the end date",
+			rule: RecurringRule{Frequency: "monthly", StartDate: date(2024, time.January, 10), EndDate: date(2024, time.March, 10)},
+			from: date(2024, time.January, 10).Add(-time.Nanosecond),
+			to:   date(2024, time.December, 31),
+			want: []time.Time{date(2024, time.January, 10), date(2024, time.February, 10), date(2024, time.March, 10)},
+		},
+		{
+			name: "weekly",
+			rule: RecurringRule{Frequency: "weekly", StartDate: date(2024, time.January, 1)},
+			from: date(2024, time.January, 1).Add(-time.Nanosecond),
+			to:   date(2024, time.January, 22),
+			want: []time.Time{date(2024, time.January, 1), date(2024, time.January, 8), date(2024, time.January, 15), date(2024, time.January, 22)},
+		},
+		{
+			name: "yearly from a leap day",
+			rule: RecurringRule{Frequency: "yearly", StartDate: date(2024, time.February, 29)},
+			from: date(2024, time.February, 29).Add(-time.Nanosecond),
+			to:   date(2028, time.March, 1),
+			want: []time.Time{date(2024, time.February, 29), date(2025, time.February, 28), date(2026, time.February, 28), date(2027, time.February, 28), date(2028, time.February, 29)},
+		},
+		{
+			name: "monthly in the rule timezone",
+			rule: RecurringRule{Frequency: "monthly", StartDate: time.Date(2024, time.January, 31, 22, 0, 0, 0, saoPaulo), Timezone: "America/Sao_Paulo"},
+			from: time.Date(2024, time.February, 1, 0, 0, 0, 0, time.UTC),
+			to:   time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC),
+			want: []time.Time{time.Date(2024, time.January, 31, 22, 0, 0, 0, saoPaulo)},
+		},
+		{
+			name: "nothing to schedule",
+			rule: RecurringRule{Frequency: "monthly", StartDate: date(2024, time.January, 10)},
+			from: date(2024, time.January, 10),
+			to:   date(2024, time.February, 9),
+			want: []time.Time{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := RuleOccurrences(tt.rule, tt.from, tt.to)
+			if err != nil {
+				t.Fatalf("RuleOccurrences() error = %v", err)
+			}
+
+			if len(got) != len(tt.want) {
+				t.Fatalf("RuleOccurrences() = %v, want %v", got, tt.want)
+			}
+
+			for i := range got {
+				if !got[i].Equal(tt.want[i]) {
+					t.Errorf("RuleOccurrences()[%d] = %s, want %s", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+
+	_, err = RuleOccurrences(RecurringRule{Frequency: "monthly", StartDate: date(2024, time.January, 10), Timezone: "Nowhere/Invalid"}, date(2024, time.January, 1), date(2024, time.February, 1))
+	if err == nil {
+		t.Error("RuleOccurrences() with an invalid timezone should fail")
+	}
+}
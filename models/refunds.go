@@ -0,0 +1,537 @@
+package models
+
+import (
+	"budget-tracker-api/observability"
+	"budget-tracker-api/services"
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/x/bsonx"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// ErrRefundReceived is returned when changing a refund which was already booked to a balance
+var ErrRefundReceived = errors.New("refund was already received")
+
+// ErrSpendRefunded is returned when deleting a spend which has refunds
+var ErrSpendRefunded = errors.New("spend has refunds")
+
+// ValidateRefund will validate a refund against the spend it references, which can't be refunded
+// more than its cost
+func ValidateRefund(parentCtx context.Context, r Refund) (errs []FieldError, err error) {
+	errs = []FieldError{}
+
+	if r.Kind != "refund" && r.Kind != "reimbursement" {
+		errs = append(errs, FieldError{Field: "kind", Message: "kind must be one of 'refund' or 'reimbursement'"})
+	}
+
+	if r.Status != "" && r.Status != "pending" && r.Status != "received" {
+		errs = append(errs, FieldError{Field: "status", Message: "status must be one of 'pending' or 'received'"})
+	}
+
+	if r.Amount <= 0 {
+		errs = append(errs, FieldError{Field: "amount", Message: "amount must be greater than zero"})
+		return errs, nil
+	}
+
+	if r.SpendID.IsZero() {
+		errs = append(errs, FieldError{Field: "spend_id", Message: "missing spend ID"})
+		return errs, nil
+	}
+
+	spend, err := GetSpend(parentCtx, r.SpendID.Hex())
+	if err == mongo.ErrNoDocuments {
+		errs = append(errs, FieldError{Field: "spend_id", Message: "non existent spend '" + r.SpendID.Hex() + "'"})
+		return errs, nil
+	}
+	if err != nil {
+		return []FieldError{}, err
+	}
+
+	refunded, err := refundedAmount(parentCtx, spend.ID)
+	if err != nil {
+		return []FieldError{}, err
+	}
+
+	if roundCents(refunded+r.Amount) > roundCents(spend.Cost) {
+		errs = append(errs, FieldError{Field: "amount", Message: "refunds can't exceed the spend cost of " + strconv.FormatFloat(spend.Cost, 'f', 2, 64) + ", already refunded " + strconv.FormatFloat(refunded, 'f', 2, 64)})
+	}
+
+	return errs, nil
+}
+
+// refundPeriod will return the balance month and year a refund is booked to. Merchant refunds of credit
+// spends are credited at the card statement of the refund date, everything else at the refund date month
+func refundPeriod(ctx context.Context, r Refund, spend Spend) (month int64, year int64, err error) {
+	if r.Kind == "refund" && spend.PaymentMethod.Credit && !spend.PaymentMethod.CardID.IsZero() {
+		card, err := GetCard(ctx, spend.PaymentMethod.CardID.Hex())
+		if err != nil {
+			return 0, 0, err
+		}
+
+		loc := time.UTC
+		if spend.Timezone != "" {
+			loc, err = time.LoadLocation(spend.Timezone)
+			if err != nil {
+				return 0, 0, err
+			}
+		}
+
+		t := statementDate(r.Date.In(loc), *card, 0)
+		return int64(t.Month()), int64(t.Year()), nil
+	}
+
+	return SpendPeriod(Spend{Date: r.Date, Timezone: spend.Timezone})
+}
+
+// bookRefund will credit a received refund to the balance of its month, reducing the outcome of the
+// refunded spend type and raising the spendable amount, creating the balance when it doesn't exist yet
+func bookRefund(parentCtx context.Context, r Refund) (err error) {
+	spanTags := []attribute.KeyValue{
+		attribute.Key("balance.owner.id").String(r.OwnerID.String()),
+		attribute.Key("refund.id").String(r.ID.Hex()),
+	}
+
+	ctx, span := observability.Span(parentCtx, "mongodb", "bookRefund", spanTags)
+	defer span.End()
+
+	dbClient, err := services.InitDatabase()
+	if err != nil {
+		return err
+	}
+
+	outcomeField := "outcome.dynamic"
+	if r.SpendType == "fixed" {
+		outcomeField = "outcome.fixed"
+	}
+
+	col := dbClient.Database(mongodbDatabase).Collection(mongodbBalanceCollection)
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+
+	_, err = col.Indexes().CreateOne(ctx, balancesPeriodIndex)
+
+	// card refunds are credited to upcoming statements, whose balances may not exist yet. A closed
+	// balance isn't matched, so the upsert hits the unique index instead
+	t := primitive.NewDateTimeFromTime(time.Now())
+	_, err = col.UpdateOne(ctx,
+		bson.M{"owner_id": r.OwnerID, "month": r.Month, "year": r.Year, "closed": bson.M{"$ne": true}},
+		bson.M{
+			"$inc": bson.M{
+				outcomeField:       -r.Amount,
+				"spendable_amount": r.Amount,
+			},
+			"$set":         bson.M{"updated_at": t},
+			"$setOnInsert": emptyBalance(t, bson.M{"historic": bson.A{}, "incomes": bson.A{}, "income": Income{}}),
+		},
+		options.Update().SetUpsert(true),
+	)
+	if mongo.IsDuplicateKeyError(err) {
+		cancel()
+		return ErrBalanceClosed
+	}
+
+	if err != nil {
+		cancel()
+		return err
+	}
+
+	defer cancel()
+	return nil
+}
+
+// CreateRefund creates a refund for a spend. Merchant refunds are received right away, while
+// reimbursements stay pending until received unless created as received. Refunds are stored as
+// pending and then received, so a refund failing to be booked is deleted instead of being left
+// credited without a record
+func CreateRefund(parentCtx context.Context, r Refund) (id string, err error) {
+	spanTags := []attribute.KeyValue{
+		attribute.Key("spend.id").String(r.SpendID.Hex()),
+	}
+
+	ctx, span := observability.Span(parentCtx, "mongodb", "CreateRefund", spanTags)
+	defer span.End()
+
+	spend, err := GetSpend(ctx, r.SpendID.Hex())
+	if err != nil {
+		return "", err
+	}
+
+	receive := r.Kind == "refund" || r.Status == "received"
+	date := r.Date
+
+	r.ID = primitive.NewObjectID()
+	r.OwnerID = spend.OwnerID
+	r.SpendType = spend.Type
+	r.PaymentMethod = spend.PaymentMethod
+	r.Status = "pending"
+	r.Date = time.Time{}
+	r.Month = 0
+	r.Year = 0
+
+	dbClient, err := services.InitDatabase()
+	if err != nil {
+		return "", err
+	}
+
+	col := dbClient.Database(mongodbDatabase).Collection(mongodbRefundsCollection)
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+
+	_, err = col.Indexes().CreateMany(
+		ctx,
+		[]mongo.IndexModel{
+			{Keys: bsonx.Doc{{Key: "spend_id", Value: bsonx.Int32(1)}}},
+			{Keys: bsonx.Doc{{Key: "owner_id", Value: bsonx.Int32(1)}, {Key: "status", Value: bsonx.Int32(1)}, {Key: "expected_at", Value: bsonx.Int32(1)}}},
+			{Keys: bsonx.Doc{{Key: "owner_id", Value: bsonx.Int32(1)}, {Key: "year", Value: bsonx.Int32(1)}, {Key: "month", Value: bsonx.Int32(1)}}},
+		},
+	)
+
+	r.CreatedAt = primitive.NewDateTimeFromTime(time.Now())
+	_, err = col.InsertOne(ctx, r)
+	if err != nil {
+		cancel()
+		return "", err
+	}
+
+	defer cancel()
+
+	if receive {
+		_, err = ReceiveRefund(ctx, r.ID.Hex(), date)
+		if err != nil {
+			_, derr := col.DeleteOne(ctx, bson.M{"_id": r.ID})
+			if derr != nil {
+				log.Errorln("could not delete unbooked", r.Kind, r.ID.Hex(), ":", derr)
+			}
+			return "", err
+		}
+	}
+
+	log.Infoln("created", r.Kind, r.ID.Hex(), "for spend", spend.ID.Hex())
+	return r.ID.Hex(), nil
+}
+
+// GetRefund will return a refund given an ID
+func GetRefund(parentCtx context.Context, id string) (refund *Refund, err error) {
+	spanTags := []attribute.KeyValue{
+		attribute.Key("refund.id").String(id),
+	}
+
+	ctx, span := observability.Span(parentCtx, "mongodb", "GetRefund", spanTags)
+	defer span.End()
+
+	rid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return &Refund{}, err
+	}
+
+	dbClient, err := services.InitDatabase()
+	if err != nil {
+		return &Refund{}, err
+	}
+
+	col := dbClient.Database(mongodbDatabase).Collection(mongodbRefundsCollection)
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+
+	err = col.FindOne(ctx, bson.M{"_id": rid}).Decode(&refund)
+	if err != nil {
+		cancel()
+		return &Refund{}, err
+	}
+
+	defer cancel()
+	return refund, nil
+}
+
+// ReceiveRefund will mark a pending refund as received at a given date (now when zero),
+// crediting it to the balance of that month
+func ReceiveRefund(parentCtx context.Context, id string, date time.Time) (refund *Refund, err error) {
+	spanTags := []attribute.KeyValue{
+		attribute.Key("refund.id").String(id),
+	}
+
+	ctx, span := observability.Span(parentCtx, "mongodb", "ReceiveRefund", spanTags)
+	defer span.End()
+
+	refund, err = GetRefund(ctx, id)
+	if err != nil {
+		return &Refund{}, err
+	}
+
+	if refund.Status == "received" {
+		return &Refund{}, ErrRefundReceived
+	}
+
+	spend, err := GetSpend(ctx, refund.SpendID.Hex())
+	if err != nil {
+		return &Refund{}, err
+	}
+
+	if date.IsZero() {
+		date = time.Now()
+	}
+
+	refund.Date = date
+	refund.Month, refund.Year, err = refundPeriod(ctx, *refund, *spend)
+	if err != nil {
+		return &Refund{}, err
+	}
+
+	dbClient, err := services.InitDatabase()
+	if err != nil {
+		return &Refund{}, err
+	}
+
+	col := dbClient.Database(mongodbDatabase).Collection(mongodbRefundsCollection)
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	// the status is flipped before booking so concurrent requests can't credit it twice
+	result, err := col.UpdateOne(ctx,
+		bson.M{"_id": refund.ID, "status": "pending"},
+		bson.M{"$set": bson.M{
+			"status": "received",
+			"date":   refund.Date,
+			"month":  refund.Month,
+			"year":   refund.Year,
+		}},
+	)
+	if err != nil {
+		return &Refund{}, err
+	}
+
+	if result.ModifiedCount == 0 {
+		return &Refund{}, ErrRefundReceived
+	}
+
+	err = bookRefund(ctx, *refund)
+	if err != nil {
+		col.UpdateOne(ctx,
+			bson.M{"_id": refund.ID},
+			bson.M{
+				"$set":   bson.M{"status": "pending"},
+				"$unset": bson.M{"date": "", "month": "", "year": ""},
+			},
+		)
+		return &Refund{}, err
+	}
+
+	refund.Status = "received"
+	log.Infoln("received", refund.Kind, id)
+	return refund, nil
+}
+
+// DeleteRefund deletes a pending reimbursement given an ID. Received refunds were already booked to
+// a balance and can't be deleted
+func DeleteRefund(parentCtx context.Context, id string) (err error) {
+	spanTags := []attribute.KeyValue{
+		attribute.Key("refund.id").String(id),
+	}
+
+	ctx, span := observability.Span(parentCtx, "mongodb", "DeleteRefund", spanTags)
+	defer span.End()
+
+	refund, err := GetRefund(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if refund.Status == "received" {
+		return ErrRefundReceived
+	}
+
+	dbClient, err := services.InitDatabase()
+	if err != nil {
+		return err
+	}
+
+	col := dbClient.Database(mongodbDatabase).Collection(mongodbRefundsCollection)
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+
+	result, err := col.DeleteOne(ctx, bson.M{"_id": refund.ID, "status": "pending"})
+	if err != nil {
+		cancel()
+		return err
+	}
+
+	if result.DeletedCount == 0 {
+		cancel()
+		return ErrRefundReceived
+	}
+
+	defer cancel()
+
+	log.Infoln("deleted refund", id)
+	return nil
+}
+
+// GetRefunds will return the refunds from an owner_id, optionally filtered by status, kind and spend
+func GetRefunds(ctx context.Context, ownerID string, status string, kind string, spendID string) (refunds []Refund, err error) {
+	oid, err := primitive.ObjectIDFromHex(ownerID)
+	if err != nil {
+		return []Refund{}, err
+	}
+
+	filter := bson.M{"owner_id": oid}
+	if status != "" {
+		filter["status"] = status
+	}
+	if kind != "" {
+		filter["kind"] = kind
+	}
+	if spendID != "" {
+		sid, err := primitive.ObjectIDFromHex(spendID)
+		if err != nil {
+			return []Refund{}, err
+		}
+		filter["spend_id"] = sid
+	}
+
+	return findRefunds(ctx, filter, bson.D{{Key: "created_at", Value: -1}})
+}
+
+// GetReceivables will return the pending reimbursements from an owner_id sorted by expected date,
+// along with their total and how much of it is overdue at a given time
+func GetReceivables(ctx context.Context, ownerID string, now time.Time) (receivables Receivables, err error) {
+	oid, err := primitive.ObjectIDFromHex(ownerID)
+	if err != nil {
+		return Receivables{}, err
+	}
+
+	refunds, err := findRefunds(ctx,
+		bson.M{"owner_id": oid, "status": "pending"},
+		bson.D{{Key: "expected_at", Value: 1}, {Key: "created_at", Value: 1}},
+	)
+	if err != nil {
+		return Receivables{}, err
+	}
+
+	receivables.Refunds = []Refund{}
+	for _, r := range refunds {
+		receivables.Total += r.Amount
+		if !r.ExpectedAt.IsZero() && r.ExpectedAt.Before(now) {
+			receivables.Overdue += r.Amount
+		}
+		receivables.Refunds = append(receivables.Refunds, r)
+	}
+
+	receivables.Count = len(receivables.Refunds)
+	receivables.Total = roundCents(receivables.Total)
+	receivables.Overdue = roundCents(receivables.Overdue)
+	return receivables, nil
+}
+
+// GetMonthRefunds will return all received refunds from an owner_id credited to a given month
+func GetMonthRefunds(ctx context.Context, ownerID primitive.ObjectID, month int64, year int64) (refunds []Refund, err error) {
+	return findRefunds(ctx, bson.M{"owner_id": ownerID, "status": "received", "month": month, "year": year}, bson.D{{Key: "date", Value: 1}})
+}
+
+// refundSpends will turn received refunds into negative spends carrying the categories and tags of
+// the spends they refund, so they can be netted from spend totals
+func refundSpends(ctx context.Context, refunds []Refund) (spends []Spend, err error) {
+	spends = []Spend{}
+	refunded := map[primitive.ObjectID]*Spend{}
+	for _, r := range refunds {
+		spend, ok := refunded[r.SpendID]
+		if !ok {
+			spend, err = GetSpend(ctx, r.SpendID.Hex())
+			if err != nil {
+				return []Spend{}, err
+			}
+			refunded[r.SpendID] = spend
+		}
+
+		spends = append(spends, Spend{
+			ID:            r.ID,
+			OwnerID:       r.OwnerID,
+			Type:          r.SpendType,
+			Description:   spend.Description,
+			Categories:    spend.Categories,
+			CategoryIDs:   spend.CategoryIDs,
+			Tags:          spend.Tags,
+			Cost:          -r.Amount,
+			Date:          r.Date,
+			PaymentMethod: r.PaymentMethod,
+			Month:         r.Month,
+			Year:          r.Year,
+		})
+	}
+
+	return spends, nil
+}
+
+// HasRefunds will tell whether any of the given spends has refunds
+func HasRefunds(parentCtx context.Context, spendIDs []primitive.ObjectID) (has bool, err error) {
+	ctx, span := observability.Span(parentCtx, "mongodb", "HasRefunds", []attribute.KeyValue{})
+	defer span.End()
+
+	dbClient, err := services.InitDatabase()
+	if err != nil {
+		return false, err
+	}
+
+	col := dbClient.Database(mongodbDatabase).Collection(mongodbRefundsCollection)
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	count, err := col.CountDocuments(ctx, bson.M{"spend_id": bson.M{"$in": spendIDs}}, options.Count().SetLimit(1))
+	if err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+// refundedAmount will return how much of a spend was refunded or is pending reimbursement
+func refundedAmount(ctx context.Context, spendID primitive.ObjectID) (amount float64, err error) {
+	refunds, err := findRefunds(ctx, bson.M{"spend_id": spendID}, bson.D{})
+	if err != nil {
+		return 0, err
+	}
+
+	for _, r := range refunds {
+		amount += r.Amount
+	}
+
+	return roundCents(amount), nil
+}
+
+// findRefunds will return all refunds matching a filter with a given sort
+func findRefunds(parentCtx context.Context, filter bson.M, sort bson.D) (refunds []Refund, err error) {
+	ctx, span := observability.Span(parentCtx, "mongodb", "findRefunds", []attribute.KeyValue{})
+	defer span.End()
+
+	dbClient, err := services.InitDatabase()
+	if err != nil {
+		return []Refund{}, err
+	}
+
+	col := dbClient.Database(mongodbDatabase).Collection(mongodbRefundsCollection)
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	cursor, err := col.Find(ctx, filter, options.Find().SetSort(sort))
+	if err != nil {
+		cancel()
+		return []Refund{}, err
+	}
+
+	defer cursor.Close(ctx)
+	defer cancel()
+
+	for cursor.Next(ctx) {
+		var refund Refund
+		cursor.Decode(&refund)
+		refunds = append(refunds, refund)
+	}
+
+	if err := cursor.Err(); err != nil {
+		cancel()
+		return []Refund{}, err
+	}
+
+	return refunds, nil
+}
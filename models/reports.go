@@ -0,0 +1,366 @@
+package models
+
+import (
+	"budget-tracker-api/observability"
+	"budget-tracker-api/services"
+	"context"
+	"errors"
+	"math"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// reportPeriodFormats defines how spend dates are truncated for each report granularity
+var reportPeriodFormats = map[string]string{
+	"day":   "%Y-%m-%d",
+	"week":  "%G-W%V",
+	"month": "%Y-%m",
+	"year":  "%Y",
+}
+
+// reportGroupKey will return the expression used to group spends by a given attribute, along with the
+// filter of the spends that can be grouped by it and the stages unwinding multi-valued attributes. Spends
+// with multiple categories or tags are accounted to each one of them, untagged spends are left out of tag
+// groups. Reimbursements aren't paid back through the spend payment method, so they are left out of
+// payment method and card groups
+func reportGroupKey(groupBy string) (key interface{}, filter bson.M, unwind bson.A, err error) {
+	switch groupBy {
+	case "category":
+		return bson.M{"$ifNull": bson.A{"$category", "uncategorized"}}, bson.M{}, bson.A{
+			bson.M{"$unwind": bson.M{"path": "$category", "preserveNullAndEmptyArrays": true}},
+		}, nil
+	case "payment_method":
+		return bson.M{"$switch": bson.M{
+			"branches": bson.A{
+				bson.M{"case": "$payment_method.credit", "then": "credit"},
+				bson.M{"case": "$payment_method.debit", "then": "debit"},
+				bson.M{"case": "$payment_method.payment_slip", "then": "payment_slip"},
+			},
+			"default": "unknown",
+		}}, bson.M{"refund_kind": bson.M{"$ne": "reimbursement"}}, bson.A{}, nil
+	case "card":
+		return "$payment_method.card_id", bson.M{"payment_method.credit": true, "refund_kind": bson.M{"$ne": "reimbursement"}}, bson.A{}, nil
+	case "type":
+		return "$type", bson.M{}, bson.A{}, nil
+	case "tag":
+		return "$tags", bson.M{}, bson.A{
+			bson.M{"$unwind": "$tags"},
+		}, nil
+	}
+
+	return nil, bson.M{}, bson.A{}, errors.New("invalid 'group_by' value '" + groupBy + "'")
+}
+
+// refundsReportPipeline will return the stages turning the received refunds matching a filter into
+// negative spends, grouped as the refunded spends currently are and dated at when they were received
+func refundsReportPipeline(filter bson.M) bson.A {
+	return bson.A{
+		bson.M{"$match": bson.M{"$and": bson.A{bson.M{"status": "received"}, filter}}},
+		bson.M{"$lookup": bson.M{
+			"from":         mongodbSpendsCollection,
+			"localField":   "spend_id",
+			"foreignField": "_id",
+			"as":           "spend",
+		}},
+		bson.M{"$unwind": "$spend"},
+		bson.M{"$project": bson.M{
+			"owner_id":       1,
+			"date":           1,
+			"month":          1,
+			"year":           1,
+			"cost":           bson.M{"$multiply": bson.A{-1, "$amount"}},
+			"refund_kind":    "$kind",
+			"type":           "$spend.type",
+			"category":       "$spend.category",
+			"category_ids":   "$spend.category_ids",
+			"tags":           "$spend.tags",
+			"payment_method": "$spend.payment_method",
+		}},
+	}
+}
+
+// aggregateReport will aggregate the booked spends matching a filter, net of the refunds received in
+// the meantime, returning their totals grouped by an attribute and optionally by period along with the
+// overall total. Since spends with multiple categories or tags are accounted to each one of them, the
+// overall total is computed before unwinding them and may be less than the rows sum. Counts only
+// include spends
+func aggregateReport(parentCtx context.Context, filter bson.M, groupBy string, granularity string, timezone string) (rows []ReportRow, total float64, err error) {
+	ctx, span := observability.Span(parentCtx, "mongodb", "aggregateReport", []attribute.KeyValue{
+		attribute.Key("report.group_by").String(groupBy),
+	})
+	defer span.End()
+
+	key, groupFilter, unwind, err := reportGroupKey(groupBy)
+	if err != nil {
+		return []ReportRow{}, 0, err
+	}
+
+	// cards are charged the full cost of split spends, not just the parts booked to their owner
+	booked := bookedSpendsFilter()
+	if groupBy == "card" {
+		booked = cardChargesFilter()
+	}
+
+	id := bson.M{"key": key}
+	if granularity != "" {
+		format, ok := reportPeriodFormats[granularity]
+		if !ok {
+			return []ReportRow{}, 0, errors.New("invalid 'granularity' value '" + granularity + "'")
+		}
+
+		if timezone == "" {
+			timezone = "UTC"
+		}
+		id["period"] = bson.M{"$dateToString": bson.M{"format": format, "date": "$date", "timezone": timezone}}
+	}
+
+	rowsPipeline := append(bson.A{}, unwind...)
+	rowsPipeline = append(rowsPipeline,
+		bson.M{"$group": bson.M{
+			"_id":   id,
+			"total": bson.M{"$sum": "$cost"},
+			"count": bson.M{"$sum": bson.M{"$cond": bson.A{bson.M{"$eq": bson.A{bson.M{"$ifNull": bson.A{"$refund_kind", ""}}, ""}}, 1, 0}}},
+		}},
+		bson.M{"$sort": bson.D{{Key: "_id.period", Value: 1}, {Key: "total", Value: -1}}},
+	)
+
+	pipeline := bson.A{
+		bson.M{"$match": bson.M{"$and": bson.A{booked, filter}}},
+		bson.M{"$unionWith": bson.M{"coll": mongodbRefundsCollection, "pipeline": refundsReportPipeline(filter)}},
+		bson.M{"$match": groupFilter},
+		bson.M{"$facet": bson.M{
+			"rows":  rowsPipeline,
+			"total": bson.A{bson.M{"$group": bson.M{"_id": nil, "total": bson.M{"$sum": "$cost"}}}},
+		}},
+	}
+
+	dbClient, err := services.InitDatabase()
+	if err != nil {
+		return []ReportRow{}, 0, err
+	}
+
+	col := dbClient.Database(mongodbDatabase).Collection(mongodbSpendsCollection)
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	cursor, err := col.Aggregate(ctx, pipeline)
+	if err != nil {
+		cancel()
+		return []ReportRow{}, 0, err
+	}
+
+	defer cursor.Close(ctx)
+	defer cancel()
+
+	var result struct {
+		Rows []struct {
+			ID struct {
+				Key    interface{} `bson:"key"`
+				Period string      `bson:"period"`
+			} `bson:"_id"`
+			Total float64 `bson:"total"`
+			Count int64   `bson:"count"`
+		} `bson:"rows"`
+		Total []struct {
+			Total float64 `bson:"total"`
+		} `bson:"total"`
+	}
+
+	if cursor.Next(ctx) {
+		err = cursor.Decode(&result)
+		if err != nil {
+			return []ReportRow{}, 0, err
+		}
+	}
+
+	if err := cursor.Err(); err != nil {
+		return []ReportRow{}, 0, err
+	}
+
+	for _, group := range result.Rows {
+		row := ReportRow{Period: group.ID.Period, Total: roundCents(group.Total), Count: group.Count}
+		switch k := group.ID.Key.(type) {
+		case string:
+			row.Key = k
+		case primitive.ObjectID:
+			row.Key = k.Hex()
+		default:
+			row.Key = "unknown"
+		}
+		row.Label = row.Key
+
+		rows = append(rows, row)
+	}
+
+	if len(result.Total) > 0 {
+		total = roundCents(result.Total[0].Total)
+	}
+
+	return rows, total, nil
+}
+
+// labelCardRows will label report rows grouped by card with the cards aliases
+func labelCardRows(ctx context.Context, ownerID string, rows []ReportRow) (err error) {
+	cards, err := GetCards(ctx, ownerID)
+	if err != nil {
+		return err
+	}
+
+	aliases := map[string]string{}
+	for _, card := range cards {
+		aliases[card.ID.Hex()] = card.Alias
+	}
+
+	for i := range rows {
+		if alias, ok := aliases[rows[i].Key]; ok {
+			rows[i].Label = alias
+		}
+	}
+
+	return nil
+}
+
+// GetReport will return the totals spent by an owner_id grouped by an attribute and optionally by period
+func GetReport(parentCtx context.Context, q ReportQuery) (report Report, err error) {
+	spanTags := []attribute.KeyValue{
+		attribute.Key("report.owner.id").String(q.OwnerID),
+	}
+
+	ctx, span := observability.Span(parentCtx, "mongodb", "GetReport", spanTags)
+	defer span.End()
+
+	oid, err := primitive.ObjectIDFromHex(q.OwnerID)
+	if err != nil {
+		return Report{}, err
+	}
+
+	filter := bson.M{"owner_id": oid}
+
+	dateFilter := bson.M{}
+	if !q.From.IsZero() {
+		dateFilter["$gte"] = q.From
+	}
+	if !q.To.IsZero() {
+		dateFilter["$lte"] = q.To
+	}
+	if len(dateFilter) > 0 {
+		filter["date"] = dateFilter
+	}
+
+	rows, total, err := aggregateReport(ctx, filter, q.GroupBy, q.Granularity, q.Timezone)
+	if err != nil {
+		return Report{}, err
+	}
+
+	if q.GroupBy == "card" {
+		err = labelCardRows(ctx, q.OwnerID, rows)
+		if err != nil {
+			return Report{}, err
+		}
+	}
+
+	report = Report{GroupBy: q.GroupBy, Granularity: q.Granularity, From: q.From, To: q.To, Rows: rows, Total: total}
+	if report.Rows == nil {
+		report.Rows = []ReportRow{}
+	}
+
+	return report, nil
+}
+
+// percentageChange will return how much a value changed from another in percentage, nil when there's
+// no base value to compare to
+func percentageChange(from float64, to float64) *float64 {
+	if from == 0 {
+		return nil
+	}
+
+	change := math.Round((to-from)/from*10000) / 100
+	return &change
+}
+
+// CompareReport will compare the totals spent by an owner_id on a month with the previous month and the
+// same month from the previous year, grouped by an attribute. Months are the ones spends are accounted to
+func CompareReport(parentCtx context.Context, ownerID string, groupBy string, month int64, year int64) (comparison ReportComparison, err error) {
+	spanTags := []attribute.KeyValue{
+		attribute.Key("report.owner.id").String(ownerID),
+	}
+
+	ctx, span := observability.Span(parentCtx, "mongodb", "CompareReport", spanTags)
+	defer span.End()
+
+	oid, err := primitive.ObjectIDFromHex(ownerID)
+	if err != nil {
+		return ReportComparison{}, err
+	}
+
+	prevMonth, prevYear := previousPeriod(month, year)
+	periods := [][2]int64{{month, year}, {prevMonth, prevYear}, {month, year - 1}}
+
+	totals := make([]map[string]float64, len(periods))
+	overall := make([]float64, len(periods))
+	labels := map[string]string{}
+	keys := []string{}
+	for i, p := range periods {
+		rows, total, err := aggregateReport(ctx, bson.M{"owner_id": oid, "month": p[0], "year": p[1]}, groupBy, "", "")
+		if err != nil {
+			return ReportComparison{}, err
+		}
+
+		overall[i] = total
+
+		totals[i] = map[string]float64{}
+		for _, row := range rows {
+			if _, ok := labels[row.Key]; !ok {
+				labels[row.Key] = row.Label
+				keys = append(keys, row.Key)
+			}
+			totals[i][row.Key] = row.Total
+		}
+	}
+
+	comparison = ReportComparison{
+		GroupBy:  groupBy,
+		Month:    month,
+		Year:     year,
+		Current:  overall[0],
+		Previous: overall[1],
+		LastYear: overall[2],
+		Changes:  []ReportChange{},
+	}
+	for _, key := range keys {
+		change := ReportChange{
+			Key:      key,
+			Label:    labels[key],
+			Current:  totals[0][key],
+			Previous: totals[1][key],
+			LastYear: totals[2][key],
+		}
+		change.ChangeFromPrevious = percentageChange(change.Previous, change.Current)
+		change.ChangeFromLastYear = percentageChange(change.LastYear, change.Current)
+		comparison.Changes = append(comparison.Changes, change)
+	}
+
+	sort.SliceStable(comparison.Changes, func(i, j int) bool {
+		return comparison.Changes[i].Current > comparison.Changes[j].Current
+	})
+
+	if groupBy == "card" {
+		rows := make([]ReportRow, len(comparison.Changes))
+		for i, c := range comparison.Changes {
+			rows[i] = ReportRow{Key: c.Key, Label: c.Label}
+		}
+
+		err = labelCardRows(ctx, ownerID, rows)
+		if err != nil {
+			return ReportComparison{}, err
+		}
+
+		for i := range rows {
+			comparison.Changes[i].Label = rows[i].Label
+		}
+	}
+
+	return comparison, nil
+}
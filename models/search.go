@@ -0,0 +1,280 @@
+package models
+
+import (
+	"budget-tracker-api/observability"
+	"budget-tracker-api/services"
+	"context"
+	"html"
+	"strings"
+	"time"
+	"unicode"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/x/bsonx"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const (
+	defaultSearchLimit = 20
+	maxSearchLimit     = 100
+
+	// fragmentRadius defines how many characters around the first match are kept at highlights
+	fragmentRadius = 60
+)
+
+// spendsTextIndex defines the text index of spends. Descriptions weigh the most, and as they mix
+// languages no stemming nor stop words are applied
+var spendsTextIndex = mongo.IndexModel{
+	Keys: bsonx.Doc{
+		{Key: "description", Value: bsonx.String("text")},
+		{Key: "notes", Value: bsonx.String("text")},
+		{Key: "category", Value: bsonx.String("text")},
+		{Key: "tags", Value: bsonx.String("text")},
+	},
+	Options: options.Index().
+		SetName("spends_text").
+		SetDefaultLanguage("none").
+		SetWeights(bson.M{"description": 10, "tags": 5, "category": 5, "notes": 2}),
+}
+
+// diacritics maps accented latin letters to their base letters, as text indexes ignore diacritics
+var diacritics = map[rune]rune{
+	'á': 'a', 'à': 'a', 'â': 'a', 'ã': 'a', 'ä': 'a', 'é': 'e', 'è': 'e', 'ê': 'e', 'ë': 'e',
+	'í': 'i', 'ì': 'i', 'î': 'i', 'ï': 'i', 'ó': 'o', 'ò': 'o', 'ô': 'o', 'õ': 'o', 'ö': 'o',
+	'ú': 'u', 'ù': 'u', 'û': 'u', 'ü': 'u', 'ç': 'c', 'ñ': 'n',
+}
+
+// foldRune will lower case a rune and remove its diacritics
+func foldRune(r rune) rune {
+	r = unicode.ToLower(r)
+	if base, ok := diacritics[r]; ok {
+		return base
+	}
+	return r
+}
+
+// isWordRune will tell if a rune is part of a word
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// searchTerms will return the folded terms of a text search, leaving out negated ones. Quoted phrases
+// are kept as single terms
+func searchTerms(text string) (terms [][]rune) {
+	for i, part := range strings.Split(text, `"`) {
+		// odd parts are between quotes
+		if i%2 == 1 {
+			if phrase := strings.TrimSpace(part); phrase != "" {
+				terms = append(terms, []rune(strings.Map(foldRune, phrase)))
+			}
+			continue
+		}
+
+		for _, word := range strings.Fields(part) {
+			if strings.HasPrefix(word, "-") {
+				continue
+			}
+			word = strings.TrimFunc(strings.Map(foldRune, word), func(r rune) bool { return !isWordRune(r) })
+			if word != "" {
+				terms = append(terms, []rune(word))
+			}
+		}
+	}
+	return terms
+}
+
+// highlight will wrap the words of a text starting with any of the terms in <em> tags, trimming long texts
+// around their first match. The text itself is HTML escaped, so fragments are safe to be rendered as
+// HTML. Texts without matches return an empty fragment
+func highlight(text string, terms [][]rune) (fragment string) {
+	original := []rune(text)
+	folded := make([]rune, len(original))
+	for i, r := range original {
+		folded[i] = foldRune(r)
+	}
+
+	// marking the matched ranges, extending matches up to the end of their words
+	matched := make([]bool, len(original))
+	first := -1
+	for _, term := range terms {
+		for i := 0; i+len(term) <= len(folded); i++ {
+			if i > 0 && isWordRune(folded[i-1]) {
+				continue
+			}
+			if string(folded[i:i+len(term)]) != string(term) {
+				continue
+			}
+
+			end := i + len(term)
+			for end < len(folded) && isWordRune(folded[end]) {
+				end++
+			}
+			for j := i; j < end; j++ {
+				matched[j] = true
+			}
+			if first < 0 || i < first {
+				first = i
+			}
+		}
+	}
+
+	if first < 0 {
+		return ""
+	}
+
+	start, end := 0, len(original)
+	if first > fragmentRadius {
+		start = first - fragmentRadius
+	}
+	if end-first > 2*fragmentRadius {
+		end = first + 2*fragmentRadius
+	}
+
+	var b strings.Builder
+	if start > 0 {
+		b.WriteString("…")
+	}
+	for i := start; i < end; i++ {
+		if matched[i] && (i == start || !matched[i-1]) {
+			b.WriteString("<em>")
+		}
+		b.WriteString(html.EscapeString(string(original[i])))
+		if matched[i] && (i == end-1 || !matched[i+1]) {
+			b.WriteString("</em>")
+		}
+	}
+	if end < len(original) {
+		b.WriteString("…")
+	}
+
+	return b.String()
+}
+
+// spendHighlights will return the highlighted matches of a text search at each spend field
+func spendHighlights(s Spend, terms [][]rune) (highlights []Highlight) {
+	highlights = []Highlight{}
+
+	fields := []struct {
+		name   string
+		values []string
+	}{
+		{"description", []string{s.Description}},
+		{"notes", []string{s.Notes}},
+		{"category", s.Categories},
+		{"tags", s.Tags},
+	}
+
+	for _, field := range fields {
+		for _, value := range field.values {
+			if fragment := highlight(value, terms); fragment != "" {
+				highlights = append(highlights, Highlight{Field: field.name, Fragment: fragment})
+			}
+		}
+	}
+
+	return highlights
+}
+
+// SearchSpends will search the spends from an owner_id by text at their descriptions, notes, categories
+// and tags, the most relevant ones first. Installments are found through their installment plans
+func SearchSpends(parentCtx context.Context, q SpendsSearch) (results []SearchResult, err error) {
+	spanTags := []attribute.KeyValue{
+		attribute.Key("spend.owner.id").String(q.OwnerID),
+	}
+
+	ctx, span := observability.Span(parentCtx, "mongodb", "SearchSpends", spanTags)
+	defer span.End()
+
+	oid, err := primitive.ObjectIDFromHex(q.OwnerID)
+	if err != nil {
+		return []SearchResult{}, err
+	}
+
+	filter := bson.M{
+		"owner_id":  oid,
+		"parent_id": bson.M{"$exists": false},
+		"$nor":      bson.A{splitLinesFilter()},
+		"$text":     bson.M{"$search": q.Text},
+	}
+
+	costFilter := bson.M{}
+	if q.MinCost != nil {
+		costFilter["$gte"] = *q.MinCost
+	}
+	if q.MaxCost != nil {
+		costFilter["$lte"] = *q.MaxCost
+	}
+	if len(costFilter) > 0 {
+		filter["cost"] = costFilter
+	}
+
+	dateFilter := bson.M{}
+	if !q.From.IsZero() {
+		dateFilter["$gte"] = q.From
+	}
+	if !q.To.IsZero() {
+		dateFilter["$lte"] = q.To
+	}
+	if len(dateFilter) > 0 {
+		filter["date"] = dateFilter
+	}
+
+	limit := q.Limit
+	if limit <= 0 {
+		limit = defaultSearchLimit
+	}
+	if limit > maxSearchLimit {
+		limit = maxSearchLimit
+	}
+
+	dbClient, err := services.InitDatabase()
+	if err != nil {
+		return []SearchResult{}, err
+	}
+
+	col := dbClient.Database(mongodbDatabase).Collection(mongodbSpendsCollection)
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+
+	// spends created before search existed may lack the text index
+	_, err = col.Indexes().CreateOne(ctx, spendsTextIndex)
+
+	score := bson.M{"$meta": "textScore"}
+	opts := options.Find().
+		SetProjection(bson.M{"score": score}).
+		SetSort(bson.D{{Key: "score", Value: score}, {Key: "date", Value: -1}}).
+		SetLimit(limit)
+
+	cursor, err := col.Find(ctx, filter, opts)
+	if err != nil {
+		cancel()
+		return []SearchResult{}, err
+	}
+
+	defer cursor.Close(ctx)
+	defer cancel()
+
+	terms := searchTerms(q.Text)
+	for cursor.Next(ctx) {
+		var match struct {
+			Spend `bson:",inline"`
+			Score float64 `bson:"score"`
+		}
+		cursor.Decode(&match)
+
+		results = append(results, SearchResult{
+			Spend:      match.Spend,
+			Score:      match.Score,
+			Highlights: spendHighlights(match.Spend, terms),
+		})
+	}
+
+	if err := cursor.Err(); err != nil {
+		cancel()
+		return []SearchResult{}, err
+	}
+
+	return results, nil
+}
@@ -0,0 +1,37 @@
+package models
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHighlight(t *testing.T) {
+	long := strings.Repeat("a", 100) + " coffee " + strings.Repeat("b", 200)
+
+	tests := []struct {
+		name  string
+		text  string
+		terms [][]rune
+		want  string
+	}{
+		{"single match", "Coffee with friends", searchTerms("coffee"), "<em>Coffee</em> with friends"},
+		{"prefix extends to the word end", "Supermarket groceries", searchTerms("super"), "<em>Supermarket</em> groceries"},
+		{"match must start a word", "Espresso", searchTerms("press"), ""},
+		{"diacritics and case folded", "Pão de açúcar", searchTerms("ACUCAR"), "Pão de <em>açúcar</em>"},
+		{"multiple terms", "Gas and parking", searchTerms("gas parking"), "<em>Gas</em> and <em>parking</em>"},
+		{"quoted phrase", "Dinner at home", searchTerms(`"at home"`), "Dinner <em>at home</em>"},
+		{"negated term ignored", "Gas and parking", searchTerms("gas -parking"), "<em>Gas</em> and parking"},
+		{"no match", "Gas", searchTerms("coffee"), ""},
+		{"html escaped", `<script>alert("x")</script> & coffee`, searchTerms("coffee"), "&lt;script&gt;alert(&#34;x&#34;)&lt;/script&gt; &amp; <em>coffee</em>"},
+		{"html escaped inside matches", "Tom&Jerry's", searchTerms("tom"), "<em>Tom</em>&amp;Jerry&#39;s"},
+		{"long text trimmed", long, searchTerms("coffee"), "…" + strings.Repeat("a", 59) + " <em>coffee</em> " + strings.Repeat("b", 113) + "…"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := highlight(tt.text, tt.terms); got != tt.want {
+				t.Errorf("highlight(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
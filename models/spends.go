@@ -4,15 +4,54 @@ import (
 	"budget-tracker-api/observability"
 	"budget-tracker-api/services"
 	"context"
+	"encoding/base64"
+	"errors"
+	"regexp"
 	"time"
 
 	log "github.com/sirupsen/logrus"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/x/bsonx"
 	"go.opentelemetry.io/otel/attribute"
 )
 
-// CreateSpend creates a card for a given owner_id
+// SpendPeriod will return the balance month and year of a spend based on its date
+// as seen from the spend's timezone (UTC when not informed)
+func SpendPeriod(s Spend) (month int64, year int64, err error) {
+	loc := time.UTC
+	if s.Timezone != "" {
+		loc, err = time.LoadLocation(s.Timezone)
+		if err != nil {
+			return 0, 0, err
+		}
+	}
+
+	t := s.Date.In(loc)
+	return int64(t.Month()), int64(t.Year()), nil
+}
+
+// prepareSpend will fill the attributes a spend gets by default: its tags normalized, its date as
+// right now when not informed and its balance month and year
+func prepareSpend(s Spend) (prepared Spend, err error) {
+	s.Tags = NormalizeTags(s.Tags)
+
+	// spends without an explicit date are considered as made right now
+	if s.Date.IsZero() {
+		s.Date = time.Now()
+	}
+
+	s.Month, s.Year, err = SpendPeriod(s)
+	if err != nil {
+		return s, err
+	}
+
+	return s, nil
+}
+
+// CreateSpend creates a spend for a given owner_id
 func CreateSpend(parentCtx context.Context, s Spend) (id string, err error) {
 	spanTags := []attribute.KeyValue{
 		attribute.Key("spend.owner.id").String(s.OwnerID.String()),
@@ -29,10 +68,34 @@ func CreateSpend(parentCtx context.Context, s Spend) (id string, err error) {
 	col := dbClient.Database(mongodbDatabase).Collection(mongodbSpendsCollection)
 	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 
+	_, err = col.Indexes().CreateMany(
+		ctx,
+		[]mongo.IndexModel{
+			{Keys: bsonx.Doc{{Key: "owner_id", Value: bsonx.Int32(1)}, {Key: "date", Value: bsonx.Int32(-1)}, {Key: "_id", Value: bsonx.Int32(-1)}}},
+			{Keys: bsonx.Doc{{Key: "owner_id", Value: bsonx.Int32(1)}, {Key: "cost", Value: bsonx.Int32(-1)}, {Key: "_id", Value: bsonx.Int32(-1)}}},
+			{Keys: bsonx.Doc{{Key: "owner_id", Value: bsonx.Int32(1)}, {Key: "created_at", Value: bsonx.Int32(-1)}, {Key: "_id", Value: bsonx.Int32(-1)}}},
+			{Keys: bsonx.Doc{{Key: "owner_id", Value: bsonx.Int32(1)}, {Key: "category", Value: bsonx.Int32(1)}, {Key: "date", Value: bsonx.Int32(-1)}}},
+			{Keys: bsonx.Doc{{Key: "owner_id", Value: bsonx.Int32(1)}, {Key: "category_ids", Value: bsonx.Int32(1)}, {Key: "date", Value: bsonx.Int32(-1)}}},
+			{Keys: bsonx.Doc{{Key: "owner_id", Value: bsonx.Int32(1)}, {Key: "type", Value: bsonx.Int32(1)}, {Key: "date", Value: bsonx.Int32(-1)}}},
+			{Keys: bsonx.Doc{{Key: "owner_id", Value: bsonx.Int32(1)}, {Key: "payment_method.card_id", Value: bsonx.Int32(1)}, {Key: "date", Value: bsonx.Int32(-1)}}},
+			{Keys: bsonx.Doc{{Key: "owner_id", Value: bsonx.Int32(1)}, {Key: "parent_id", Value: bsonx.Int32(1)}, {Key: "year", Value: bsonx.Int32(1)}, {Key: "month", Value: bsonx.Int32(1)}}},
+			{Keys: bsonx.Doc{{Key: "owner_id", Value: bsonx.Int32(1)}, {Key: "external_id", Value: bsonx.Int32(1)}}},
+			{Keys: bsonx.Doc{{Key: "owner_id", Value: bsonx.Int32(1)}, {Key: "tags", Value: bsonx.Int32(1)}, {Key: "date", Value: bsonx.Int32(-1)}}},
+		},
+	)
+
+	_, err = col.Indexes().CreateOne(ctx, spendsTextIndex)
+
 	// adding timestamp to creationDate
 	t := time.Now()
 	s.CreatedAt = primitive.NewDateTimeFromTime(t)
 
+	s, err = prepareSpend(s)
+	if err != nil {
+		cancel()
+		return "", err
+	}
+
 	r, err := col.InsertOne(ctx, s)
 	if err != nil {
 		cancel()
@@ -47,13 +110,132 @@ func CreateSpend(parentCtx context.Context, s Spend) (id string, err error) {
 	return r.InsertedID.(primitive.ObjectID).Hex(), nil
 }
 
-// GetSpends will return all spends from a specific owner_id
-func GetSpends(parentCtx context.Context, ownerID string) (spends []Spend, err error) {
+// SpendHook defines a function called with every spend booked to a balance
+type SpendHook func(ctx context.Context, s Spend) error
+
+// spendBookedHooks are the hooks registered with OnSpendBooked
+var spendBookedHooks []SpendHook
+
+// OnSpendBooked will register a hook called with every spend booked to a balance by RegisterSpend, such
+// as the checks of notification events. Hooks are meant to be registered at startup
+func OnSpendBooked(hook SpendHook) {
+	spendBookedHooks = append(spendBookedHooks, hook)
+}
+
+// RegisterSpend will validate a spend, resolve its categories and apply the owner's categorization rules
+// before creating it and booking it to its month balance, creating the balance when it doesn't exist
+// yet. Installment plans book each installment to its card statement month instead, while split spends
+// book the parts owned by the spend owner, shares being booked once their users answer them. Spends that can't be booked are deleted back along with their
+// installments or parts. The registered spend is returned along with the booked ones, which are passed to
+// the hooks registered with OnSpendBooked
+func RegisterSpend(parentCtx context.Context, s Spend) (registered Spend, booked []Spend, errs []FieldError, err error) {
 	spanTags := []attribute.KeyValue{
-		attribute.Key("spend.owner.id").String(ownerID),
+		attribute.Key("spend.owner.id").String(s.OwnerID.String()),
 	}
 
-	ctx, span := observability.Span(parentCtx, "mongodb", "GetSpends", spanTags)
+	ctx, span := observability.Span(parentCtx, "mongodb", "RegisterSpend", spanTags)
+	defer span.End()
+
+	errs, err = ValidateSpend(ctx, s)
+	if err != nil || len(errs) > 0 {
+		return Spend{}, []Spend{}, errs, err
+	}
+
+	errs, err = ValidateSpendPeriod(ctx, s)
+	if err != nil || len(errs) > 0 {
+		return Spend{}, []Spend{}, errs, err
+	}
+
+	s, errs, err = ResolveSpendCategories(ctx, s)
+	if err != nil || len(errs) > 0 {
+		return Spend{}, []Spend{}, errs, err
+	}
+
+	s, errs, err = ResolveSplitCategories(ctx, s)
+	if err != nil || len(errs) > 0 {
+		return Spend{}, []Spend{}, errs, err
+	}
+
+	s, err = CategorizeSpend(ctx, s)
+	if err != nil {
+		return Spend{}, []Spend{}, errs, err
+	}
+
+	s, err = prepareSpend(s)
+	if err != nil {
+		return Spend{}, []Spend{}, errs, err
+	}
+
+	var id string
+	switch {
+	case s.Installments != 0:
+		id, booked, err = CreateInstallments(ctx, s)
+	case len(s.Lines) > 0 || len(s.Shares) > 0:
+		id, booked, err = CreateSplitSpend(ctx, s)
+	default:
+		id, err = CreateSpend(ctx, s)
+	}
+
+	if err != nil {
+		return Spend{}, []Spend{}, errs, err
+	}
+
+	s.ID, _ = primitive.ObjectIDFromHex(id)
+	if booked == nil {
+		booked = []Spend{s}
+	}
+
+	err = bookSpends(ctx, booked)
+	if err != nil {
+		rollbackErr := deleteSpends(ctx, append(spendIDs(booked), s.ID))
+		if rollbackErr != nil {
+			log.Errorln("could not delete spend", id, "left out of its balance:", rollbackErr)
+		}
+		return Spend{}, []Spend{}, errs, err
+	}
+
+	runSpendBookedHooks(ctx, booked)
+	return s, booked, errs, nil
+}
+
+// runSpendBookedHooks will run the hooks registered with OnSpendBooked for every booked spend. Hook
+// errors are logged, since the spends were already booked
+func runSpendBookedHooks(ctx context.Context, booked []Spend) {
+	for _, b := range booked {
+		for _, hook := range spendBookedHooks {
+			hookErr := hook(ctx, b)
+			if hookErr != nil {
+				log.Errorln("could not run hook of booked spend", b.ID.Hex(), ":", hookErr)
+			}
+		}
+	}
+}
+
+// bookSpends will add spends to the balances they belong to. Spends added before a failure are removed
+// back from their balances
+func bookSpends(ctx context.Context, spends []Spend) (err error) {
+	for i, s := range spends {
+		err = AddSpendToBalance(ctx, s)
+		if err == nil {
+			continue
+		}
+
+		for _, added := range spends[:i] {
+			rollbackErr := RemoveSpendFromBalance(ctx, added)
+			if rollbackErr != nil {
+				log.Errorln("could not remove spend", added.ID.Hex(), "from balance:", rollbackErr)
+			}
+		}
+		return err
+	}
+
+	return nil
+}
+
+// insertSpends will insert spends generated from another one, such as installments, at once. Spends
+// inserted before a failure are deleted back. The inserted spends are returned with their IDs
+func insertSpends(parentCtx context.Context, spends []Spend) (inserted []Spend, err error) {
+	ctx, span := observability.Span(parentCtx, "mongodb", "insertSpends", []attribute.KeyValue{})
 	defer span.End()
 
 	dbClient, err := services.InitDatabase()
@@ -61,17 +243,261 @@ func GetSpends(parentCtx context.Context, ownerID string) (spends []Spend, err e
 		return []Spend{}, err
 	}
 
-	pid, err := primitive.ObjectIDFromHex(ownerID)
+	col := dbClient.Database(mongodbDatabase).Collection(mongodbSpendsCollection)
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	t := primitive.NewDateTimeFromTime(time.Now())
+	docs := []interface{}{}
+	for i := range spends {
+		spends[i].ID = primitive.NewObjectID()
+		spends[i].CreatedAt = t
+		docs = append(docs, spends[i])
+	}
+
+	_, err = col.InsertMany(ctx, docs)
 	if err != nil {
+		rollbackErr := deleteSpends(ctx, spendIDs(spends))
+		if rollbackErr != nil {
+			log.Errorln("could not delete partially inserted spends:", rollbackErr)
+		}
 		return []Spend{}, err
 	}
 
+	observability.Metrics.Spends.SpendsCreated.Add(float64(len(spends)))
+	return spends, nil
+}
+
+// spendIDs will return the IDs of the given spends
+func spendIDs(spends []Spend) (ids []primitive.ObjectID) {
+	for _, s := range spends {
+		ids = append(ids, s.ID)
+	}
+	return ids
+}
+
+// deleteSpends will delete spends given their IDs, without touching the balances they were added to
+func deleteSpends(ctx context.Context, ids []primitive.ObjectID) (err error) {
+	dbClient, err := services.InitDatabase()
+	if err != nil {
+		return err
+	}
+
+	col := dbClient.Database(mongodbDatabase).Collection(mongodbSpendsCollection)
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	_, err = col.DeleteMany(ctx, bson.M{"_id": bson.M{"$in": ids}})
+	return err
+}
+
+const (
+	defaultSpendsLimit = 50
+	maxSpendsLimit     = 500
+)
+
+// spendsSortFields maps the allowed sorting options to their BSON fields
+var spendsSortFields = map[string]string{
+	"date":       "date",
+	"cost":       "cost",
+	"created_at": "created_at",
+}
+
+// spendsCursor defines the position of the last returned spend for keyset pagination
+type spendsCursor struct {
+	Value interface{}        `bson:"v"`
+	ID    primitive.ObjectID `bson:"id"`
+}
+
+// encodeSpendsCursor will return an opaque cursor pointing to a given spend
+func encodeSpendsCursor(s Spend, sortField string) (string, error) {
+	var value interface{}
+	switch sortField {
+	case "cost":
+		value = s.Cost
+	case "created_at":
+		value = s.CreatedAt
+	default:
+		value = s.Date
+	}
+
+	b, err := bson.MarshalExtJSON(spendsCursor{Value: value, ID: s.ID}, true, false)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// decodeSpendsCursor will parse an opaque cursor generated by encodeSpendsCursor
+func decodeSpendsCursor(cursor string) (c spendsCursor, err error) {
+	b, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return c, errors.New("invalid cursor")
+	}
+
+	err = bson.UnmarshalExtJSON(b, true, &c)
+	if err != nil {
+		return c, errors.New("invalid cursor")
+	}
+
+	return c, nil
+}
+
+// spendsFilter will build a mongodb filter based on a spends query
+func spendsFilter(q SpendsQuery) (filter bson.M, err error) {
+	err = ValidateSpendsQuery(q)
+	if err != nil {
+		return bson.M{}, err
+	}
+
+	pid, err := primitive.ObjectIDFromHex(q.OwnerID)
+	if err != nil {
+		return bson.M{}, err
+	}
+
+	filter = bson.M{"owner_id": pid}
+
+	if len(q.Categories) > 0 {
+		filter["category"] = bson.M{"$in": q.Categories}
+	}
+
+	if len(q.CategoryIDs) > 0 {
+		ids := bson.A{}
+		for _, id := range q.CategoryIDs {
+			cid, err := primitive.ObjectIDFromHex(id)
+			if err != nil {
+				return bson.M{}, err
+			}
+			ids = append(ids, cid)
+		}
+		filter["category_ids"] = bson.M{"$in": ids}
+	}
+
+	if len(q.Tags) > 0 {
+		filter["tags"] = bson.M{"$in": NormalizeTags(q.Tags)}
+	}
+
+	if q.Type != "" {
+		filter["type"] = q.Type
+	}
+
+	if q.PaymentMethod != "" {
+		filter["payment_method."+q.PaymentMethod] = true
+	}
+
+	if q.CardID != "" {
+		cid, err := primitive.ObjectIDFromHex(q.CardID)
+		if err != nil {
+			return bson.M{}, err
+		}
+		filter["payment_method.card_id"] = cid
+	}
+
+	costFilter := bson.M{}
+	if q.MinCost != nil {
+		costFilter["$gte"] = *q.MinCost
+	}
+	if q.MaxCost != nil {
+		costFilter["$lte"] = *q.MaxCost
+	}
+	if len(costFilter) > 0 {
+		filter["cost"] = costFilter
+	}
+
+	dateFilter := bson.M{}
+	if !q.From.IsZero() {
+		dateFilter["$gte"] = q.From
+	}
+	if !q.To.IsZero() {
+		dateFilter["$lte"] = q.To
+	}
+	if len(dateFilter) > 0 {
+		filter["date"] = dateFilter
+	}
+
+	if q.Description != "" {
+		filter["description"] = primitive.Regex{Pattern: regexp.QuoteMeta(q.Description), Options: "i"}
+	}
+
+	if q.Month > 0 && q.Year > 0 {
+		filter["month"] = q.Month
+		filter["year"] = q.Year
+	}
+
+	// listings show split spends at their full cost, booked queries show their parts instead
+	if q.Booked {
+		filter = bson.M{"$and": bson.A{filter, bookedSpendsFilter()}}
+	} else {
+		filter["$nor"] = bson.A{splitLinesFilter()}
+	}
+
+	return filter, nil
+}
+
+// GetSpends will return a page of spends from a specific owner_id matching the given query along
+// with the cursor for the next page. An empty cursor means there are no more spends to be listed
+func GetSpends(parentCtx context.Context, q SpendsQuery) (spends []Spend, next string, err error) {
+	spanTags := []attribute.KeyValue{
+		attribute.Key("spend.owner.id").String(q.OwnerID),
+	}
+
+	ctx, span := observability.Span(parentCtx, "mongodb", "GetSpends", spanTags)
+	defer span.End()
+
+	filter, err := spendsFilter(q)
+	if err != nil {
+		return []Spend{}, "", err
+	}
+
+	sortField := "date"
+	if q.SortBy != "" {
+		sortField = spendsSortFields[q.SortBy]
+	}
+
+	direction := 1
+	comparison := "$gt"
+	if q.SortDesc {
+		direction = -1
+		comparison = "$lt"
+	}
+
+	if q.Cursor != "" {
+		c, err := decodeSpendsCursor(q.Cursor)
+		if err != nil {
+			return []Spend{}, "", err
+		}
+
+		filter["$or"] = bson.A{
+			bson.M{sortField: bson.M{comparison: c.Value}},
+			bson.M{sortField: c.Value, "_id": bson.M{comparison: c.ID}},
+		}
+	}
+
+	limit := q.Limit
+	if limit <= 0 {
+		limit = defaultSpendsLimit
+	}
+	if limit > maxSpendsLimit {
+		limit = maxSpendsLimit
+	}
+
+	dbClient, err := services.InitDatabase()
+	if err != nil {
+		return []Spend{}, "", err
+	}
+
+	// fetching one extra spend to find out whether there is a next page
+	opts := options.Find().
+		SetSort(bson.D{{Key: sortField, Value: direction}, {Key: "_id", Value: direction}}).
+		SetLimit(limit + 1)
+
 	col := dbClient.Database(mongodbDatabase).Collection(mongodbSpendsCollection)
 	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
-	cursor, err := col.Find(ctx, bson.M{"owner_id": pid})
+	cursor, err := col.Find(ctx, filter, opts)
 	if err != nil {
 		cancel()
-		return []Spend{}, err
+		return []Spend{}, "", err
 	}
 
 	defer cursor.Close(ctx)
@@ -81,13 +507,167 @@ func GetSpends(parentCtx context.Context, ownerID string) (spends []Spend, err e
 		var spend Spend
 		cursor.Decode(&spend)
 		spends = append(spends, spend)
-		defer cancel()
 	}
 
 	if err := cursor.Err(); err != nil {
 		cancel()
+		return []Spend{}, "", err
+	}
+
+	if int64(len(spends)) > limit {
+		spends = spends[:limit]
+		next, err = encodeSpendsCursor(spends[len(spends)-1], sortField)
+		if err != nil {
+			return []Spend{}, "", err
+		}
+	}
+
+	return spends, next, nil
+}
+
+// GetSpend will return a spend given an ID
+func GetSpend(parentCtx context.Context, id string) (spend *Spend, err error) {
+	spanTags := []attribute.KeyValue{
+		attribute.Key("spend.id").String(id),
+	}
+
+	ctx, span := observability.Span(parentCtx, "mongodb", "GetSpend", spanTags)
+	defer span.End()
+
+	sid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return &Spend{}, err
+	}
+
+	dbClient, err := services.InitDatabase()
+	if err != nil {
+		return &Spend{}, err
+	}
+
+	col := dbClient.Database(mongodbDatabase).Collection(mongodbSpendsCollection)
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+
+	err = col.FindOne(ctx, bson.M{"_id": sid}).Decode(&spend)
+	if err != nil {
+		cancel()
+		return &Spend{}, err
+	}
+
+	defer cancel()
+	return spend, nil
+}
+
+// restoreBalances will add spends back to the balances they were removed from
+func restoreBalances(ctx context.Context, spends []Spend) {
+	for _, s := range spends {
+		err := AddSpendToBalance(ctx, s)
+		if err != nil {
+			log.Errorln("could not add spend", s.ID.Hex(), "back to balance:", err)
+		}
+	}
+}
+
+// DeleteSpend deletes a spend given an ID, removing it from its month balance. Deleting an installment
+// plan or a split spend deletes all its installments or parts, which can't be deleted on their own.
+// Spends from closed balances or with refunds can't be deleted. The deleted spends are returned
+func DeleteSpend(parentCtx context.Context, id string) (deleted []Spend, err error) {
+	spanTags := []attribute.KeyValue{
+		attribute.Key("spend.id").String(id),
+	}
+
+	ctx, span := observability.Span(parentCtx, "mongodb", "DeleteSpend", spanTags)
+	defer span.End()
+
+	spend, err := GetSpend(ctx, id)
+	if err != nil {
 		return []Spend{}, err
 	}
 
-	return spends, nil
+	dbClient, err := services.InitDatabase()
+	if err != nil {
+		return []Spend{}, err
+	}
+
+	col := dbClient.Database(mongodbDatabase).Collection(mongodbSpendsCollection)
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	if !spend.SplitID.IsZero() {
+		return []Spend{}, ErrSplitPart
+	}
+
+	deleted = []Spend{*spend}
+	if len(spend.Lines) > 0 || len(spend.Shares) > 0 {
+		cursor, err := col.Find(ctx, bson.M{"split_id": spend.ID})
+		if err != nil {
+			return []Spend{}, err
+		}
+
+		parts := []Spend{}
+		err = cursor.All(ctx, &parts)
+		if err != nil {
+			return []Spend{}, err
+		}
+		deleted = append(deleted, parts...)
+	}
+
+	if spend.Installments > 0 && spend.ParentID.IsZero() {
+		cursor, err := col.Find(ctx, bson.M{"parent_id": spend.ID})
+		if err != nil {
+			return []Spend{}, err
+		}
+
+		installments := []Spend{}
+		err = cursor.All(ctx, &installments)
+		if err != nil {
+			return []Spend{}, err
+		}
+		deleted = append(deleted, installments...)
+	}
+
+	booked := []Spend{}
+	for _, s := range deleted {
+		// installment plans and split spends themselves aren't booked at balances
+		if !isBooked(s) {
+			continue
+		}
+
+		closed, err := IsBalanceClosed(ctx, s.OwnerID, s.Month, s.Year)
+		if err != nil {
+			return []Spend{}, err
+		}
+
+		if closed {
+			return []Spend{}, ErrBalanceClosed
+		}
+		booked = append(booked, s)
+	}
+
+	refunded, err := HasRefunds(ctx, spendIDs(deleted))
+	if err != nil {
+		return []Spend{}, err
+	}
+
+	if refunded {
+		return []Spend{}, ErrSpendRefunded
+	}
+
+	// spends are removed from their balances before being deleted and added back on failure, so balances
+	// never account deleted spends
+	for i, s := range booked {
+		err = RemoveSpendFromBalance(ctx, s)
+		if err != nil {
+			restoreBalances(ctx, booked[:i])
+			return []Spend{}, err
+		}
+	}
+
+	err = deleteSpends(ctx, spendIDs(deleted))
+	if err != nil {
+		restoreBalances(ctx, booked)
+		return []Spend{}, err
+	}
+
+	log.Infoln("deleted spend", id)
+	return deleted, nil
 }
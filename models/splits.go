@@ -0,0 +1,570 @@
+package models
+
+import (
+	"budget-tracker-api/observability"
+	"budget-tracker-api/services"
+	"context"
+	"errors"
+	"sort"
+	"strconv"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// ErrSplitPart is returned when deleting a single part of a split spend
+var ErrSplitPart = errors.New("split spend parts can only be deleted along with their spend")
+
+// ErrShareAnswered is returned when answering a share which was already accepted or declined
+var ErrShareAnswered = errors.New("share was already answered")
+
+// ValidateSplits will validate the category lines and shares of a spend. Lines and shares must add up to
+// the spend cost, while shares alone can't exceed it, leaving the remainder to the spend owner
+func ValidateSplits(ctx context.Context, s Spend) (errs []FieldError, err error) {
+	errs = []FieldError{}
+	if len(s.Lines) == 0 && len(s.Shares) == 0 {
+		return errs, nil
+	}
+
+	if s.Installments != 0 {
+		errs = append(errs, FieldError{Field: "installments", Message: "installment plans can't be split"})
+	}
+
+	total := 0.0
+	for i, l := range s.Lines {
+		if l.Amount <= 0 {
+			errs = append(errs, FieldError{Field: "lines[" + strconv.Itoa(i) + "].amount", Message: "amount must be greater than zero"})
+		}
+		total += l.Amount
+	}
+
+	seen := map[primitive.ObjectID]bool{}
+	for i, sh := range s.Shares {
+		prefix := "shares[" + strconv.Itoa(i) + "]."
+		if sh.Amount <= 0 {
+			errs = append(errs, FieldError{Field: prefix + "amount", Message: "amount must be greater than zero"})
+		}
+		total += sh.Amount
+
+		if sh.UserID.IsZero() {
+			errs = append(errs, FieldError{Field: prefix + "user_id", Message: "missing user ID"})
+			continue
+		}
+
+		if sh.UserID == s.OwnerID {
+			errs = append(errs, FieldError{Field: prefix + "user_id", Message: "spends can't be shared with their owner"})
+			continue
+		}
+
+		if seen[sh.UserID] {
+			errs = append(errs, FieldError{Field: prefix + "user_id", Message: "duplicated share for user '" + sh.UserID.Hex() + "'"})
+			continue
+		}
+		seen[sh.UserID] = true
+
+		_, userErr := GetUser(ctx, sh.UserID.Hex())
+		if userErr == mongo.ErrNoDocuments {
+			errs = append(errs, FieldError{Field: prefix + "user_id", Message: "non existent user '" + sh.UserID.Hex() + "'"})
+		} else if userErr != nil {
+			return errs, userErr
+		}
+	}
+
+	total = roundCents(total)
+	cost := strconv.FormatFloat(s.Cost, 'f', 2, 64)
+	if len(s.Lines) > 0 && total != roundCents(s.Cost) {
+		errs = append(errs, FieldError{Field: "lines", Message: "lines and shares must add up to the spend cost of " + cost + ", got " + strconv.FormatFloat(total, 'f', 2, 64)})
+	}
+
+	if len(s.Lines) == 0 && total > roundCents(s.Cost) {
+		errs = append(errs, FieldError{Field: "shares", Message: "shares can't exceed the spend cost of " + cost})
+	}
+
+	return errs, nil
+}
+
+// ResolveSplitCategories will fill both category IDs and names of every line from a split spend, the same
+// way ResolveSpendCategories does for the spend itself
+func ResolveSplitCategories(ctx context.Context, s Spend) (resolved Spend, errs []FieldError, err error) {
+	errs = []FieldError{}
+
+	for i, l := range s.Lines {
+		line, lineErrs, err := ResolveSpendCategories(ctx, Spend{OwnerID: s.OwnerID, Categories: l.Categories, CategoryIDs: l.CategoryIDs})
+		if err != nil {
+			return s, errs, err
+		}
+
+		for _, e := range lineErrs {
+			e.Field = "lines[" + strconv.Itoa(i) + "]." + e.Field
+			errs = append(errs, e)
+		}
+		s.Lines[i].Categories, s.Lines[i].CategoryIDs = line.Categories, line.CategoryIDs
+	}
+
+	return s, errs, nil
+}
+
+// SplitSpend will generate the parts of a split spend booked to the spend owner, one per category line.
+// Spends split only into shares keep the remainder to the owner with the spend categories. Shares get
+// their parts only once answered by their users, see SharePart
+func SplitSpend(s Spend) (parts []Spend) {
+	parts = []Spend{}
+	base := splitPart(s)
+
+	owned := s.Cost
+	for _, sh := range s.Shares {
+		owned -= sh.Amount
+	}
+
+	lines := s.Lines
+	if len(lines) == 0 && roundCents(owned) > 0 {
+		lines = []SpendLine{{Amount: roundCents(owned), Categories: s.Categories, CategoryIDs: s.CategoryIDs}}
+	}
+
+	for _, l := range lines {
+		part := base
+		part.Cost = l.Amount
+		if l.Description != "" {
+			part.Description = l.Description
+		}
+		if len(l.Categories) > 0 || len(l.CategoryIDs) > 0 {
+			part.Categories, part.CategoryIDs = l.Categories, l.CategoryIDs
+		}
+		parts = append(parts, part)
+	}
+
+	return parts
+}
+
+// SharePart will generate the part of an answered share. Accepted shares are booked to the user who owes
+// them, while declined ones fall back to the spend owner with the spend categories
+func SharePart(s Spend, sh SpendShare) (part Spend) {
+	part = splitPart(s)
+	part.Cost = sh.Amount
+	if sh.Status == "declined" {
+		return part
+	}
+
+	// categories, tags and notes belong to the spend owner, shares are categorized by their users' rules
+	part.OwnerID = sh.UserID
+	part.PaidBy = s.OwnerID
+	part.PaymentMethod = PaymentMethod{}
+	part.Categories = nil
+	part.CategoryIDs = nil
+	part.Tags = nil
+	part.Notes = ""
+	part.RecurringRuleID = primitive.NilObjectID
+	return part
+}
+
+// splitPart will return the base of every part from a split spend
+func splitPart(s Spend) (part Spend) {
+	part = s
+	part.ID = primitive.NilObjectID
+	part.SplitID = s.ID
+	part.Lines = nil
+	part.Shares = nil
+	part.ExternalID = ""
+	return part
+}
+
+// CreateSplitSpend will create a spend split into category lines and shares along with the parts owned
+// by the spend owner. Only the parts are meant to be booked to balances, so each user accounts just their
+// own portion. Shares stay pending until their users accept or decline them
+func CreateSplitSpend(parentCtx context.Context, s Spend) (id string, parts []Spend, err error) {
+	spanTags := []attribute.KeyValue{
+		attribute.Key("spend.owner.id").String(s.OwnerID.String()),
+		attribute.Key("spend.lines").Int(len(s.Lines)),
+		attribute.Key("spend.shares").Int(len(s.Shares)),
+	}
+
+	ctx, span := observability.Span(parentCtx, "mongodb", "CreateSplitSpend", spanTags)
+	defer span.End()
+
+	s, err = prepareSpend(s)
+	if err != nil {
+		return "", []Spend{}, err
+	}
+
+	for i := range s.Shares {
+		s.Shares[i].Status = "pending"
+	}
+
+	// parts are inserted before their spend, so a failure never leaves a split spend without parts
+	s.ID = primitive.NewObjectID()
+	parts = SplitSpend(s)
+	if len(parts) > 0 {
+		parts, err = insertSpends(ctx, parts)
+		if err != nil {
+			return "", []Spend{}, err
+		}
+	}
+
+	id, err = CreateSpend(ctx, s)
+	if err != nil {
+		rollbackErr := deleteSpends(ctx, spendIDs(parts))
+		if rollbackErr != nil {
+			log.Errorln("could not delete parts from split spend", s.ID.Hex(), ":", rollbackErr)
+		}
+		return "", []Spend{}, err
+	}
+
+	log.Infoln("split spend", id, "into", len(parts), "parts and", len(s.Shares), "pending shares")
+	return id, parts, nil
+}
+
+// GetShareRequests will return the pending shares other users split with an owner_id, newest first
+func GetShareRequests(parentCtx context.Context, ownerID string) (requests []ShareRequest, err error) {
+	spanTags := []attribute.KeyValue{
+		attribute.Key("spend.owner.id").String(ownerID),
+	}
+
+	ctx, span := observability.Span(parentCtx, "mongodb", "GetShareRequests", spanTags)
+	defer span.End()
+
+	oid, err := primitive.ObjectIDFromHex(ownerID)
+	if err != nil {
+		return []ShareRequest{}, err
+	}
+
+	dbClient, err := services.InitDatabase()
+	if err != nil {
+		return []ShareRequest{}, err
+	}
+
+	col := dbClient.Database(mongodbDatabase).Collection(mongodbSpendsCollection)
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	cursor, err := col.Find(ctx,
+		bson.M{"shares": bson.M{"$elemMatch": bson.M{"user_id": oid, "status": "pending"}}},
+		options.Find().SetSort(bson.D{{Key: "date", Value: -1}, {Key: "_id", Value: -1}}),
+	)
+	if err != nil {
+		return []ShareRequest{}, err
+	}
+
+	spends := []Spend{}
+	err = cursor.All(ctx, &spends)
+	if err != nil {
+		return []ShareRequest{}, err
+	}
+
+	logins := map[primitive.ObjectID]string{}
+	requests = []ShareRequest{}
+	for _, s := range spends {
+		if _, ok := logins[s.OwnerID]; !ok {
+			user, err := GetUser(ctx, s.OwnerID.Hex())
+			if err == nil {
+				logins[s.OwnerID] = user.Login
+			}
+		}
+
+		for _, sh := range s.Shares {
+			if sh.UserID != oid || sh.Status != "pending" {
+				continue
+			}
+
+			requests = append(requests, ShareRequest{
+				SpendID:     s.ID,
+				PaidBy:      s.OwnerID,
+				Login:       logins[s.OwnerID],
+				Description: s.Description,
+				Date:        s.Date,
+				Amount:      sh.Amount,
+			})
+		}
+	}
+
+	return requests, nil
+}
+
+// AcceptShare will accept the pending share of a spend owed by an user, booking its part to the user
+func AcceptShare(ctx context.Context, spendID string, userID string) (part Spend, err error) {
+	return answerShare(ctx, spendID, userID, "accepted")
+}
+
+// DeclineShare will decline the pending share of a spend owed by an user, booking its amount back to the
+// spend owner
+func DeclineShare(ctx context.Context, spendID string, userID string) (part Spend, err error) {
+	return answerShare(ctx, spendID, userID, "declined")
+}
+
+// answerShare will set the status of a pending share and book the part it generates. The share is set
+// back to pending when its part can't be booked
+func answerShare(parentCtx context.Context, spendID string, userID string, status string) (part Spend, err error) {
+	spanTags := []attribute.KeyValue{
+		attribute.Key("spend.id").String(spendID),
+		attribute.Key("user.id").String(userID),
+	}
+
+	ctx, span := observability.Span(parentCtx, "mongodb", "answerShare", spanTags)
+	defer span.End()
+
+	uid, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return Spend{}, err
+	}
+
+	spend, err := GetSpend(ctx, spendID)
+	if err != nil {
+		return Spend{}, err
+	}
+
+	share := SpendShare{}
+	for _, sh := range spend.Shares {
+		if sh.UserID == uid {
+			share = sh
+		}
+	}
+
+	if share.UserID.IsZero() {
+		return Spend{}, mongo.ErrNoDocuments
+	}
+
+	if share.Status != "pending" {
+		return Spend{}, ErrShareAnswered
+	}
+
+	share.Status = status
+	part = SharePart(*spend, share)
+	if status == "accepted" {
+		part, err = CategorizeSpend(ctx, part)
+		if err != nil {
+			return Spend{}, err
+		}
+	}
+
+	closed, err := IsBalanceClosed(ctx, part.OwnerID, part.Month, part.Year)
+	if err != nil {
+		return Spend{}, err
+	}
+
+	if closed {
+		return Spend{}, ErrBalanceClosed
+	}
+
+	dbClient, err := services.InitDatabase()
+	if err != nil {
+		return Spend{}, err
+	}
+
+	col := dbClient.Database(mongodbDatabase).Collection(mongodbSpendsCollection)
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	// the status is flipped before booking so concurrent answers can't book the share twice
+	result, err := col.UpdateOne(ctx,
+		bson.M{"_id": spend.ID, "shares": bson.M{"$elemMatch": bson.M{"user_id": uid, "status": "pending"}}},
+		bson.M{"$set": bson.M{"shares.$.status": status}},
+	)
+	if err != nil {
+		return Spend{}, err
+	}
+
+	if result.ModifiedCount == 0 {
+		return Spend{}, ErrShareAnswered
+	}
+
+	reopen := func() {
+		_, rollbackErr := col.UpdateOne(ctx,
+			bson.M{"_id": spend.ID, "shares.user_id": uid},
+			bson.M{"$set": bson.M{"shares.$.status": "pending"}},
+		)
+		if rollbackErr != nil {
+			log.Errorln("could not set share of spend", spendID, "back to pending:", rollbackErr)
+		}
+	}
+
+	parts, err := insertSpends(ctx, []Spend{part})
+	if err != nil {
+		reopen()
+		return Spend{}, err
+	}
+
+	err = bookSpends(ctx, parts)
+	if err != nil {
+		rollbackErr := deleteSpends(ctx, spendIDs(parts))
+		if rollbackErr != nil {
+			log.Errorln("could not delete share part", parts[0].ID.Hex(), "left out of its balance:", rollbackErr)
+		}
+		reopen()
+		return Spend{}, err
+	}
+
+	runSpendBookedHooks(ctx, parts)
+
+	log.Infoln(status, "share of spend", spendID, "for user", userID)
+	return parts[0], nil
+}
+
+// splitLinesFilter will match the parts of split spends booked to the spend owner, which are already
+// listed through the split spend itself
+func splitLinesFilter() bson.M {
+	return bson.M{"split_id": bson.M{"$exists": true}, "paid_by": bson.M{"$exists": false}}
+}
+
+// acceptedSharesStatus will match the status of accepted shares. Shares created before they had to be
+// accepted have no status and were booked right away
+func acceptedSharesStatus() bson.M {
+	return bson.M{"$nin": bson.A{"pending", "declined"}}
+}
+
+// sumShares will run a shares aggregation grouping unsettled share amounts by user
+func sumShares(ctx context.Context, col *mongo.Collection, pipeline bson.A) (totals map[primitive.ObjectID]float64, err error) {
+	totals = map[primitive.ObjectID]float64{}
+
+	cursor, err := col.Aggregate(ctx, pipeline)
+	if err != nil {
+		return totals, err
+	}
+
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var group struct {
+			ID    primitive.ObjectID `bson:"_id"`
+			Total float64            `bson:"total"`
+		}
+		cursor.Decode(&group)
+		totals[group.ID] = group.Total
+	}
+
+	return totals, cursor.Err()
+}
+
+// GetDebts will return who owes whom between an owner_id and every user it has unsettled shared spends
+// with, sorted by how much the other user owes. Only accepted shares are owed
+func GetDebts(parentCtx context.Context, ownerID string) (debts []Debt, err error) {
+	spanTags := []attribute.KeyValue{
+		attribute.Key("spend.owner.id").String(ownerID),
+	}
+
+	ctx, span := observability.Span(parentCtx, "mongodb", "GetDebts", spanTags)
+	defer span.End()
+
+	oid, err := primitive.ObjectIDFromHex(ownerID)
+	if err != nil {
+		return []Debt{}, err
+	}
+
+	dbClient, err := services.InitDatabase()
+	if err != nil {
+		return []Debt{}, err
+	}
+
+	col := dbClient.Database(mongodbDatabase).Collection(mongodbSpendsCollection)
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	owed, err := sumShares(ctx, col, bson.A{
+		bson.M{"$match": bson.M{"owner_id": oid, "shares.0": bson.M{"$exists": true}}},
+		bson.M{"$unwind": "$shares"},
+		bson.M{"$match": bson.M{"shares.settled": bson.M{"$ne": true}, "shares.status": acceptedSharesStatus()}},
+		bson.M{"$group": bson.M{"_id": "$shares.user_id", "total": bson.M{"$sum": "$shares.amount"}}},
+	})
+	if err != nil {
+		return []Debt{}, err
+	}
+
+	owing, err := sumShares(ctx, col, bson.A{
+		bson.M{"$match": bson.M{"shares.user_id": oid}},
+		bson.M{"$unwind": "$shares"},
+		bson.M{"$match": bson.M{"shares.user_id": oid, "shares.settled": bson.M{"$ne": true}, "shares.status": acceptedSharesStatus()}},
+		bson.M{"$group": bson.M{"_id": "$owner_id", "total": bson.M{"$sum": "$shares.amount"}}},
+	})
+	if err != nil {
+		return []Debt{}, err
+	}
+
+	byUser := map[primitive.ObjectID]*Debt{}
+	for id, total := range owed {
+		byUser[id] = &Debt{UserID: id, OwedToYou: roundCents(total)}
+	}
+
+	for id, total := range owing {
+		if _, ok := byUser[id]; !ok {
+			byUser[id] = &Debt{UserID: id}
+		}
+		byUser[id].YouOwe = roundCents(total)
+	}
+
+	for id, d := range byUser {
+		d.Net = roundCents(d.OwedToYou - d.YouOwe)
+
+		user, err := GetUser(ctx, id.Hex())
+		if err == nil {
+			d.Login = user.Login
+		}
+		debts = append(debts, *d)
+	}
+
+	sort.Slice(debts, func(i, j int) bool {
+		if debts[i].Net != debts[j].Net {
+			return debts[i].Net > debts[j].Net
+		}
+		return debts[i].UserID.Hex() < debts[j].UserID.Hex()
+	})
+
+	return debts, nil
+}
+
+// SettleDebts will mark every unsettled accepted share between an owner_id and another user as settled,
+// both ways, returning how many spends were settled
+func SettleDebts(parentCtx context.Context, ownerID string, userID string) (settled int64, err error) {
+	spanTags := []attribute.KeyValue{
+		attribute.Key("spend.owner.id").String(ownerID),
+		attribute.Key("user.id").String(userID),
+	}
+
+	ctx, span := observability.Span(parentCtx, "mongodb", "SettleDebts", spanTags)
+	defer span.End()
+
+	oid, err := primitive.ObjectIDFromHex(ownerID)
+	if err != nil {
+		return 0, err
+	}
+
+	uid, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return 0, err
+	}
+
+	dbClient, err := services.InitDatabase()
+	if err != nil {
+		return 0, err
+	}
+
+	col := dbClient.Database(mongodbDatabase).Collection(mongodbSpendsCollection)
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	now := primitive.NewDateTimeFromTime(time.Now())
+	for _, pair := range [][2]primitive.ObjectID{{oid, uid}, {uid, oid}} {
+		unsettled := bson.M{"user_id": pair[1], "settled": bson.M{"$ne": true}, "status": acceptedSharesStatus()}
+
+		result, err := col.UpdateMany(ctx,
+			bson.M{"owner_id": pair[0], "shares": bson.M{"$elemMatch": unsettled}},
+			bson.M{"$set": bson.M{
+				"shares.$[share].settled":    true,
+				"shares.$[share].settled_at": now,
+			}},
+			options.Update().SetArrayFilters(options.ArrayFilters{Filters: []interface{}{
+				bson.M{"share.user_id": pair[1], "share.settled": bson.M{"$ne": true}, "share.status": acceptedSharesStatus()},
+			}}),
+		)
+		if err != nil {
+			return settled, err
+		}
+
+		settled += result.ModifiedCount
+	}
+
+	log.Infoln("settled", settled, "shared spends between", ownerID, "and", userID)
+	return settled, nil
+}
@@ -0,0 +1,99 @@
+package models
+
+import (
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestSplitSpend(t *testing.T) {
+	owner := primitive.NewObjectID()
+	friend := primitive.NewObjectID()
+	card := primitive.NewObjectID()
+
+	spend := func(cost float64, lines []SpendLine, shares []SpendShare) Spend {
+		return Spend{
+			ID:            primitive.NewObjectID(),
+			OwnerID:       owner,
+			Description:   "dinner",
+			Cost:          cost,
+			Categories:    []string{"food"},
+			PaymentMethod: PaymentMethod{Credit: true, CardID: card},
+			Lines:         lines,
+			Shares:        shares,
+		}
+	}
+
+	tests := []struct {
+		name  string
+		spend Spend
+		want  []float64
+	}{
+		{"lines only", spend(100, []SpendLine{{Amount: 60}, {Amount: 40}}, nil), []float64{60, 40}},
+		{"shares keep the remainder to the owner", spend(100, nil, []SpendShare{{UserID: friend, Amount: 30}}), []float64{70}},
+		{"shares of the whole cost", spend(100, nil, []SpendShare{{UserID: friend, Amount: 100}}), []float64{}},
+		{"lines and shares", spend(100, []SpendLine{{Amount: 50}}, []SpendShare{{UserID: friend, Amount: 50}}), []float64{50}},
+		{"remainder rounded to cents", spend(10, nil, []SpendShare{{UserID: friend, Amount: 3.33}}), []float64{6.67}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parts := SplitSpend(tt.spend)
+			if len(parts) != len(tt.want) {
+				t.Fatalf("SplitSpend() returned %d parts, want %d", len(parts), len(tt.want))
+			}
+
+			for i, p := range parts {
+				if roundCents(p.Cost) != tt.want[i] {
+					t.Errorf("part %d cost = %.2f, want %.2f", i, p.Cost, tt.want[i])
+				}
+				if p.OwnerID != owner || p.SplitID != tt.spend.ID || !p.PaidBy.IsZero() {
+					t.Errorf("part %d = %+v, want an owner part of spend %s", i, p, tt.spend.ID.Hex())
+				}
+				if p.PaymentMethod.CardID != card || len(p.Lines) > 0 || len(p.Shares) > 0 {
+					t.Errorf("part %d = %+v, want the spend card without lines or shares", i, p)
+				}
+			}
+		})
+	}
+}
+
+func TestSharePart(t *testing.T) {
+	owner := primitive.NewObjectID()
+	friend := primitive.NewObjectID()
+	s := Spend{
+		ID:            primitive.NewObjectID(),
+		OwnerID:       owner,
+		Cost:          100,
+		Categories:    []string{"food"},
+		Tags:          []string{"trip"},
+		PaymentMethod: PaymentMethod{Credit: true, CardID: primitive.NewObjectID()},
+		Shares:        []SpendShare{{UserID: friend, Amount: 40}},
+	}
+
+	tests := []struct {
+		name       string
+		status     string
+		wantOwner  primitive.ObjectID
+		wantPaidBy primitive.ObjectID
+		wantCats   int
+	}{
+		{"accepted share is booked to its user", "accepted", friend, owner, 0},
+		{"declined share falls back to the owner", "declined", owner, primitive.NilObjectID, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			part := SharePart(s, SpendShare{UserID: friend, Amount: 40, Status: tt.status})
+			if part.Cost != 40 || part.SplitID != s.ID || len(part.Shares) > 0 {
+				t.Errorf("SharePart() = %+v, want a part of 40 from spend %s", part, s.ID.Hex())
+			}
+			if part.OwnerID != tt.wantOwner || part.PaidBy != tt.wantPaidBy {
+				t.Errorf("SharePart() owner %s paid by %s, want %s paid by %s", part.OwnerID.Hex(), part.PaidBy.Hex(), tt.wantOwner.Hex(), tt.wantPaidBy.Hex())
+			}
+			if len(part.Categories) != tt.wantCats {
+				t.Errorf("SharePart() categories = %v, want %d", part.Categories, tt.wantCats)
+			}
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package models
+
+import (
+	"budget-tracker-api/observability"
+	"context"
+	"math"
+	"sort"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// savingsRate will return the percentage of a net income not spent by fixed and dynamic outcomes
+func savingsRate(net float64, o Outcome) float64 {
+	if net <= 0 {
+		return 0
+	}
+	return math.Round((net-o.FixedOutcome-o.DynamicOutcome)/net*10000) / 100
+}
+
+// SummarizeYear will summarize the balances from a year. Months without balances are left out
+func SummarizeYear(year int64, balances []Balance) (summary YearlySummary) {
+	summary = YearlySummary{Year: year, Months: []MonthSummary{}, Categories: []CategoryRanking{}}
+
+	for _, b := range balances {
+		if b.Year != year {
+			continue
+		}
+
+		m := MonthSummary{
+			Month:       b.Month,
+			Income:      Income{GrossIncome: b.Income.GrossIncome, NetIncome: b.Income.NetIncome},
+			Outcome:     b.Outcome,
+			Leftover:    roundCents(b.Income.NetIncome - b.Outcome.FixedOutcome - b.Outcome.DynamicOutcome - b.Outcome.Savings),
+			SavingsRate: savingsRate(b.Income.NetIncome, b.Outcome),
+		}
+		summary.Months = append(summary.Months, m)
+
+		summary.Income.GrossIncome += b.Income.GrossIncome
+		summary.Income.NetIncome += b.Income.NetIncome
+		summary.Outcome.FixedOutcome += b.Outcome.FixedOutcome
+		summary.Outcome.DynamicOutcome += b.Outcome.DynamicOutcome
+		summary.Outcome.Savings += b.Outcome.Savings
+	}
+
+	summary.Income.GrossIncome = roundCents(summary.Income.GrossIncome)
+	summary.Income.NetIncome = roundCents(summary.Income.NetIncome)
+	summary.Outcome.FixedOutcome = roundCents(summary.Outcome.FixedOutcome)
+	summary.Outcome.DynamicOutcome = roundCents(summary.Outcome.DynamicOutcome)
+	summary.Outcome.Savings = roundCents(summary.Outcome.Savings)
+	summary.SavingsRate = savingsRate(summary.Income.NetIncome, summary.Outcome)
+
+	sort.Slice(summary.Months, func(i, j int) bool { return summary.Months[i].Month < summary.Months[j].Month })
+
+	for i := range summary.Months {
+		m := &summary.Months[i]
+		if summary.BestMonth == nil || m.SavingsRate > summary.BestMonth.SavingsRate {
+			summary.BestMonth = m
+		}
+		if summary.WorstMonth == nil || m.SavingsRate < summary.WorstMonth.SavingsRate {
+			summary.WorstMonth = m
+		}
+	}
+
+	return summary
+}
+
+// GetYearlySummary will summarize the balances from an owner_id during a year, ranking the categories
+// spends were made on
+func GetYearlySummary(parentCtx context.Context, ownerID string, year int64) (summary YearlySummary, err error) {
+	spanTags := []attribute.KeyValue{
+		attribute.Key("balance.owner.id").String(ownerID),
+	}
+
+	ctx, span := observability.Span(parentCtx, "mongodb", "GetYearlySummary", spanTags)
+	defer span.End()
+
+	oid, err := primitive.ObjectIDFromHex(ownerID)
+	if err != nil {
+		return YearlySummary{}, err
+	}
+
+	balances, err := GetAllBalances(ctx, ownerID)
+	if err != nil {
+		return YearlySummary{}, err
+	}
+
+	summary = SummarizeYear(year, balances)
+
+	rows, total, err := aggregateReport(ctx, bson.M{"owner_id": oid, "year": year}, "category", "", "")
+	if err != nil {
+		return YearlySummary{}, err
+	}
+
+	for i, row := range rows {
+		ranking := CategoryRanking{Rank: i + 1, Category: row.Label, Total: row.Total}
+		if total > 0 {
+			ranking.Percentage = math.Round(row.Total/total*10000) / 100
+		}
+		summary.Categories = append(summary.Categories, ranking)
+	}
+
+	return summary, nil
+}
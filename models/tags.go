@@ -0,0 +1,230 @@
+package models
+
+import (
+	"budget-tracker-api/observability"
+	"budget-tracker-api/services"
+	"context"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const (
+	maxTags        = 20
+	maxTagLength   = 32
+	maxNotesLength = 1000
+
+	defaultTagsLimit = 10
+)
+
+// tagPattern defines the characters allowed at tags
+var tagPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// NormalizeTags will lower case tags, replacing spaces by dashes and leaving out empty and repeated ones
+func NormalizeTags(tags []string) []string {
+	normalized := []string{}
+	seen := map[string]bool{}
+	for _, tag := range tags {
+		tag = strings.Join(strings.Fields(strings.ToLower(tag)), "-")
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		normalized = append(normalized, tag)
+	}
+	return normalized
+}
+
+// ValidateTags will validate a list of normalized tags, returning the invalid ones
+func ValidateTags(tags []string) (errs []FieldError) {
+	errs = []FieldError{}
+
+	if len(tags) > maxTags {
+		errs = append(errs, FieldError{Field: "tags", Message: "spends must have at most " + strconv.Itoa(maxTags) + " tags"})
+	}
+
+	for i, tag := range tags {
+		field := "tags[" + strconv.Itoa(i) + "]"
+		if len(tag) > maxTagLength {
+			errs = append(errs, FieldError{Field: field, Message: "tags must have at most " + strconv.Itoa(maxTagLength) + " characters"})
+		} else if !tagPattern.MatchString(tag) {
+			errs = append(errs, FieldError{Field: field, Message: "tags must have only letters, numbers, dashes and underscores"})
+		}
+	}
+
+	return errs
+}
+
+// ValidateSpendUpdate will validate the attributes of a spend update, returning all invalid ones
+func ValidateSpendUpdate(u SpendUpdate) (errs []FieldError) {
+	errs = []FieldError{}
+
+	if u.Tags != nil {
+		errs = append(errs, ValidateTags(NormalizeTags(*u.Tags))...)
+	}
+
+	if u.Notes != nil && len(*u.Notes) > maxNotesLength {
+		errs = append(errs, FieldError{Field: "notes", Message: "notes must have at most " + strconv.Itoa(maxNotesLength) + " characters"})
+	}
+
+	return errs
+}
+
+// UpdateSpend will update the tags and notes from a spend given an ID. Installment plans have their
+// installments updated as well, along with the copies kept at balances historic
+func UpdateSpend(parentCtx context.Context, id string, u SpendUpdate) (err error) {
+	spanTags := []attribute.KeyValue{
+		attribute.Key("spend.id").String(id),
+	}
+
+	ctx, span := observability.Span(parentCtx, "mongodb", "UpdateSpend", spanTags)
+	defer span.End()
+
+	sid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+
+	fields := bson.M{}
+	if u.Tags != nil {
+		fields["tags"] = NormalizeTags(*u.Tags)
+	}
+	if u.Notes != nil {
+		fields["notes"] = *u.Notes
+	}
+
+	dbClient, err := services.InitDatabase()
+	if err != nil {
+		return err
+	}
+
+	col := dbClient.Database(mongodbDatabase).Collection(mongodbSpendsCollection)
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var spend Spend
+	err = col.FindOne(ctx, bson.M{"_id": sid}).Decode(&spend)
+	if err != nil {
+		return err
+	}
+
+	if len(fields) == 0 {
+		return nil
+	}
+
+	filter := bson.M{"$or": bson.A{bson.M{"_id": sid}, bson.M{"parent_id": sid}, bson.M{"split_id": sid, "owner_id": spend.OwnerID}}}
+	ids := bson.A{}
+
+	cursor, err := col.Find(ctx, filter, options.Find().SetProjection(bson.M{"_id": 1}))
+	if err != nil {
+		return err
+	}
+
+	for cursor.Next(ctx) {
+		var s Spend
+		cursor.Decode(&s)
+		ids = append(ids, s.ID)
+	}
+
+	if err := cursor.Err(); err != nil {
+		return err
+	}
+
+	_, err = col.UpdateMany(ctx, filter, bson.M{"$set": fields})
+	if err != nil {
+		return err
+	}
+
+	historic := bson.M{}
+	for field, value := range fields {
+		historic["historic.$[spend]."+field] = value
+	}
+
+	balances := dbClient.Database(mongodbDatabase).Collection(mongodbBalanceCollection)
+	_, err = balances.UpdateMany(ctx,
+		bson.M{"owner_id": spend.OwnerID, "historic._id": bson.M{"$in": ids}},
+		bson.M{"$set": historic},
+		options.Update().SetArrayFilters(options.ArrayFilters{Filters: []interface{}{
+			bson.M{"spend._id": bson.M{"$in": ids}},
+		}}),
+	)
+	if err != nil {
+		return err
+	}
+
+	log.Infoln("updated spend", id)
+	return nil
+}
+
+// GetTags will return the tags used by an owner_id starting with a prefix, the most used ones first.
+// Installments aren't counted apart from their installment plans
+func GetTags(parentCtx context.Context, ownerID string, prefix string, limit int64) (tags []TagCount, err error) {
+	spanTags := []attribute.KeyValue{
+		attribute.Key("spend.owner.id").String(ownerID),
+	}
+
+	ctx, span := observability.Span(parentCtx, "mongodb", "GetTags", spanTags)
+	defer span.End()
+
+	oid, err := primitive.ObjectIDFromHex(ownerID)
+	if err != nil {
+		return []TagCount{}, err
+	}
+
+	if limit <= 0 {
+		limit = defaultTagsLimit
+	}
+
+	match := bson.M{"tags": bson.M{"$exists": true}}
+	if prefix = strings.ToLower(strings.TrimSpace(prefix)); prefix != "" {
+		match["tags"] = primitive.Regex{Pattern: "^" + regexp.QuoteMeta(prefix)}
+	}
+
+	pipeline := bson.A{
+		bson.M{"$match": bson.M{"owner_id": oid, "parent_id": bson.M{"$exists": false}, "$nor": bson.A{splitLinesFilter()}}},
+		bson.M{"$unwind": "$tags"},
+		bson.M{"$match": match},
+		bson.M{"$group": bson.M{"_id": "$tags", "count": bson.M{"$sum": 1}}},
+		bson.M{"$sort": bson.D{{Key: "count", Value: -1}, {Key: "_id", Value: 1}}},
+		bson.M{"$limit": limit},
+	}
+
+	dbClient, err := services.InitDatabase()
+	if err != nil {
+		return []TagCount{}, err
+	}
+
+	col := dbClient.Database(mongodbDatabase).Collection(mongodbSpendsCollection)
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	cursor, err := col.Aggregate(ctx, pipeline)
+	if err != nil {
+		cancel()
+		return []TagCount{}, err
+	}
+
+	defer cursor.Close(ctx)
+	defer cancel()
+
+	for cursor.Next(ctx) {
+		var group struct {
+			Tag   string `bson:"_id"`
+			Count int64  `bson:"count"`
+		}
+		cursor.Decode(&group)
+		tags = append(tags, TagCount{Tag: group.Tag, Count: group.Count})
+	}
+
+	if err := cursor.Err(); err != nil {
+		cancel()
+		return []TagCount{}, err
+	}
+
+	return tags, nil
+}
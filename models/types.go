@@ -1,6 +1,9 @@
 package models
 
 import (
+	"regexp"
+	"time"
+
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 )
@@ -11,6 +14,19 @@ const (
 	mongodbCardsCollection   = "cards"
 	mongodbBalanceCollection = "balance"
 	mongodbSpendsCollection  = "spends"
+
+	mongodbRecurringCollection            = "recurring"
+	mongodbRecurringOccurrencesCollection = "recurring_occurrences"
+	mongodbCategoriesCollection           = "categories"
+	mongodbCategorizationRulesCollection  = "categorization_rules"
+	mongodbNotificationsCollection        = "notifications"
+	mongodbNotificationEventsCollection   = "notification_events"
+	mongodbGoalsCollection                = "goals"
+	mongodbGoalContributionsCollection    = "goal_contributions"
+	mongodbImportProfilesCollection       = "import_profiles"
+	mongodbImportJobsCollection           = "import_jobs"
+	mongodbAttachmentsCollection          = "attachments"
+	mongodbRefundsCollection              = "refunds"
 )
 
 // Database creates a Database client
@@ -81,28 +97,90 @@ type CreditCard struct {
 	Color string `json:"color" bson:"color"`
 	// example: 1234
 	LastDigits int32 `json:"last_digits" bson:"last_digits"`
+	// example: 25
+	ClosingDay int `json:"closing_day,omitempty" bson:"closing_day,omitempty"`
+	// example: 5
+	DueDay int `json:"due_day,omitempty" bson:"due_day,omitempty"`
 	// swagger:ignore
 	CreatedAt primitive.DateTime `json:"created_at,omitempty" bson:"created_at,omitempty"`
 }
 
-// Income defines an user outcome for a certain month
+// Income defines the total income from an user for a certain month
 type Income struct {
 	GrossIncome float64 `json:"gross" bson:"gross"`
 	NetIncome   float64 `json:"net" bson:"net"`
+	// surplus (or deficit, when negative) carried over from the previous month closing
+	PreviousMonth float64 `json:"previous_month,omitempty" bson:"previous_month,omitempty"`
+}
+
+// IncomeEntry defines a single income received by an user in a certain month
+// swagger:model
+type IncomeEntry struct {
+	// swagger:ignore
+	ID primitive.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`
+	// example: salary
+	Source string `json:"source" bson:"source"`
+	// example: ACME Corp
+	Description string `json:"description,omitempty" bson:"description,omitempty"`
+	// example: 5000.00
+	GrossIncome float64 `json:"gross" bson:"gross"`
+	// example: 4100.00
+	NetIncome float64 `json:"net" bson:"net"`
+	// example: 2021-05-05T00:00:00-03:00
+	ReceivedAt time.Time `json:"received_at,omitempty" bson:"received_at,omitempty"`
+	// example: true
+	Recurring bool `json:"recurring" bson:"recurring"`
+	// transaction ID from the bank statement the income was imported from (OFX ACCTID and FITID)
+	// swagger:ignore
+	ExternalID string `json:"external_id,omitempty" bson:"external_id,omitempty"`
+}
+
+// IncomeBreakdown defines how much an user received from an income source during a year
+type IncomeBreakdown struct {
+	Source      string        `json:"source"`
+	GrossIncome float64       `json:"gross"`
+	NetIncome   float64       `json:"net"`
+	Months      []IncomeMonth `json:"months"`
+}
+
+// IncomeMonth defines how much an user received from an income source in a certain month
+type IncomeMonth struct {
+	Month       int64   `json:"month"`
+	GrossIncome float64 `json:"gross"`
+	NetIncome   float64 `json:"net"`
 }
 
 // Outcome defines an user outcome for a certain month
 type Outcome struct {
 	FixedOutcome   float64 `json:"fixed" bson:"fixed"`
 	DynamicOutcome float64 `json:"dynamic" bson:"dynamic"`
+	// amount contributed to savings goals
+	Savings float64 `json:"savings,omitempty" bson:"savings,omitempty"`
 }
 
 // swagger:model
-// PaymentMethod defines which payment method was used for a certain spend
+// PaymentMethod defines which payment method was used for a certain spend. Credit spends
+// reference one of the owner's cards by its ID
 type PaymentMethod struct {
-	Credit      CreditCard `json:"credit,omitempty" bson:"credit,omitempty"`
-	Debit       bool       `json:"debit,omitempty" bson:"debit,omitempty"`
-	PaymentSlip bool       `json:"payment_slip,omitempty" bson:"payment_slip,omitempty"`
+	// example: true
+	Credit bool `json:"credit,omitempty" bson:"credit,omitempty"`
+	// example: 5f4e76699c362be701856be6
+	CardID      primitive.ObjectID `json:"card_id,omitempty" bson:"card_id,omitempty"`
+	Debit       bool               `json:"debit,omitempty" bson:"debit,omitempty"`
+	PaymentSlip bool               `json:"payment_slip,omitempty" bson:"payment_slip,omitempty"`
+}
+
+// FieldError defines a single invalid attribute from a request payload
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationErrors defines the response of a payload with invalid attributes
+// swagger:model
+type ValidationErrors struct {
+	Message string       `json:"message"`
+	Errors  []FieldError `json:"errors"`
 }
 
 // swagger:model
@@ -121,22 +199,685 @@ type Spend struct {
 	PaymentMethod PaymentMethod `json:"payment_method,omitempty" bson:"payment_method,omitempty"`
 	// example: "categories": ["personal development"]
 	Categories []string `json:"category,omitempty" bson:"category,omitempty"`
+	// example: ["5f4e76699c362be701856be6"]
+	CategoryIDs []primitive.ObjectID `json:"category_ids,omitempty" bson:"category_ids,omitempty"`
+	// example: 2021-05-10T18:30:00-03:00
+	Date time.Time `json:"date" bson:"date"`
+	// example: America/Sao_Paulo
+	Timezone string `json:"timezone,omitempty" bson:"timezone,omitempty"`
+	// example: 10
+	Installments int `json:"installments,omitempty" bson:"installments,omitempty"`
+	// swagger:ignore
+	InstallmentNumber int `json:"installment_number,omitempty" bson:"installment_number,omitempty"`
+	// swagger:ignore
+	ParentID primitive.ObjectID `json:"parent_id,omitempty" bson:"parent_id,omitempty"`
+	// swagger:ignore
+	RecurringRuleID primitive.ObjectID `json:"recurring_rule_id,omitempty" bson:"recurring_rule_id,omitempty"`
+	// example: ["trip-2026", "reimbursable"]
+	Tags []string `json:"tags,omitempty" bson:"tags,omitempty"`
+	// example: paid for the whole team, split later
+	Notes string `json:"notes,omitempty" bson:"notes,omitempty"`
+	// transaction ID from the bank statement the spend was imported from (OFX ACCTID and FITID)
+	// swagger:ignore
+	ExternalID string `json:"external_id,omitempty" bson:"external_id,omitempty"`
+	// category lines the spend is split into, adding up to its cost along with the shares
+	Lines []SpendLine `json:"lines,omitempty" bson:"lines,omitempty"`
+	// portions of the spend owed by other users
+	Shares []SpendShare `json:"shares,omitempty" bson:"shares,omitempty"`
+	// swagger:ignore
+	SplitID primitive.ObjectID `json:"split_id,omitempty" bson:"split_id,omitempty"`
+	// swagger:ignore
+	PaidBy primitive.ObjectID `json:"paid_by,omitempty" bson:"paid_by,omitempty"`
+	// swagger:ignore
+	Month int64 `json:"month" bson:"month"`
+	// swagger:ignore
+	Year int64 `json:"year" bson:"year"`
 	// swagger:ignore
 	CreatedAt primitive.DateTime `json:"created_at,omitempty" bson:"created_at,omitempty"`
 }
 
+// SpendLine defines a portion of a split spend accounted to its own categories
+// swagger:model
+type SpendLine struct {
+	// example: cleaning supplies
+	Description string `json:"description,omitempty" bson:"description,omitempty"`
+	// example: 35.90
+	Amount float64 `json:"amount" bson:"amount"`
+	// example: ["household"]
+	Categories []string `json:"category,omitempty" bson:"category,omitempty"`
+	// example: ["5f4e76699c362be701856be6"]
+	CategoryIDs []primitive.ObjectID `json:"category_ids,omitempty" bson:"category_ids,omitempty"`
+}
+
+// SpendShare defines the portion of a spend owed by another user to the spend owner
+// swagger:model
+type SpendShare struct {
+	// example: 5f4e76699c362be701856be6
+	UserID primitive.ObjectID `json:"user_id" bson:"user_id"`
+	// example: 60.00
+	Amount float64 `json:"amount" bson:"amount"`
+	// pending until the user accepts or declines it
+	// swagger:ignore
+	Status string `json:"status,omitempty" bson:"status,omitempty"`
+	// swagger:ignore
+	Settled bool `json:"settled,omitempty" bson:"settled,omitempty"`
+	// swagger:ignore
+	SettledAt primitive.DateTime `json:"settled_at,omitempty" bson:"settled_at,omitempty"`
+}
+
+// ShareRequest defines a share of another user's spend waiting to be accepted or declined
+// swagger:model
+type ShareRequest struct {
+	SpendID primitive.ObjectID `json:"spend_id"`
+	PaidBy  primitive.ObjectID `json:"paid_by"`
+	// example: vsantos
+	Login string `json:"login,omitempty"`
+	// example: dinner with friends
+	Description string `json:"description"`
+	// example: 2021-05-10T18:30:00-03:00
+	Date time.Time `json:"date"`
+	// example: 60.00
+	Amount float64 `json:"amount"`
+}
+
+// Debt defines how much an user and another one owe each other from unsettled shared spends
+// swagger:model
+type Debt struct {
+	UserID primitive.ObjectID `json:"user_id"`
+	// example: vsantos
+	Login string `json:"login,omitempty"`
+	// owed by the other user
+	OwedToYou float64 `json:"owed_to_you"`
+	// owed to the other user
+	YouOwe float64 `json:"you_owe"`
+	// positive when the other user owes you
+	Net float64 `json:"net"`
+}
+
 // swagger:model
 // Balance defines an user balance
 type Balance struct {
 	ID              primitive.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`
 	OwnerID         primitive.ObjectID `json:"owner_id,omitempty" bson:"owner_id,omitempty"`
 	Income          Income             `json:"income,omitempty" bson:"income,omitempty"`
+	Incomes         []IncomeEntry      `json:"incomes" bson:"incomes"`
 	Outcome         Outcome            `json:"outcome" bson:"outcome"`
 	SpendableAmount float64            `json:"spendable_amount" bson:"spendable_amount"`
 	Historic        []Spend            `json:"historic" bson:"historic"`
+	Budgets         []CategoryBudget   `json:"budgets,omitempty" bson:"budgets,omitempty"`
 	Currency        string             `json:"currency" bson:"currency"`
+	Closed          bool               `json:"closed" bson:"closed"`
+	ClosedAt        primitive.DateTime `json:"closed_at,omitempty" bson:"closed_at,omitempty"`
+	Leftover        float64            `json:"leftover,omitempty" bson:"leftover,omitempty"`
 	Month           int64              `json:"month" bson:"month"`
 	Year            int64              `json:"year" bson:"year"`
 	CreatedAt       primitive.DateTime `json:"created_at" bson:"created_at"`
 	UpdatedAt       primitive.DateTime `json:"updated_at" bson:"updated_at"`
 }
+
+// SpendUpdate defines the editable attributes of a spend, nil attributes are left untouched
+// swagger:model
+type SpendUpdate struct {
+	// example: ["trip-2026", "reimbursable"]
+	Tags *[]string `json:"tags,omitempty"`
+	// example: paid for the whole team, split later
+	Notes *string `json:"notes,omitempty"`
+}
+
+// SpendsSearch defines a text search across the spends from an owner, along with date and cost filters
+type SpendsSearch struct {
+	OwnerID string
+	Text    string
+	From    time.Time
+	To      time.Time
+	MinCost *float64
+	MaxCost *float64
+	Limit   int64
+}
+
+// Highlight defines a spend field fragment matching a text search, with the matches wrapped in <em> tags
+type Highlight struct {
+	// example: description
+	Field string `json:"field"`
+	// example: <em>pharmacy</em> downtown
+	Fragment string `json:"fragment"`
+}
+
+// SearchResult defines a spend found by a text search along with its relevance
+// swagger:model
+type SearchResult struct {
+	Spend      Spend       `json:"spend"`
+	Score      float64     `json:"score"`
+	Highlights []Highlight `json:"highlights"`
+}
+
+// TagCount defines how many spends from an user carry a tag
+type TagCount struct {
+	Tag   string `json:"tag"`
+	Count int64  `json:"count"`
+}
+
+// BalanceUpdate defines the editable attributes of a balance, nil attributes are left untouched. An incomes
+// update replaces all income entries, keeping the amount carried over from the previous month
+// swagger:model
+type BalanceUpdate struct {
+	Incomes *[]IncomeEntry `json:"incomes,omitempty"`
+	// example: BRL
+	Currency *string `json:"currency,omitempty"`
+}
+
+// SpendsQuery defines filters, sorting and pagination options to list spends from an owner
+type SpendsQuery struct {
+	OwnerID       string
+	Categories    []string
+	CategoryIDs   []string
+	Tags          []string
+	Type          string
+	PaymentMethod string
+	CardID        string
+	MinCost       *float64
+	MaxCost       *float64
+	From          time.Time
+	To            time.Time
+	Description   string
+	// balance month and year spends belong to, unfiltered when zero
+	Month int64
+	Year  int64
+	// only spends booked to balances, leaving out installment plans and split spends in favour of their
+	// installments and parts
+	Booked   bool
+	SortBy   string
+	SortDesc bool
+	Limit    int64
+	Cursor   string
+}
+
+// CardInstallments defines the remaining installments of a credit card
+// swagger:model
+type CardInstallments struct {
+	CardID           primitive.ObjectID `json:"card_id"`
+	Alias            string             `json:"alias"`
+	Remaining        []Spend            `json:"remaining"`
+	RemainingCount   int                `json:"remaining_count"`
+	FutureCommitment float64            `json:"future_commitment"`
+}
+
+// RecurringRule defines a spend or income which repeats itself on a schedule
+// swagger:model
+type RecurringRule struct {
+	// swagger:ignore
+	ID primitive.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`
+	// example: 5f4e76699c362be701856be6
+	OwnerID primitive.ObjectID `json:"owner_id,omitempty" bson:"owner_id,omitempty"`
+	// example: spend
+	Kind string `json:"kind" bson:"kind"`
+	// example: monthly
+	Frequency string `json:"frequency" bson:"frequency"`
+	// example: 10
+	DayOfMonth int `json:"day_of_month,omitempty" bson:"day_of_month,omitempty"`
+	// example: 2021-05-10T00:00:00-03:00
+	StartDate time.Time `json:"start_date" bson:"start_date"`
+	// example: 2022-05-10T00:00:00-03:00
+	EndDate time.Time `json:"end_date,omitempty" bson:"end_date,omitempty"`
+	// example: America/Sao_Paulo
+	Timezone string `json:"timezone,omitempty" bson:"timezone,omitempty"`
+	// example: false
+	Paused bool `json:"paused" bson:"paused"`
+	// Spend template used by rules of kind 'spend'
+	Spend Spend `json:"spend,omitempty" bson:"spend,omitempty"`
+	// Income template used by rules of kind 'income'
+	Income IncomeEntry `json:"income,omitempty" bson:"income,omitempty"`
+	// swagger:ignore
+	LastRun time.Time `json:"last_run,omitempty" bson:"last_run,omitempty"`
+	// swagger:ignore
+	CreatedAt primitive.DateTime `json:"created_at,omitempty" bson:"created_at,omitempty"`
+	// swagger:ignore
+	UpdatedAt primitive.DateTime `json:"updated_at,omitempty" bson:"updated_at,omitempty"`
+}
+
+// RecurringRuleUpdate defines the editable attributes of a recurring rule, nil attributes are left untouched
+// swagger:model
+type RecurringRuleUpdate struct {
+	Frequency  *string      `json:"frequency,omitempty"`
+	DayOfMonth *int         `json:"day_of_month,omitempty"`
+	EndDate    *time.Time   `json:"end_date,omitempty"`
+	Timezone   *string      `json:"timezone,omitempty"`
+	Spend      *Spend       `json:"spend,omitempty"`
+	Income     *IncomeEntry `json:"income,omitempty"`
+}
+
+// Category defines a user spend category, which may be a child of another category
+// swagger:model
+type Category struct {
+	// swagger:ignore
+	ID primitive.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`
+	// example: 5f4e76699c362be701856be6
+	OwnerID primitive.ObjectID `json:"owner_id,omitempty" bson:"owner_id,omitempty"`
+	// example: 5f4e76699c362be701856be7
+	ParentID primitive.ObjectID `json:"parent_id,omitempty" bson:"parent_id,omitempty"`
+	// example: groceries
+	Name string `json:"name" bson:"name"`
+	// example: shopping-cart
+	Icon string `json:"icon,omitempty" bson:"icon,omitempty"`
+	// example: #4caf50
+	Color string `json:"color,omitempty" bson:"color,omitempty"`
+	// swagger:ignore
+	Children []Category `json:"children,omitempty" bson:"-"`
+	// swagger:ignore
+	CreatedAt primitive.DateTime `json:"created_at,omitempty" bson:"created_at,omitempty"`
+	// swagger:ignore
+	UpdatedAt primitive.DateTime `json:"updated_at,omitempty" bson:"updated_at,omitempty"`
+}
+
+// CategoryUpdate defines the editable attributes of a category, nil attributes are left untouched.
+// An empty parent ID turns the category into a top level one
+// swagger:model
+type CategoryUpdate struct {
+	Name     *string `json:"name,omitempty"`
+	ParentID *string `json:"parent_id,omitempty"`
+	Icon     *string `json:"icon,omitempty"`
+	Color    *string `json:"color,omitempty"`
+}
+
+// CategoryMerge defines the category in which another one will be merged into
+// swagger:model
+type CategoryMerge struct {
+	// example: 5f4e76699c362be701856be6
+	Into primitive.ObjectID `json:"into"`
+}
+
+// CategorizationRule defines a rule to automatically assign categories to spends. Every informed
+// condition must match a spend for the rule to be applied
+// swagger:model
+type CategorizationRule struct {
+	// swagger:ignore
+	ID primitive.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`
+	// example: 5f4e76699c362be701856be6
+	OwnerID primitive.ObjectID `json:"owner_id,omitempty" bson:"owner_id,omitempty"`
+	// example: uber
+	DescriptionContains string `json:"description_contains,omitempty" bson:"description_contains,omitempty"`
+	// example: ^netflix\b
+	DescriptionRegex string `json:"description_regex,omitempty" bson:"description_regex,omitempty"`
+	// example: 10.00
+	MinCost *float64 `json:"min_cost,omitempty" bson:"min_cost,omitempty"`
+	// example: 100.00
+	MaxCost *float64 `json:"max_cost,omitempty" bson:"max_cost,omitempty"`
+	// example: credit
+	PaymentMethod string `json:"payment_method,omitempty" bson:"payment_method,omitempty"`
+	// example: ["5f4e76699c362be701856be6"]
+	CategoryIDs []primitive.ObjectID `json:"category_ids" bson:"category_ids"`
+	// Rules with a lower priority are applied first
+	// example: 1
+	Priority int `json:"priority" bson:"priority"`
+	// swagger:ignore
+	CreatedAt primitive.DateTime `json:"created_at,omitempty" bson:"created_at,omitempty"`
+
+	// compiled DescriptionRegex, set when the rule is loaded
+	descriptionRegexp *regexp.Regexp
+}
+
+// CategoryBudget defines how much is planned to be spent on a category (and its children) in a month
+// swagger:model
+type CategoryBudget struct {
+	// example: 5f4e76699c362be701856be6
+	CategoryID primitive.ObjectID `json:"category_id" bson:"category_id"`
+	// swagger:ignore
+	Category string `json:"category" bson:"category"`
+	// example: 800.00
+	Amount float64 `json:"amount" bson:"amount"`
+	// Unspent amounts will be carried over to the next month budget of the same category
+	// example: true
+	CarryOver bool `json:"carry_over" bson:"carry_over"`
+	// swagger:ignore
+	CarriedOver float64 `json:"carried_over" bson:"carried_over"`
+}
+
+// BudgetProgress defines how much was spent from a category budget
+// swagger:model
+type BudgetProgress struct {
+	CategoryID primitive.ObjectID `json:"category_id"`
+	Category   string             `json:"category"`
+	Budgeted   float64            `json:"budgeted"`
+	Spent      float64            `json:"spent"`
+	Remaining  float64            `json:"remaining"`
+	Percentage float64            `json:"percentage"`
+	Overspent  bool               `json:"overspent"`
+}
+
+// Notification defines a message sent to an user due to an event, such as a budget being exceeded
+// swagger:model
+type Notification struct {
+	ID      primitive.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`
+	OwnerID primitive.ObjectID `json:"owner_id" bson:"owner_id"`
+	// example: budget.exceeded
+	Event string `json:"event" bson:"event"`
+	// Key identifies a single occurrence of an event, notifications with an already sent key are discarded
+	// example: budget.exceeded:5f4e76699c362be701856be6:2021-05
+	Key string `json:"key" bson:"key"`
+	// example: Groceries budget exceeded
+	Title string `json:"title" bson:"title"`
+	// example: You have spent 820.00 out of 800.00 budgeted for groceries in 5/2021
+	Message   string             `json:"message" bson:"message"`
+	Read      bool               `json:"read" bson:"read"`
+	CreatedAt primitive.DateTime `json:"created_at" bson:"created_at"`
+}
+
+// Goal defines a savings goal from an user, such as a trip or an emergency fund
+// swagger:model
+type Goal struct {
+	// swagger:ignore
+	ID primitive.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`
+	// example: 5f4e76699c362be701856be6
+	OwnerID primitive.ObjectID `json:"owner_id,omitempty" bson:"owner_id,omitempty"`
+	// example: trip to Japan
+	Name string `json:"name" bson:"name"`
+	// example: 15000.00
+	TargetAmount float64 `json:"target_amount" bson:"target_amount"`
+	// example: 2022-12-01T00:00:00-03:00
+	Deadline time.Time `json:"deadline,omitempty" bson:"deadline,omitempty"`
+	// swagger:ignore
+	Saved float64 `json:"saved" bson:"saved"`
+	// swagger:ignore
+	CreatedAt primitive.DateTime `json:"created_at,omitempty" bson:"created_at,omitempty"`
+	// swagger:ignore
+	UpdatedAt primitive.DateTime `json:"updated_at,omitempty" bson:"updated_at,omitempty"`
+}
+
+// GoalUpdate defines the editable attributes of a goal, nil attributes are left untouched
+// swagger:model
+type GoalUpdate struct {
+	Name         *string    `json:"name,omitempty"`
+	TargetAmount *float64   `json:"target_amount,omitempty"`
+	Deadline     *time.Time `json:"deadline,omitempty"`
+}
+
+// GoalContribution defines an amount saved to a goal, accounted as savings outcome of its month balance
+// swagger:model
+type GoalContribution struct {
+	// swagger:ignore
+	ID primitive.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`
+	// swagger:ignore
+	GoalID primitive.ObjectID `json:"goal_id,omitempty" bson:"goal_id,omitempty"`
+	// swagger:ignore
+	OwnerID primitive.ObjectID `json:"owner_id,omitempty" bson:"owner_id,omitempty"`
+	// example: 500.00
+	Amount float64 `json:"amount" bson:"amount"`
+	// example: 2021-05-10T00:00:00-03:00
+	Date time.Time `json:"date" bson:"date"`
+	// timezone used to find out the contribution month, UTC when not informed
+	// example: America/Sao_Paulo
+	Timezone string `json:"timezone,omitempty" bson:"timezone,omitempty"`
+	// swagger:ignore
+	Month int64 `json:"month" bson:"month"`
+	// swagger:ignore
+	Year int64 `json:"year" bson:"year"`
+	// swagger:ignore
+	CreatedAt primitive.DateTime `json:"created_at,omitempty" bson:"created_at,omitempty"`
+}
+
+// GoalProgress defines how far a goal is from its target and when it's expected to be reached based
+// on the monthly contribution rate so far
+type GoalProgress struct {
+	Goal                Goal               `json:"goal"`
+	Remaining           float64            `json:"remaining"`
+	Percentage          float64            `json:"percentage"`
+	MonthlyRate         float64            `json:"monthly_rate"`
+	ProjectedCompletion *time.Time         `json:"projected_completion,omitempty"`
+	OnTrack             bool               `json:"on_track"`
+	Contributions       []GoalContribution `json:"contributions"`
+}
+
+// ForecastLine defines a single expected income or outcome from a forecast month along with the
+// assumption it was based on
+type ForecastLine struct {
+	// income or outcome
+	Kind string `json:"kind"`
+	// recurring, installment, scheduled, registered_income or average
+	Source      string              `json:"source"`
+	Description string              `json:"description"`
+	Category    string              `json:"category,omitempty"`
+	Amount      float64             `json:"amount"`
+	Assumption  string              `json:"assumption"`
+	ReferenceID *primitive.ObjectID `json:"reference_id,omitempty"`
+}
+
+// ForecastMonth defines the expected income, outcome and spendable amount for a future month
+type ForecastMonth struct {
+	Month           int64          `json:"month"`
+	Year            int64          `json:"year"`
+	Income          float64        `json:"income"`
+	Outcome         float64        `json:"outcome"`
+	SpendableAmount float64        `json:"spendable_amount"`
+	Lines           []ForecastLine `json:"lines"`
+}
+
+// ReportQuery defines how spends are aggregated into a report
+type ReportQuery struct {
+	OwnerID string
+	// category, payment_method, card, type or tag
+	GroupBy string
+	// day, week, month or year. An empty granularity aggregates the whole period
+	Granularity string
+	From        time.Time
+	To          time.Time
+	Timezone    string
+}
+
+// ReportRow defines the total spent on a group during a period
+type ReportRow struct {
+	Key    string  `json:"key"`
+	Label  string  `json:"label"`
+	Period string  `json:"period,omitempty"`
+	Total  float64 `json:"total"`
+	Count  int64   `json:"count"`
+}
+
+// Report defines spends totals grouped by an attribute and optionally by period
+type Report struct {
+	GroupBy     string      `json:"group_by"`
+	Granularity string      `json:"granularity,omitempty"`
+	From        time.Time   `json:"from,omitempty"`
+	To          time.Time   `json:"to,omitempty"`
+	Total       float64     `json:"total"`
+	Rows        []ReportRow `json:"rows"`
+}
+
+// ReportChange defines how the total spent on a group changed between a month, the previous one and
+// the same month from the previous year. Changes are percentages, nil when there's nothing to compare to
+type ReportChange struct {
+	Key                string   `json:"key"`
+	Label              string   `json:"label"`
+	Current            float64  `json:"current"`
+	Previous           float64  `json:"previous"`
+	LastYear           float64  `json:"last_year"`
+	ChangeFromPrevious *float64 `json:"change_from_previous,omitempty"`
+	ChangeFromLastYear *float64 `json:"change_from_last_year,omitempty"`
+}
+
+// ReportComparison defines the comparison of a month report with the previous month and the same month
+// from the previous year
+type ReportComparison struct {
+	GroupBy  string         `json:"group_by"`
+	Month    int64          `json:"month"`
+	Year     int64          `json:"year"`
+	Current  float64        `json:"current"`
+	Previous float64        `json:"previous"`
+	LastYear float64        `json:"last_year"`
+	Changes  []ReportChange `json:"changes"`
+}
+
+// MonthSummary defines the income and outcome totals from a month balance. The savings rate is the
+// percentage of the net income not spent
+type MonthSummary struct {
+	Month       int64   `json:"month"`
+	Income      Income  `json:"income"`
+	Outcome     Outcome `json:"outcome"`
+	Leftover    float64 `json:"leftover"`
+	SavingsRate float64 `json:"savings_rate"`
+}
+
+// CategoryRanking defines how much was spent on a category during a period and its share of the total.
+// Spends with multiple categories count towards each one of them, so shares may add up to more than 100
+type CategoryRanking struct {
+	Rank       int     `json:"rank"`
+	Category   string  `json:"category"`
+	Total      float64 `json:"total"`
+	Percentage float64 `json:"percentage"`
+}
+
+// YearlySummary defines the income and outcome totals from an user during a year, with the best and
+// worst months based on their savings rate
+type YearlySummary struct {
+	Year        int64             `json:"year"`
+	Income      Income            `json:"income"`
+	Outcome     Outcome           `json:"outcome"`
+	SavingsRate float64           `json:"savings_rate"`
+	Months      []MonthSummary    `json:"months"`
+	BestMonth   *MonthSummary     `json:"best_month,omitempty"`
+	WorstMonth  *MonthSummary     `json:"worst_month,omitempty"`
+	Categories  []CategoryRanking `json:"categories"`
+}
+
+// ImportProfile defines how the columns of a bank statement CSV are mapped into spends. Columns are
+// referenced by their header names
+// swagger:model
+type ImportProfile struct {
+	// swagger:ignore
+	ID primitive.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`
+	// example: 5f4e76699c362be701856be6
+	OwnerID primitive.ObjectID `json:"owner_id,omitempty" bson:"owner_id,omitempty"`
+	// example: nubank checking account
+	Name string `json:"name" bson:"name"`
+	// example: ;
+	Delimiter string `json:"delimiter,omitempty" bson:"delimiter,omitempty"`
+	// lines skipped before the header line
+	// example: 0
+	SkipLines int `json:"skip_lines,omitempty" bson:"skip_lines,omitempty"`
+	// example: Data
+	DateColumn string `json:"date_column" bson:"date_column"`
+	// example: DD/MM/YYYY
+	DateFormat string `json:"date_format" bson:"date_format"`
+	// example: Descrição
+	DescriptionColumn string `json:"description_column" bson:"description_column"`
+	// example: Valor
+	AmountColumn string `json:"amount_column" bson:"amount_column"`
+	// example: true
+	DecimalComma bool `json:"decimal_comma" bson:"decimal_comma"`
+	// sign of spends amounts, 'negative' (default) or 'positive'. Rows with the opposite sign are skipped
+	// example: negative
+	SpendSign string `json:"spend_sign,omitempty" bson:"spend_sign,omitempty"`
+	// spend type given to imported spends, 'dynamic' by default
+	// example: dynamic
+	Type string `json:"type,omitempty" bson:"type,omitempty"`
+	// payment method given to imported spends
+	PaymentMethod PaymentMethod `json:"payment_method" bson:"payment_method"`
+	// example: America/Sao_Paulo
+	Timezone string `json:"timezone,omitempty" bson:"timezone,omitempty"`
+	// swagger:ignore
+	CreatedAt primitive.DateTime `json:"created_at,omitempty" bson:"created_at,omitempty"`
+}
+
+// ImportError defines why a line from an imported file couldn't be imported
+type ImportError struct {
+	Line    int    `json:"line" bson:"line"`
+	Message string `json:"message" bson:"message"`
+}
+
+// ImportRecord defines a spend, or an income entry, parsed from a line of an imported file
+type ImportRecord struct {
+	Line      int          `json:"line" bson:"line"`
+	Spend     *Spend       `json:"spend,omitempty" bson:"spend,omitempty"`
+	Income    *IncomeEntry `json:"income,omitempty" bson:"income,omitempty"`
+	Duplicate bool         `json:"duplicate" bson:"duplicate"`
+}
+
+// ImportJob defines the status of a file import. Dry runs only preview the parsed spends
+// swagger:model
+type ImportJob struct {
+	ID      primitive.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`
+	OwnerID primitive.ObjectID `json:"owner_id,omitempty" bson:"owner_id,omitempty"`
+	// csv, ofx or qif
+	Format   string `json:"format" bson:"format"`
+	Filename string `json:"filename" bson:"filename"`
+	DryRun   bool   `json:"dry_run" bson:"dry_run"`
+	// pending, running, done or failed
+	Status     string             `json:"status" bson:"status"`
+	Total      int                `json:"total" bson:"total"`
+	Imported   int                `json:"imported" bson:"imported"`
+	Duplicates int                `json:"duplicates" bson:"duplicates"`
+	Skipped    int                `json:"skipped" bson:"skipped"`
+	Failed     int                `json:"failed" bson:"failed"`
+	Errors     []ImportError      `json:"errors" bson:"errors"`
+	Preview    []ImportRecord     `json:"preview,omitempty" bson:"preview,omitempty"`
+	CreatedAt  primitive.DateTime `json:"created_at" bson:"created_at"`
+	FinishedAt primitive.DateTime `json:"finished_at,omitempty" bson:"finished_at,omitempty"`
+}
+
+// Attachment defines a file, such as a receipt photo, attached to a spend. Files themselves are kept
+// at the attachments storage under Key
+// swagger:model
+type Attachment struct {
+	ID      primitive.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`
+	OwnerID primitive.ObjectID `json:"owner_id,omitempty" bson:"owner_id,omitempty"`
+	SpendID primitive.ObjectID `json:"spend_id" bson:"spend_id"`
+	// example: receipt.jpg
+	Filename string `json:"filename" bson:"filename"`
+	// example: image/jpeg
+	ContentType string `json:"content_type" bson:"content_type"`
+	// example: 183204
+	Size int64 `json:"size" bson:"size"`
+	// swagger:ignore
+	Key       string             `json:"-" bson:"key"`
+	CreatedAt primitive.DateTime `json:"created_at" bson:"created_at"`
+}
+
+// Refund defines money given back for a spend, either a refund from the merchant or a reimbursement from
+// someone else, such as an employer. Received refunds reduce the outcome of the month they are booked to
+// swagger:model
+type Refund struct {
+	// swagger:ignore
+	ID primitive.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`
+	// swagger:ignore
+	OwnerID primitive.ObjectID `json:"owner_id,omitempty" bson:"owner_id,omitempty"`
+	// example: 5f4e76699c362be701856be6
+	SpendID primitive.ObjectID `json:"spend_id" bson:"spend_id"`
+	// refund or reimbursement
+	// example: reimbursement
+	Kind string `json:"kind" bson:"kind"`
+	// example: 120.50
+	Amount float64 `json:"amount" bson:"amount"`
+	// example: hotel from the sales conference
+	Description string `json:"description,omitempty" bson:"description,omitempty"`
+	// who pays a reimbursement back
+	// example: ACME Corp
+	Payer string `json:"payer,omitempty" bson:"payer,omitempty"`
+	// pending or received. Refunds are always received, reimbursements are pending until received
+	// example: pending
+	Status string `json:"status" bson:"status"`
+	// when the money was received, now when not informed
+	// example: 2021-05-10T00:00:00-03:00
+	Date time.Time `json:"date,omitempty" bson:"date,omitempty"`
+	// when a pending reimbursement is expected
+	// example: 2021-06-05T00:00:00-03:00
+	ExpectedAt time.Time `json:"expected_at,omitempty" bson:"expected_at,omitempty"`
+	// swagger:ignore
+	SpendType string `json:"spend_type,omitempty" bson:"spend_type,omitempty"`
+	// swagger:ignore
+	PaymentMethod PaymentMethod `json:"payment_method,omitempty" bson:"payment_method,omitempty"`
+	// swagger:ignore
+	Month int64 `json:"month,omitempty" bson:"month,omitempty"`
+	// swagger:ignore
+	Year int64 `json:"year,omitempty" bson:"year,omitempty"`
+	// swagger:ignore
+	CreatedAt primitive.DateTime `json:"created_at,omitempty" bson:"created_at,omitempty"`
+}
+
+// Receivables defines the reimbursements an user is still waiting for
+// swagger:model
+type Receivables struct {
+	Total float64 `json:"total"`
+	Count int     `json:"count"`
+	// total of reimbursements past their expected date
+	Overdue float64  `json:"overdue"`
+	Refunds []Refund `json:"refunds"`
+}
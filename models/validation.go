@@ -0,0 +1,182 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// spendTypes defines the allowed spend types
+var spendTypes = []string{"fixed", "dynamic"}
+
+// validateSpendType will validate if a spend type is an allowed one
+func validateSpendType(spendType string) bool {
+	for _, t := range spendTypes {
+		if spendType == t {
+			return true
+		}
+	}
+	return false
+}
+
+// spendsPaymentMethods defines the payment methods spends can be filtered by
+var spendsPaymentMethods = []string{"credit", "debit", "payment_slip"}
+
+// ValidateSpendsQuery will validate the type, payment method and sorting of a spends query, returning
+// the first invalid one. Empty attributes aren't filtered or sorted by
+func ValidateSpendsQuery(q SpendsQuery) error {
+	if q.Type != "" && !validateSpendType(q.Type) {
+		return errors.New("invalid 'type' value, must be one of 'fixed' or 'dynamic'")
+	}
+
+	if q.PaymentMethod != "" {
+		valid := false
+		for _, m := range spendsPaymentMethods {
+			valid = valid || q.PaymentMethod == m
+		}
+
+		if !valid {
+			return errors.New("invalid 'payment_method' value")
+		}
+	}
+
+	if _, ok := spendsSortFields[q.SortBy]; q.SortBy != "" && !ok {
+		return errors.New("invalid 'sort' value")
+	}
+
+	return nil
+}
+
+// JoinFieldErrors will join validation errors into a single error, for callers with no way to report
+// them one by one
+func JoinFieldErrors(errs []FieldError) error {
+	messages := []string{}
+	for _, e := range errs {
+		messages = append(messages, e.Field+": "+e.Message)
+	}
+	return errors.New(strings.Join(messages, "; "))
+}
+
+// ValidateSpend will validate every attribute of a spend, returning all invalid ones. Credit spends
+// must reference an existing card owned by the spend owner and shares must reference existing users
+func ValidateSpend(ctx context.Context, s Spend) (errs []FieldError, err error) {
+	errs = []FieldError{}
+
+	if s.OwnerID.IsZero() {
+		errs = append(errs, FieldError{Field: "owner_id", Message: "missing owner ID"})
+	}
+
+	if !validateSpendType(s.Type) {
+		errs = append(errs, FieldError{Field: "type", Message: "type must be one of 'fixed' or 'dynamic'"})
+	}
+
+	if s.Description == "" {
+		errs = append(errs, FieldError{Field: "description", Message: "missing description"})
+	}
+
+	if s.Cost <= 0 {
+		errs = append(errs, FieldError{Field: "cost", Message: "cost must be greater than zero"})
+	}
+
+	if s.Timezone != "" {
+		_, tzErr := time.LoadLocation(s.Timezone)
+		if tzErr != nil {
+			errs = append(errs, FieldError{Field: "timezone", Message: "invalid timezone '" + s.Timezone + "'"})
+		}
+	}
+
+	methods := 0
+	for _, set := range []bool{s.PaymentMethod.Credit, s.PaymentMethod.Debit, s.PaymentMethod.PaymentSlip} {
+		if set {
+			methods++
+		}
+	}
+
+	if methods != 1 {
+		errs = append(errs, FieldError{Field: "payment_method", Message: "exactly one of 'credit', 'debit' or 'payment_slip' must be set"})
+	}
+
+	if !s.PaymentMethod.Credit && !s.PaymentMethod.CardID.IsZero() {
+		errs = append(errs, FieldError{Field: "payment_method.card_id", Message: "cards can only be referenced by credit spends"})
+	}
+
+	if s.PaymentMethod.Credit {
+		if s.PaymentMethod.CardID.IsZero() {
+			errs = append(errs, FieldError{Field: "payment_method.card_id", Message: "credit spends must reference a card"})
+		} else {
+			card, cardErr := GetCard(ctx, s.PaymentMethod.CardID.Hex())
+			if cardErr == mongo.ErrNoDocuments {
+				errs = append(errs, FieldError{Field: "payment_method.card_id", Message: "non existent card"})
+			} else if cardErr != nil {
+				return errs, cardErr
+			} else if card.OwnerID != s.OwnerID {
+				errs = append(errs, FieldError{Field: "payment_method.card_id", Message: "card is not owned by the spend owner"})
+			}
+		}
+	}
+
+	errs = append(errs, ValidateTags(NormalizeTags(s.Tags))...)
+
+	if len(s.Notes) > maxNotesLength {
+		errs = append(errs, FieldError{Field: "notes", Message: "notes must have at most " + strconv.Itoa(maxNotesLength) + " characters"})
+	}
+
+	splitErrs, err := ValidateSplits(ctx, s)
+	if err != nil {
+		return errs, err
+	}
+	errs = append(errs, splitErrs...)
+
+	if s.Installments != 0 {
+		if s.Installments < 2 {
+			errs = append(errs, FieldError{Field: "installments", Message: "installment plans must have at least 2 installments"})
+		}
+
+		if !s.PaymentMethod.Credit {
+			errs = append(errs, FieldError{Field: "installments", Message: "installments are only allowed for credit spends"})
+		}
+	}
+
+	return errs, nil
+}
+
+// ValidateSpendPeriod will validate if a spend doesn't belong to a closed month balance, neither its own
+// nor the ones from the users it is shared with. Spends without a date belong to the current month
+func ValidateSpendPeriod(ctx context.Context, s Spend) (errs []FieldError, err error) {
+	errs = []FieldError{}
+
+	if s.Date.IsZero() {
+		s.Date = time.Now()
+	}
+
+	month, year, err := SpendPeriod(s)
+	if err != nil {
+		return errs, nil
+	}
+
+	closed, err := IsBalanceClosed(ctx, s.OwnerID, month, year)
+	if err != nil {
+		return errs, err
+	}
+
+	if closed {
+		errs = append(errs, FieldError{Field: "date", Message: "balance from " + strconv.FormatInt(month, 10) + "/" + strconv.FormatInt(year, 10) + " is closed"})
+	}
+
+	for i, sh := range s.Shares {
+		closed, err := IsBalanceClosed(ctx, sh.UserID, month, year)
+		if err != nil {
+			return errs, err
+		}
+
+		if closed {
+			errs = append(errs, FieldError{Field: "shares[" + strconv.Itoa(i) + "].user_id", Message: "balance from " + strconv.FormatInt(month, 10) + "/" + strconv.FormatInt(year, 10) + " of user '" + sh.UserID.Hex() + "' is closed"})
+		}
+	}
+
+	return errs, nil
+}
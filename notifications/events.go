@@ -0,0 +1,139 @@
+package notifications
+
+import (
+	"budget-tracker-api/models"
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// budgetThresholds defines the budget percentages in which users are warned, from the highest to the lowest
+var budgetThresholds = []struct {
+	percentage float64
+	event      string
+	title      string
+}{
+	{percentage: 100, event: "budget.exceeded", title: "exceeded"},
+	{percentage: 80, event: "budget.warning", title: "almost exceeded"},
+}
+
+// period will format a balance month and year as YYYY-MM
+func period(month int64, year int64) string {
+	return fmt.Sprintf("%04d-%02d", year, month)
+}
+
+// CheckSpendEvents will check the events triggered by a spend at its month balance
+func CheckSpendEvents(ctx context.Context, s models.Spend) error {
+	balance, err := models.GetBalance(ctx, s.OwnerID.Hex(), s.Month, s.Year)
+	if err == mongo.ErrNoDocuments {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return CheckBalanceEvents(ctx, *balance)
+}
+
+// CheckBalanceEvents will notify a negative spendable amount and category budgets reaching their thresholds
+func CheckBalanceEvents(ctx context.Context, balance models.Balance) error {
+	if balance.SpendableAmount < 0 {
+		err := Notify(ctx, models.Notification{
+			OwnerID: balance.OwnerID,
+			Event:   "balance.negative",
+			Key:     "balance.negative:" + period(balance.Month, balance.Year),
+			Title:   "Spendable amount is negative",
+			Message: fmt.Sprintf("Your spendable amount for %d/%d is %.2f %s", balance.Month, balance.Year, balance.SpendableAmount, balance.Currency),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	progress, err := models.GetBudgetsProgress(ctx, balance.OwnerID.Hex(), balance.Month, balance.Year)
+	if err != nil {
+		return err
+	}
+
+	for _, p := range progress {
+		for _, t := range budgetThresholds {
+			if p.Percentage < t.percentage {
+				continue
+			}
+
+			err := Notify(ctx, models.Notification{
+				OwnerID: balance.OwnerID,
+				Event:   t.event,
+				Key:     t.event + ":" + p.CategoryID.Hex() + ":" + period(balance.Month, balance.Year),
+				Title:   "Budget for " + p.Category + " " + t.title,
+				Message: fmt.Sprintf("You have spent %.2f out of %.2f budgeted for %s in %d/%d", p.Spent, p.Budgeted, p.Category, balance.Month, balance.Year),
+			})
+			if err != nil {
+				return err
+			}
+
+			break
+		}
+	}
+
+	return nil
+}
+
+// nextDueDate will return the next due date of a card from a given time on
+func nextDueDate(card models.CreditCard, now time.Time) time.Time {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	for offset := 0; ; offset++ {
+		first := time.Date(now.Year(), now.Month()+time.Month(offset), 1, 0, 0, 0, 0, now.Location())
+		day := card.DueDay
+		if lastDay := first.AddDate(0, 1, -1).Day(); day > lastDay {
+			day = lastDay
+		}
+
+		due := first.AddDate(0, 0, day-1)
+		if !due.Before(today) {
+			return due
+		}
+	}
+}
+
+// CheckCardDueDates will notify owners about card statements due within a given duration
+func CheckCardDueDates(ctx context.Context, now time.Time, within time.Duration) error {
+	cards, err := models.GetAllCards(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, card := range cards {
+		if card.DueDay <= 0 {
+			continue
+		}
+
+		due := nextDueDate(card, now)
+		if due.Sub(now) > within {
+			continue
+		}
+
+		month, year := int64(due.Month()), int64(due.Year())
+		total, err := models.GetCardStatement(ctx, card, month, year)
+		if err != nil {
+			return err
+		}
+
+		err = Notify(ctx, models.Notification{
+			OwnerID: card.OwnerID,
+			Event:   "card.due",
+			Key:     "card.due:" + card.ID.Hex() + ":" + period(month, year),
+			Title:   card.Alias + " statement is due soon",
+			Message: fmt.Sprintf("Your %s statement of %.2f is due on %s", card.Alias, total, due.Format("2006-01-02")),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,20 @@
+package notifications
+
+import (
+	"budget-tracker-api/models"
+	"context"
+)
+
+// InboxNotifier stores notifications at the user in-app inbox
+type InboxNotifier struct{}
+
+// Name will return the notifier name
+func (i InboxNotifier) Name() string {
+	return "inbox"
+}
+
+// Notify will store a notification at the user inbox
+func (i InboxNotifier) Notify(ctx context.Context, n models.Notification) error {
+	_, err := models.CreateNotification(ctx, n)
+	return err
+}
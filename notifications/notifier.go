@@ -0,0 +1,95 @@
+package notifications
+
+import (
+	"budget-tracker-api/models"
+	"context"
+	"errors"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Notifier defines a channel in which notifications can be sent to users
+type Notifier interface {
+	Name() string
+	Notify(ctx context.Context, n models.Notification) error
+}
+
+// Dispatcher will send notifications through all its notifiers
+type Dispatcher struct {
+	Notifiers []Notifier
+}
+
+// dispatcher is the global dispatcher used by Notify, it only stores notifications at the
+// in-app inbox until Init is called
+var dispatcher = &Dispatcher{Notifiers: []Notifier{InboxNotifier{}}}
+
+// Init will set the notifiers used by Notify
+func Init(notifiers ...Notifier) {
+	dispatcher = &Dispatcher{Notifiers: notifiers}
+}
+
+// Notify will send a notification through the global dispatcher
+func Notify(ctx context.Context, n models.Notification) error {
+	return dispatcher.Dispatch(ctx, n)
+}
+
+// Dispatch will send a notification through every notifier. The notification key is claimed before
+// sending, so notifications already sent (or being sent) are discarded, and a failing notifier won't
+// prevent the remaining ones from being used. Notifications failing on every notifier are released to be
+// sent again when their event is checked again, while the notifiers failing alongside succeeding ones
+// are recorded and retried then
+func (d *Dispatcher) Dispatch(ctx context.Context, n models.Notification) error {
+	claimed, err := models.RegisterNotificationEvent(ctx, n)
+	if err != nil {
+		return err
+	}
+
+	notifiers := d.Notifiers
+	if !claimed {
+		retry, err := models.ClaimFailedNotifiers(ctx, n)
+		if err != nil {
+			return err
+		}
+
+		notifiers = []Notifier{}
+		for _, notifier := range d.Notifiers {
+			for _, name := range retry {
+				if notifier.Name() == name {
+					notifiers = append(notifiers, notifier)
+				}
+			}
+		}
+	}
+
+	failed := []string{}
+	for _, notifier := range notifiers {
+		err := notifier.Notify(ctx, n)
+		if err != nil {
+			log.Errorln("could not send notification", n.Key, "through", notifier.Name(), ":", err)
+			failed = append(failed, notifier.Name())
+		}
+	}
+
+	if len(failed) == 0 {
+		return nil
+	}
+
+	if claimed && len(failed) == len(notifiers) {
+		err = models.ReleaseNotificationEvent(ctx, n)
+		if err != nil {
+			log.Errorln("could not release notification", n.Key, ":", err)
+		}
+		return errors.New("could not send notification " + n.Key + " through any notifier")
+	}
+
+	err = models.RecordFailedNotifiers(ctx, n, failed)
+	if err != nil {
+		return err
+	}
+
+	if len(failed) == len(notifiers) {
+		return errors.New("could not send notification " + n.Key + " through any notifier")
+	}
+
+	return nil
+}
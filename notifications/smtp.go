@@ -0,0 +1,65 @@
+package notifications
+
+import (
+	"budget-tracker-api/models"
+	"context"
+	"errors"
+	"net"
+	"net/smtp"
+	"strings"
+)
+
+// SMTPNotifier sends notifications by email to the user address
+type SMTPNotifier struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+}
+
+// Name will return the notifier name
+func (s SMTPNotifier) Name() string {
+	return "smtp"
+}
+
+// headerValue will make a text safe to be used as an email header value, replacing every line break
+// (either CR or LF) by a space
+func headerValue(text string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '\r' || r == '\n' {
+			return ' '
+		}
+		return r
+	}, text)
+}
+
+// Notify will send a notification by email, users without an email address are skipped
+func (s SMTPNotifier) Notify(ctx context.Context, n models.Notification) error {
+	user, err := models.GetUser(ctx, n.OwnerID.Hex())
+	if err != nil {
+		return err
+	}
+
+	if user.Email == "" {
+		return nil
+	}
+
+	if strings.ContainsAny(user.Email, "\r\n") {
+		return errors.New("invalid email address")
+	}
+
+	var auth smtp.Auth
+	if s.Username != "" {
+		auth = smtp.PlainAuth("", s.Username, s.Password, s.Host)
+	}
+
+	msg := "From: " + s.From + "\r\n" +
+		"To: " + user.Email + "\r\n" +
+		"Subject: " + headerValue(n.Title) + "\r\n" +
+		"Content-Type: text/plain; charset=UTF-8\r\n" +
+		"\r\n" +
+		n.Message + "\r\n"
+
+	return smtp.SendMail(net.JoinHostPort(s.Host, s.Port), auth, s.From, []string{user.Email}, []byte(msg))
+}
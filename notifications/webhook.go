@@ -0,0 +1,54 @@
+package notifications
+
+import (
+	"budget-tracker-api/models"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// WebhookNotifier sends notifications as a JSON payload to a generic HTTP endpoint
+type WebhookNotifier struct {
+	URL    string
+	Client *http.Client
+}
+
+// Name will return the notifier name
+func (w WebhookNotifier) Name() string {
+	return "webhook"
+}
+
+// Notify will POST a notification to the webhook URL
+func (w WebhookNotifier) Notify(ctx context.Context, n models.Notification) error {
+	payload, err := json.Marshal(n)
+	if err != nil {
+		return err
+	}
+
+	client := w.Client
+	if client == nil {
+		client = &http.Client{Timeout: 5 * time.Second}
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+
+	request.Header.Set("Content-Type", "application/json")
+
+	response, err := client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded with status %d", response.StatusCode)
+	}
+
+	return nil
+}
@@ -28,12 +28,19 @@ type MetricsSpends struct {
 	SpendsCreated prometheus.Counter
 }
 
+// MetricsRecurring will return a set of recurring rules' related prometheus metrics
+type MetricsRecurring struct {
+	RulesCreated            prometheus.Counter
+	OccurrencesMaterialized prometheus.Counter
+}
+
 // MetricsCollectors will return a struct with all metrics collectors
 type MetricsCollectors struct {
-	Users    *MetricsUsers
-	Cards    *MetricsCards
-	Balances *MetricsBalances
-	Spends   *MetricsSpends
+	Users     *MetricsUsers
+	Cards     *MetricsCards
+	Balances  *MetricsBalances
+	Spends    *MetricsSpends
+	Recurring *MetricsRecurring
 }
 
 // InitMetrics will
@@ -58,6 +65,16 @@ func InitMetrics() (m *MetricsCollectors) {
 		Help: "The total number of created spends",
 	})
 
+	recurringRulesCreated := promauto.NewCounter(prometheus.CounterOpts{
+		Name: "budget_tracker_recurring_rules_created_total",
+		Help: "The total number of created recurring rules",
+	})
+
+	recurringOccurrencesMaterialized := promauto.NewCounter(prometheus.CounterOpts{
+		Name: "budget_tracker_recurring_occurrences_materialized_total",
+		Help: "The total number of spends and incomes materialized from recurring rules",
+	})
+
 	Metrics = &MetricsCollectors{
 		&MetricsUsers{
 			UsersCreated: usersCreated,
@@ -71,6 +88,10 @@ func InitMetrics() (m *MetricsCollectors) {
 		&MetricsSpends{
 			SpendsCreated: spendsCreated,
 		},
+		&MetricsRecurring{
+			RulesCreated:            recurringRulesCreated,
+			OccurrencesMaterialized: recurringOccurrencesMaterialized,
+		},
 	}
 
 	prometheus.Unregister(prometheus.NewGoCollector())
@@ -78,6 +99,8 @@ func InitMetrics() (m *MetricsCollectors) {
 	prometheus.Register(Metrics.Cards.CardsCreated)
 	prometheus.Register(Metrics.Balances.BalancesCreated)
 	prometheus.Register(Metrics.Spends.SpendsCreated)
+	prometheus.Register(Metrics.Recurring.RulesCreated)
+	prometheus.Register(Metrics.Recurring.OccurrencesMaterialized)
 
 	return m
 }
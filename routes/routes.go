@@ -366,7 +366,8 @@ func InitRoutes(service string, router *mux.Router) {
 
 	// swagger:operation POST /api/v1/balance Balance create
 	//
-	// Creates a single balance for a given owner
+	// Creates a single balance for a given owner. Salaries informed only by their gross income have
+	// their net income derived from INSS and IRRF tables
 	// ---
 	// consumes:
 	// - application/json
@@ -381,6 +382,9 @@ func InitRoutes(service string, router *mux.Router) {
 	//   in: owner_id
 	//   description: balance owner_id
 	//   required: true
+	// - name: dependents
+	//   in: query
+	//   description: number of dependents used when deriving net salaries
 	// responses:
 	//   '201':
 	//     description: deleted user
@@ -435,9 +439,307 @@ func InitRoutes(service string, router *mux.Router) {
 	//     type: json
 	router.Handle("/api/v1/balance/{owner_id}", m.JSON(m.Auth(h.GetBalanceHandler))).Methods("GET")
 
+	// swagger:operation PUT /api/v1/balance/{owner_id}/budgets Balance budgets
+	//
+	// Replaces the category budgets from a given month balance. Unspent amounts from previous month
+	// budgets with 'carry_over' enabled are added to the same category budget
+	// ---
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: content-type
+	//   in: headers
+	//   description: application/json
+	//   required: true
+	// - name: owner_id
+	//   in: owner_id
+	//   description: owner id
+	//   required: true
+	// - name: month
+	//   in: query
+	//   description: month
+	//   required: true
+	// - name: year
+	//   in: query
+	//   description: year
+	//   required: true
+	// - name: body
+	//   in: body
+	//   description: category budgets
+	//   required: true
+	//   schema:
+	//     type: array
+	//     items:
+	//       "$ref": "#/definitions/CategoryBudget"
+	// responses:
+	//   '200':
+	//     description: budgets set
+	//     examples:
+	//       application/json: { "message": "set budgets to balance <MONTH>/<YEAR>" }
+	//     type: json
+	//   '400':
+	//     description: bad request
+	//     examples:
+	//       application/json: { "message": "could not set budgets", "details": "invalid or missing 'month' param" }
+	//     type: json
+	//   '422':
+	//     description: invalid budgets
+	//     schema:
+	//       "$ref": "#/definitions/ValidationErrors"
+	//   '500':
+	//     description: internal server error
+	//     examples:
+	//       application/json: { "message": "could not set budgets", "details": "<ERROR_DETAILS>" }
+	//     type: json
+	router.Handle("/api/v1/balance/{owner_id}/budgets", m.JSON(m.Auth(h.SetBudgetsHandler))).Methods("PUT")
+
+	// swagger:operation GET /api/v1/balance/{owner_id}/budgets Balance budgets
+	//
+	// Returns how much was spent from each category budget of a given month balance, net of the refunds
+	// received within the month
+	// ---
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: content-type
+	//   in: headers
+	//   description: application/json
+	//   required: true
+	// - name: owner_id
+	//   in: owner_id
+	//   description: owner id
+	//   required: true
+	// - name: month
+	//   in: query
+	//   description: month
+	//   required: true
+	// - name: year
+	//   in: query
+	//   description: year
+	//   required: true
+	// responses:
+	//   '200':
+	//     description: budgets progress response
+	//     schema:
+	//       type: array
+	//       items:
+	//         "$ref": "#/definitions/BudgetProgress"
+	//   '404':
+	//     description: balance not found
+	//     examples:
+	//       application/json: { "message": "could not get budgets progress", "details": "non existent balance" }
+	//     type: json
+	//   '500':
+	//     description: internal server error
+	//     examples:
+	//       application/json: {"message": "<ERROR_DETAILS>"}
+	//     type: json
+	router.Handle("/api/v1/balance/{owner_id}/budgets", m.JSON(m.Auth(h.GetBudgetsProgressHandler))).Methods("GET")
+
+	// swagger:operation POST /api/v1/balance/{owner_id}/close Balance close
+	//
+	// Closes a given month balance, rejecting further spends to it and keeping its spendable amount as
+	// the final leftover. With 'carry_over' the leftover is added to the next month income
+	// ---
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: content-type
+	//   in: headers
+	//   description: application/json
+	//   required: true
+	// - name: owner_id
+	//   in: owner_id
+	//   description: owner id
+	//   required: true
+	// - name: month
+	//   in: query
+	//   description: month
+	//   required: true
+	// - name: year
+	//   in: query
+	//   description: year
+	//   required: true
+	// - name: carry_over
+	//   in: query
+	//   description: carry the leftover over to the next month income
+	// responses:
+	//   '200':
+	//     description: closed balance
+	//     schema:
+	//       "$ref": "#/definitions/Balance"
+	//   '400':
+	//     description: bad request
+	//     examples:
+	//       application/json: { "message": "could not close balance", "details": "invalid or missing 'month' param" }
+	//     type: json
+	//   '404':
+	//     description: balance not found
+	//     examples:
+	//       application/json: { "message": "could not close balance", "details": "non existent balance" }
+	//     type: json
+	//   '409':
+	//     description: balance already closed
+	//     examples:
+	//       application/json: { "message": "could not close balance", "details": "balance is closed" }
+	//     type: json
+	//   '500':
+	//     description: internal server error
+	//     examples:
+	//       application/json: { "message": "could not close balance", "details": "<ERROR_DETAILS>" }
+	//     type: json
+	router.Handle("/api/v1/balance/{owner_id}/close", m.JSON(m.Auth(h.CloseBalanceHandler))).Methods("POST")
+
+	// swagger:operation PATCH /api/v1/balance/{owner_id} Balance update
+	//
+	// Updates the incomes and currency from a given month balance. Income changes are reflected at the
+	// spendable amount
+	// ---
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: content-type
+	//   in: headers
+	//   description: application/json
+	//   required: true
+	// - name: owner_id
+	//   in: owner_id
+	//   description: owner id
+	//   required: true
+	// - name: month
+	//   in: query
+	//   description: month
+	//   required: true
+	// - name: year
+	//   in: query
+	//   description: year
+	//   required: true
+	// - name: body
+	//   in: body
+	//   description: balance attributes to update
+	//   required: true
+	//   schema:
+	//     "$ref": "#/definitions/BalanceUpdate"
+	// responses:
+	//   '200':
+	//     description: updated balance
+	//     examples:
+	//       application/json: { "message": "updated balance <MONTH>/<YEAR>" }
+	//     type: json
+	//   '400':
+	//     description: bad request
+	//     examples:
+	//       application/json: { "message": "could not update balance", "details": "<ERROR_DETAILS>" }
+	//     type: json
+	//   '404':
+	//     description: balance not found
+	//     examples:
+	//       application/json: { "message": "could not update balance", "details": "non existent balance" }
+	//     type: json
+	//   '409':
+	//     description: balance closed
+	//     examples:
+	//       application/json: { "message": "could not update balance", "details": "balance is closed" }
+	//     type: json
+	router.Handle("/api/v1/balance/{owner_id}", m.JSON(m.Auth(h.UpdateBalanceHandler))).Methods("PATCH")
+
+	// swagger:operation POST /api/v1/balance/{owner_id}/recompute Balance recompute
+	//
+	// Rebuilds the historic, outcome and spendable amount from a given month balance based on its spends
+	// ---
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: content-type
+	//   in: headers
+	//   description: application/json
+	//   required: true
+	// - name: owner_id
+	//   in: owner_id
+	//   description: owner id
+	//   required: true
+	// - name: month
+	//   in: query
+	//   description: month
+	//   required: true
+	// - name: year
+	//   in: query
+	//   description: year
+	//   required: true
+	// responses:
+	//   '200':
+	//     description: recomputed balance
+	//     schema:
+	//       "$ref": "#/definitions/Balance"
+	//   '400':
+	//     description: bad request
+	//     examples:
+	//       application/json: { "message": "could not recompute balance", "details": "invalid or missing 'month' param" }
+	//     type: json
+	//   '404':
+	//     description: balance not found
+	//     examples:
+	//       application/json: { "message": "could not recompute balance", "details": "non existent balance" }
+	//     type: json
+	//   '409':
+	//     description: balance closed
+	//     examples:
+	//       application/json: { "message": "could not recompute balance", "details": "balance is closed" }
+	//     type: json
+	//   '500':
+	//     description: internal server error
+	//     examples:
+	//       application/json: { "message": "could not recompute balance", "details": "<ERROR_DETAILS>" }
+	//     type: json
+	router.Handle("/api/v1/balance/{owner_id}/recompute", m.JSON(m.Auth(h.RecomputeBalanceHandler))).Methods("POST")
+
+	// swagger:operation GET /api/v1/balance/{owner_id}/summary Balance summary
+	//
+	// Summarizes the balances from a given owner during a year: income and outcome totals, savings rate per
+	// month, best and worst months and the categories ranked by how much was spent on them
+	// ---
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: content-type
+	//   in: headers
+	//   description: application/json
+	//   required: true
+	// - name: owner_id
+	//   in: owner_id
+	//   description: owner id
+	//   required: true
+	// - name: year
+	//   in: query
+	//   description: year
+	//   required: true
+	// responses:
+	//   '200':
+	//     description: yearly summary response
+	//     schema:
+	//       "$ref": "#/definitions/YearlySummary"
+	//   '400':
+	//     description: bad request
+	//     examples:
+	//       application/json: { "message": "could not get yearly summary", "details": "invalid or missing 'year' param" }
+	//     type: json
+	//   '500':
+	//     description: internal server error
+	//     examples:
+	//       application/json: { "message": "could not get yearly summary", "details": "<ERROR_DETAILS>" }
+	//     type: json
+	router.Handle("/api/v1/balance/{owner_id}/summary", m.JSON(m.Auth(h.GetYearlySummaryHandler))).Methods("GET")
+
 	// swagger:operation POST /api/v1/spends Spends create
 	//
-	// Creates a single spend for a given owner
+	// Creates a single spend for a given owner. Credit spends with 'installments' will be split
+	// into one spend per card statement. Spends with 'lines' and/or 'shares' will be split into one
+	// spend per category line booked to the owner, while shares wait for their users to accept them
+	// (see /api/v1/shares) before being booked to the balance of the user who owes them
 	// ---
 	// consumes:
 	// - application/json
@@ -458,11 +760,15 @@ func InitRoutes(service string, router *mux.Router) {
 	//     examples:
 	//       application/json: { "message": "created spend to user '<OWNER_ID>'", "id": "<SPEND_ID>"}
 	//     type: json
-	//   '400':
-	//     description: bad request
+	//   '409':
+	//     description: spend month balance is closed
 	//     examples:
-	//       application/json: {"message": "could not create spend", "details": "missing owner ID"}
+	//       application/json: { "message": "could not create spend", "details": "balance is closed" }
 	//     type: json
+	//   '422':
+	//     description: invalid spend attributes
+	//     schema:
+	//       "$ref": "#/definitions/ValidationErrors"
 	//   '500':
 	//     description: internal server error
 	//     examples:
@@ -472,7 +778,8 @@ func InitRoutes(service string, router *mux.Router) {
 
 	// swagger:operation GET /api/v1/spends/{owner_id} Spends list
 	//
-	// Get all spends for a given owner id
+	// Get a page of spends for a given owner id, filtered and sorted by query params.
+	// A 'Link' header with rel="next" points to the next page when there are more spends
 	// ---
 	// produces:
 	// - application/json
@@ -484,6 +791,51 @@ func InitRoutes(service string, router *mux.Router) {
 	// - name: owner_id
 	//   in: owner_id
 	//   description: owner id
+	// - name: category
+	//   in: query
+	//   description: spend category name (can be repeated)
+	// - name: category_id
+	//   in: query
+	//   description: spend category id (can be repeated)
+	// - name: tag
+	//   in: query
+	//   description: spend tag (can be repeated)
+	// - name: type
+	//   in: query
+	//   description: spend type (fixed or dynamic)
+	// - name: payment_method
+	//   in: query
+	//   description: payment method (credit, debit or payment_slip)
+	// - name: card_id
+	//   in: query
+	//   description: credit card id
+	// - name: min_cost
+	//   in: query
+	//   description: minimum spend cost
+	// - name: max_cost
+	//   in: query
+	//   description: maximum spend cost
+	// - name: from
+	//   in: query
+	//   description: start date (RFC3339 or YYYY-MM-DD)
+	// - name: to
+	//   in: query
+	//   description: end date (RFC3339 or YYYY-MM-DD, inclusive)
+	// - name: timezone
+	//   in: query
+	//   description: timezone of plain from and to dates (defaults to UTC)
+	// - name: description
+	//   in: query
+	//   description: case insensitive text to be found at the spend description
+	// - name: sort
+	//   in: query
+	//   description: sorting field (date, cost or created_at), prefixed by '-' for descending order. Defaults to '-date'
+	// - name: limit
+	//   in: query
+	//   description: page size, defaults to 50 (max 500)
+	// - name: cursor
+	//   in: query
+	//   description: opaque cursor returned by the 'Link' header
 	// responses:
 	//   '200':
 	//     description: spends response
@@ -491,10 +843,2111 @@ func InitRoutes(service string, router *mux.Router) {
 	//       type: array
 	//       items:
 	//         "$ref": "#/definitions/Spend"
+	//   '400':
+	//     description: bad request
+	//     examples:
+	//       application/json: {"message": "could not list spends", "details": "invalid 'sort' value"}
+	//     type: json
 	//   '500':
 	//     description: internal server error
 	//     examples:
 	//       application/json: {"message": "<ERROR_DETAILS>"}
 	//     type: json
 	router.Handle("/api/v1/spends/{owner_id}", m.JSON(m.Auth(h.GetSpendsHandler))).Methods("GET")
+
+	// swagger:operation GET /api/v1/spends/{owner_id}/search Spends search
+	//
+	// Searches spends from a given owner by text at their descriptions, notes, categories and tags, the
+	// most relevant ones first. Quoted phrases must match as a whole and '-' prefixed words exclude spends.
+	// Matches are highlighted with <em> tags
+	// ---
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: content-type
+	//   in: headers
+	//   description: application/json
+	//   required: true
+	// - name: owner_id
+	//   in: owner_id
+	//   description: owner id
+	//   required: true
+	// - name: q
+	//   in: query
+	//   description: searched text
+	//   required: true
+	// - name: from
+	//   in: query
+	//   description: spends from date (RFC3339 or YYYY-MM-DD)
+	// - name: to
+	//   in: query
+	//   description: spends until date (RFC3339 or YYYY-MM-DD)
+	// - name: timezone
+	//   in: query
+	//   description: timezone of plain from and to dates (defaults to UTC)
+	// - name: min_cost
+	//   in: query
+	//   description: minimum spend cost
+	// - name: max_cost
+	//   in: query
+	//   description: maximum spend cost
+	// - name: limit
+	//   in: query
+	//   description: maximum number of spends (default 20, max 100)
+	// responses:
+	//   '200':
+	//     description: search results
+	//     schema:
+	//       type: array
+	//       items:
+	//         "$ref": "#/definitions/SearchResult"
+	//   '400':
+	//     description: bad request
+	//     examples:
+	//       application/json: { "message": "could not search spends", "details": "missing 'q' param" }
+	//     type: json
+	router.Handle("/api/v1/spends/{owner_id}/search", m.JSON(m.Auth(h.SearchSpendsHandler))).Methods("GET")
+
+	// swagger:operation PATCH /api/v1/spends/{id} Spends update
+	//
+	// Updates the tags and notes from a spend. Tags are lower cased and spaces become dashes
+	// ---
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: content-type
+	//   in: headers
+	//   description: application/json
+	//   required: true
+	// - name: id
+	//   in: id
+	//   description: spend id
+	//   required: true
+	// - name: body
+	//   in: body
+	//   description: spend tags and notes
+	//   required: true
+	//   schema:
+	//     "$ref": "#/definitions/SpendUpdate"
+	// responses:
+	//   '200':
+	//     description: updated spend
+	//     examples:
+	//       application/json: { "message": "updated spend '<SPEND_ID>'" }
+	//     type: json
+	//   '404':
+	//     description: spend not found
+	//     examples:
+	//       application/json: { "message": "could not update spend", "details": "non existent spend" }
+	//     type: json
+	//   '422':
+	//     description: invalid tags or notes
+	//     schema:
+	//       "$ref": "#/definitions/ValidationErrors"
+	router.Handle("/api/v1/spends/{id}", m.JSON(m.Auth(h.UpdateSpendHandler))).Methods("PATCH")
+
+	// swagger:operation GET /api/v1/tags/{owner_id} Tags list
+	//
+	// List the tags from a given owner starting with a prefix, the most used ones first, for autocompletion
+	// ---
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: content-type
+	//   in: headers
+	//   description: application/json
+	//   required: true
+	// - name: owner_id
+	//   in: owner_id
+	//   description: owner id
+	//   required: true
+	// - name: prefix
+	//   in: query
+	//   description: tag prefix
+	// - name: limit
+	//   in: query
+	//   description: maximum number of tags (default 10)
+	// responses:
+	//   '200':
+	//     description: tags response
+	//     examples:
+	//       application/json: [{ "tag": "trip-2026", "count": 12 }]
+	//     type: json
+	router.Handle("/api/v1/tags/{owner_id}", m.JSON(m.Auth(h.GetTagsHandler))).Methods("GET")
+
+	// swagger:operation DELETE /api/v1/spends/{id} Spends delete
+	//
+	// Deletes a spend, removing it from its balance along with its attachments. Deleting an installment
+	// plan or a split spend deletes all its installments or parts, which can't be deleted on their own.
+	// Spends from closed balances or with refunds can't be deleted
+	// ---
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: content-type
+	//   in: headers
+	//   description: application/json
+	//   required: true
+	// - name: id
+	//   in: id
+	//   description: spend id
+	//   required: true
+	// responses:
+	//   '200':
+	//     description: deleted spend
+	//     examples:
+	//       application/json: { "message": "deleted spend '<SPEND_ID>'" }
+	//     type: json
+	//   '404':
+	//     description: spend not found
+	//     examples:
+	//       application/json: { "message": "could not delete spend", "details": "non existent spend" }
+	//     type: json
+	//   '409':
+	//     description: closed balance, refunded spend or split spend part
+	//     examples:
+	//       application/json: { "message": "could not delete spend", "details": "balance is closed" }
+	//     type: json
+	router.Handle("/api/v1/spends/{id}", m.JSON(m.Auth(h.DeleteSpendHandler))).Methods("DELETE")
+
+	// swagger:operation GET /api/v1/installments/{owner_id} Spends installments
+	//
+	// List the remaining installments for a given owner grouped by card, along with the future commitment of each card
+	// ---
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: content-type
+	//   in: headers
+	//   description: application/json
+	//   required: true
+	// - name: owner_id
+	//   in: owner_id
+	//   description: owner id
+	//   required: true
+	// - name: card_id
+	//   in: query
+	//   description: credit card id
+	// responses:
+	//   '200':
+	//     description: installments response
+	//     schema:
+	//       type: array
+	//       items:
+	//         "$ref": "#/definitions/CardInstallments"
+	//   '500':
+	//     description: internal server error
+	//     examples:
+	//       application/json: {"message": "<ERROR_DETAILS>"}
+	//     type: json
+	router.Handle("/api/v1/installments/{owner_id}", m.JSON(m.Auth(h.GetInstallmentsHandler))).Methods("GET")
+
+	// swagger:operation POST /api/v1/recurring Recurring create
+	//
+	// Creates a recurring spend or income (monthly, weekly or yearly) for a given owner
+	// ---
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: content-type
+	//   in: headers
+	//   description: application/json
+	//   required: true
+	// - name: body
+	//   in: body
+	//   description: recurring rule payload
+	//   required: true
+	//   schema:
+	//     "$ref": "#/definitions/RecurringRule"
+	// responses:
+	//   '201':
+	//     description: created recurring rule
+	//     examples:
+	//       application/json: { "message": "created recurring rule to user '<OWNER_ID>'", "id": "<RULE_ID>" }
+	//     type: json
+	//   '400':
+	//     description: bad request
+	//     examples:
+	//       application/json: {"message": "could not create recurring rule", "details": "invalid frequency '<FREQUENCY>'"}
+	//     type: json
+	//   '422':
+	//     description: invalid spend template attributes
+	//     schema:
+	//       "$ref": "#/definitions/ValidationErrors"
+	//   '500':
+	//     description: internal server error
+	//     examples:
+	//       application/json: { "message": "could not create recurring rule", "details": "<ERROR_DETAILS>" }
+	//     type: json
+	router.Handle("/api/v1/recurring", m.JSON(m.Auth(h.CreateRecurringRuleHandler))).Methods("POST")
+
+	// swagger:operation GET /api/v1/recurring/{owner_id} Recurring list
+	//
+	// List all recurring rules from a given owner
+	// ---
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: content-type
+	//   in: headers
+	//   description: application/json
+	//   required: true
+	// - name: owner_id
+	//   in: owner_id
+	//   description: owner id
+	//   required: true
+	// responses:
+	//   '200':
+	//     description: recurring rules response
+	//     schema:
+	//       type: array
+	//       items:
+	//         "$ref": "#/definitions/RecurringRule"
+	//   '500':
+	//     description: internal server error
+	//     examples:
+	//       application/json: {"message": "<ERROR_DETAILS>"}
+	//     type: json
+	router.Handle("/api/v1/recurring/{owner_id}", m.JSON(m.Auth(h.GetRecurringRulesHandler))).Methods("GET")
+
+	// swagger:operation PATCH /api/v1/recurring/{id} Recurring update
+	//
+	// Edits a recurring rule, only future occurrences are affected
+	// ---
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: content-type
+	//   in: headers
+	//   description: application/json
+	//   required: true
+	// - name: id
+	//   in: id
+	//   description: recurring rule id
+	//   required: true
+	// - name: body
+	//   in: body
+	//   description: recurring rule attributes to be updated
+	//   required: true
+	//   schema:
+	//     "$ref": "#/definitions/RecurringRuleUpdate"
+	// responses:
+	//   '200':
+	//     description: updated recurring rule
+	//     examples:
+	//       application/json: { "message": "updated recurring rule '<RULE_ID>'" }
+	//     type: json
+	//   '400':
+	//     description: bad request
+	//     examples:
+	//       application/json: { "message": "could not update recurring rule", "details": "<ERROR_DETAILS>" }
+	//     type: json
+	router.Handle("/api/v1/recurring/{id}", m.JSON(m.Auth(h.UpdateRecurringRuleHandler))).Methods("PATCH")
+
+	// swagger:operation POST /api/v1/recurring/{id}/pause Recurring pause
+	//
+	// Pauses a recurring rule, no occurrences will be created until it's resumed
+	// ---
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: content-type
+	//   in: headers
+	//   description: application/json
+	//   required: true
+	// - name: id
+	//   in: id
+	//   description: recurring rule id
+	//   required: true
+	// responses:
+	//   '200':
+	//     description: paused recurring rule
+	//     examples:
+	//       application/json: { "message": "paused recurring rule '<RULE_ID>'" }
+	//     type: json
+	//   '500':
+	//     description: internal server error
+	//     examples:
+	//       application/json: { "message": "could not pause recurring rule", "details": "<ERROR_DETAILS>" }
+	//     type: json
+	router.Handle("/api/v1/recurring/{id}/pause", m.JSON(m.Auth(h.PauseRecurringRuleHandler))).Methods("POST")
+
+	// swagger:operation POST /api/v1/recurring/{id}/resume Recurring resume
+	//
+	// Resumes a paused recurring rule, occurrences skipped while paused are not created
+	// ---
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: content-type
+	//   in: headers
+	//   description: application/json
+	//   required: true
+	// - name: id
+	//   in: id
+	//   description: recurring rule id
+	//   required: true
+	// responses:
+	//   '200':
+	//     description: resumed recurring rule
+	//     examples:
+	//       application/json: { "message": "resumed recurring rule '<RULE_ID>'" }
+	//     type: json
+	//   '500':
+	//     description: internal server error
+	//     examples:
+	//       application/json: { "message": "could not resume recurring rule", "details": "<ERROR_DETAILS>" }
+	//     type: json
+	router.Handle("/api/v1/recurring/{id}/resume", m.JSON(m.Auth(h.ResumeRecurringRuleHandler))).Methods("POST")
+
+	// swagger:operation POST /api/v1/categories Categories create
+	//
+	// Creates a category for a given owner, optionally nested under a parent category
+	// ---
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: content-type
+	//   in: headers
+	//   description: application/json
+	//   required: true
+	// - name: body
+	//   in: body
+	//   description: category payload
+	//   required: true
+	//   schema:
+	//     "$ref": "#/definitions/Category"
+	// responses:
+	//   '201':
+	//     description: created category
+	//     examples:
+	//       application/json: { "message": "created category '<CATEGORY_NAME>'", "id": "<CATEGORY_ID>" }
+	//     type: json
+	//   '400':
+	//     description: bad request
+	//     examples:
+	//       application/json: { "message": "could not create category", "details": "non existent parent category" }
+	//     type: json
+	//   '409':
+	//     description: category already exists
+	//     examples:
+	//       application/json: { "message": "could not create category", "details": "category '<CATEGORY_NAME>' already exists" }
+	//     type: json
+	router.Handle("/api/v1/categories", m.JSON(m.Auth(h.CreateCategoryHandler))).Methods("POST")
+
+	// swagger:operation GET /api/v1/categories/{owner_id} Categories list
+	//
+	// List all categories from a given owner
+	// ---
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: content-type
+	//   in: headers
+	//   description: application/json
+	//   required: true
+	// - name: owner_id
+	//   in: owner_id
+	//   description: owner id
+	//   required: true
+	// - name: tree
+	//   in: query
+	//   description: nest categories under their parents
+	// responses:
+	//   '200':
+	//     description: categories response
+	//     schema:
+	//       type: array
+	//       items:
+	//         "$ref": "#/definitions/Category"
+	//   '500':
+	//     description: internal server error
+	//     examples:
+	//       application/json: {"message": "<ERROR_DETAILS>"}
+	//     type: json
+	router.Handle("/api/v1/categories/{owner_id}", m.JSON(m.Auth(h.GetCategoriesHandler))).Methods("GET")
+
+	// swagger:operation POST /api/v1/categories/{owner_id}/defaults Categories seed
+	//
+	// Creates the default categories to a given owner which has no categories yet. Users get them on sign up
+	// ---
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: content-type
+	//   in: headers
+	//   description: application/json
+	//   required: true
+	// - name: owner_id
+	//   in: owner_id
+	//   description: owner id
+	//   required: true
+	// responses:
+	//   '200':
+	//     description: seeded categories
+	//     examples:
+	//       application/json: { "message": "seeded default categories to user '<OWNER_ID>'" }
+	//     type: json
+	//   '400':
+	//     description: bad request
+	//     examples:
+	//       application/json: { "message": "could not seed categories", "details": "invalid owner ID" }
+	//     type: json
+	//   '500':
+	//     description: internal server error
+	//     examples:
+	//       application/json: { "message": "could not seed categories", "details": "<ERROR_DETAILS>" }
+	//     type: json
+	router.Handle("/api/v1/categories/{owner_id}/defaults", m.JSON(m.Auth(h.SeedDefaultCategoriesHandler))).Methods("POST")
+
+	// swagger:operation PATCH /api/v1/categories/{id} Categories update
+	//
+	// Edits a category, renaming it on every spend, split line, budget and recurring rule referencing it
+	// ---
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: content-type
+	//   in: headers
+	//   description: application/json
+	//   required: true
+	// - name: id
+	//   in: id
+	//   description: category id
+	//   required: true
+	// - name: body
+	//   in: body
+	//   description: category attributes to be updated
+	//   required: true
+	//   schema:
+	//     "$ref": "#/definitions/CategoryUpdate"
+	// responses:
+	//   '200':
+	//     description: updated category
+	//     examples:
+	//       application/json: { "message": "updated category '<CATEGORY_ID>'" }
+	//     type: json
+	//   '400':
+	//     description: bad request
+	//     examples:
+	//       application/json: { "message": "could not update category", "details": "<ERROR_DETAILS>" }
+	//     type: json
+	//   '404':
+	//     description: category not found
+	//     examples:
+	//       application/json: { "message": "could not update category", "details": "non existent category" }
+	//     type: json
+	router.Handle("/api/v1/categories/{id}", m.JSON(m.Auth(h.UpdateCategoryHandler))).Methods("PATCH")
+
+	// swagger:operation POST /api/v1/categories/{id}/merge Categories merge
+	//
+	// Merges a category into another one. Every spend, split line, balance historic and budget, categorization
+	// rule, recurring template and child category is moved to the target category, which can't be one of the
+	// merged category descendants
+	// ---
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: content-type
+	//   in: headers
+	//   description: application/json
+	//   required: true
+	// - name: id
+	//   in: id
+	//   description: category id to be merged
+	//   required: true
+	// - name: body
+	//   in: body
+	//   description: target category
+	//   required: true
+	//   schema:
+	//     "$ref": "#/definitions/CategoryMerge"
+	// responses:
+	//   '200':
+	//     description: merged category
+	//     examples:
+	//       application/json: { "message": "merged category '<CATEGORY_ID>' into '<TARGET_ID>'", "spends": 10 }
+	//     type: json
+	//   '400':
+	//     description: bad request
+	//     examples:
+	//       application/json: { "message": "could not merge category", "details": "<ERROR_DETAILS>" }
+	//     type: json
+	//   '404':
+	//     description: category not found
+	//     examples:
+	//       application/json: { "message": "could not merge category", "details": "non existent category" }
+	//     type: json
+	router.Handle("/api/v1/categories/{id}/merge", m.JSON(m.Auth(h.MergeCategoryHandler))).Methods("POST")
+
+	// swagger:operation POST /api/v1/categorization/rules Categorization create
+	//
+	// Creates a rule to automatically categorize spends matching its conditions
+	// ---
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: content-type
+	//   in: headers
+	//   description: application/json
+	//   required: true
+	// - name: body
+	//   in: body
+	//   description: categorization rule payload
+	//   required: true
+	//   schema:
+	//     "$ref": "#/definitions/CategorizationRule"
+	// responses:
+	//   '201':
+	//     description: created categorization rule
+	//     examples:
+	//       application/json: { "message": "created categorization rule to user '<OWNER_ID>'", "id": "<RULE_ID>" }
+	//     type: json
+	//   '422':
+	//     description: invalid categorization rule attributes
+	//     schema:
+	//       "$ref": "#/definitions/ValidationErrors"
+	//   '500':
+	//     description: internal server error
+	//     examples:
+	//       application/json: { "message": "could not create categorization rule", "details": "<ERROR_DETAILS>" }
+	//     type: json
+	router.Handle("/api/v1/categorization/rules", m.JSON(m.Auth(h.CreateCategorizationRuleHandler))).Methods("POST")
+
+	// swagger:operation GET /api/v1/categorization/rules/{owner_id} Categorization list
+	//
+	// List all categorization rules from a given owner sorted by priority
+	// ---
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: content-type
+	//   in: headers
+	//   description: application/json
+	//   required: true
+	// - name: owner_id
+	//   in: owner_id
+	//   description: owner id
+	//   required: true
+	// responses:
+	//   '200':
+	//     description: categorization rules response
+	//     schema:
+	//       type: array
+	//       items:
+	//         "$ref": "#/definitions/CategorizationRule"
+	//   '500':
+	//     description: internal server error
+	//     examples:
+	//       application/json: {"message": "<ERROR_DETAILS>"}
+	//     type: json
+	router.Handle("/api/v1/categorization/rules/{owner_id}", m.JSON(m.Auth(h.GetCategorizationRulesHandler))).Methods("GET")
+
+	// swagger:operation DELETE /api/v1/categorization/rules/{id} Categorization delete
+	//
+	// Deletes a categorization rule, already categorized spends are kept as they are
+	// ---
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: content-type
+	//   in: headers
+	//   description: application/json
+	//   required: true
+	// - name: id
+	//   in: id
+	//   description: categorization rule id
+	//   required: true
+	// responses:
+	//   '200':
+	//     description: deleted categorization rule
+	//     examples:
+	//       application/json: { "message": "deleted categorization rule '<RULE_ID>'" }
+	//     type: json
+	//   '500':
+	//     description: internal server error
+	//     examples:
+	//       application/json: { "message": "could not delete categorization rule", "details": "<ERROR_DETAILS>" }
+	//     type: json
+	router.Handle("/api/v1/categorization/rules/{id}", m.JSON(m.Auth(h.DeleteCategorizationRuleHandler))).Methods("DELETE")
+
+	// swagger:operation POST /api/v1/categorization/apply/{owner_id} Categorization apply
+	//
+	// Re-applies the categorization rules from a given owner to its past spends. Split spends keep the
+	// categories of their lines
+	// ---
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: content-type
+	//   in: headers
+	//   description: application/json
+	//   required: true
+	// - name: owner_id
+	//   in: owner_id
+	//   description: owner id
+	//   required: true
+	// - name: overwrite
+	//   in: query
+	//   description: also recategorize spends which already have categories
+	// responses:
+	//   '200':
+	//     description: recategorized spends
+	//     examples:
+	//       application/json: { "message": "reapplied categorization rules to user '<OWNER_ID>'", "spends": 10 }
+	//     type: json
+	//   '500':
+	//     description: internal server error
+	//     examples:
+	//       application/json: { "message": "could not reapply categorization rules", "details": "<ERROR_DETAILS>" }
+	//     type: json
+	router.Handle("/api/v1/categorization/apply/{owner_id}", m.JSON(m.Auth(h.ReapplyCategorizationRulesHandler))).Methods("POST")
+
+	// swagger:operation GET /api/v1/notifications/{owner_id} Notifications list
+	//
+	// List the most recent in-app notifications from a given owner
+	// ---
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: content-type
+	//   in: headers
+	//   description: application/json
+	//   required: true
+	// - name: owner_id
+	//   in: owner_id
+	//   description: owner id
+	//   required: true
+	// - name: unread
+	//   in: query
+	//   description: list only unread notifications
+	// responses:
+	//   '200':
+	//     description: notifications response
+	//     schema:
+	//       type: array
+	//       items:
+	//         "$ref": "#/definitions/Notification"
+	//   '500':
+	//     description: internal server error
+	//     examples:
+	//       application/json: {"message": "<ERROR_DETAILS>"}
+	//     type: json
+	router.Handle("/api/v1/notifications/{owner_id}", m.JSON(m.Auth(h.GetNotificationsHandler))).Methods("GET")
+
+	// swagger:operation POST /api/v1/notifications/{id}/read Notifications read
+	//
+	// Marks an in-app notification as read
+	// ---
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: content-type
+	//   in: headers
+	//   description: application/json
+	//   required: true
+	// - name: id
+	//   in: id
+	//   description: notification id
+	//   required: true
+	// responses:
+	//   '200':
+	//     description: read notification
+	//     examples:
+	//       application/json: { "message": "read notification '<NOTIFICATION_ID>'" }
+	//     type: json
+	//   '500':
+	//     description: internal server error
+	//     examples:
+	//       application/json: { "message": "could not read notification", "details": "<ERROR_DETAILS>" }
+	//     type: json
+	router.Handle("/api/v1/notifications/{id}/read", m.JSON(m.Auth(h.ReadNotificationHandler))).Methods("POST")
+
+	// swagger:operation POST /api/v1/balance/{owner_id}/incomes Incomes add
+	//
+	// Adds an income entry to a given month balance, creating the balance when it doesn't exist yet
+	// ---
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: content-type
+	//   in: headers
+	//   description: application/json
+	//   required: true
+	// - name: owner_id
+	//   in: owner_id
+	//   description: owner id
+	//   required: true
+	// - name: month
+	//   in: query
+	//   description: month
+	//   required: true
+	// - name: year
+	//   in: query
+	//   description: year
+	//   required: true
+	// - name: body
+	//   in: body
+	//   description: income entry
+	//   required: true
+	//   schema:
+	//     "$ref": "#/definitions/IncomeEntry"
+	// responses:
+	//   '201':
+	//     description: added income
+	//     examples:
+	//       application/json: { "message": "added income to balance <MONTH>/<YEAR>", "id": "<INCOME_ID>" }
+	//     type: json
+	//   '400':
+	//     description: bad request
+	//     examples:
+	//       application/json: { "message": "could not add income", "details": "malformed payload" }
+	//     type: json
+	//   '409':
+	//     description: balance closed
+	//     examples:
+	//       application/json: { "message": "could not add income", "details": "balance is closed" }
+	//     type: json
+	//   '422':
+	//     description: invalid income
+	//     schema:
+	//       "$ref": "#/definitions/ValidationErrors"
+	//   '500':
+	//     description: internal server error
+	//     examples:
+	//       application/json: { "message": "could not add income", "details": "<ERROR_DETAILS>" }
+	//     type: json
+	router.Handle("/api/v1/balance/{owner_id}/incomes", m.JSON(m.Auth(h.AddIncomeHandler))).Methods("POST")
+
+	// swagger:operation DELETE /api/v1/balance/{owner_id}/incomes/{id} Incomes remove
+	//
+	// Removes an income entry from a given month balance
+	// ---
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: content-type
+	//   in: headers
+	//   description: application/json
+	//   required: true
+	// - name: owner_id
+	//   in: owner_id
+	//   description: owner id
+	//   required: true
+	// - name: id
+	//   in: id
+	//   description: income id
+	//   required: true
+	// - name: month
+	//   in: query
+	//   description: month
+	//   required: true
+	// - name: year
+	//   in: query
+	//   description: year
+	//   required: true
+	// responses:
+	//   '200':
+	//     description: removed income
+	//     examples:
+	//       application/json: { "message": "removed income '<INCOME_ID>'" }
+	//     type: json
+	//   '400':
+	//     description: bad request
+	//     examples:
+	//       application/json: { "message": "could not remove income", "details": "non existent income" }
+	//     type: json
+	//   '404':
+	//     description: balance not found
+	//     examples:
+	//       application/json: { "message": "could not remove income", "details": "non existent balance" }
+	//     type: json
+	//   '409':
+	//     description: balance closed
+	//     examples:
+	//       application/json: { "message": "could not remove income", "details": "balance is closed" }
+	//     type: json
+	router.Handle("/api/v1/balance/{owner_id}/incomes/{id}", m.JSON(m.Auth(h.RemoveIncomeHandler))).Methods("DELETE")
+
+	// swagger:operation GET /api/v1/incomes/{owner_id} Incomes breakdown
+	//
+	// Returns how much a given owner received from each income source during a year, month by month
+	// ---
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: content-type
+	//   in: headers
+	//   description: application/json
+	//   required: true
+	// - name: owner_id
+	//   in: owner_id
+	//   description: owner id
+	//   required: true
+	// - name: year
+	//   in: query
+	//   description: year
+	//   required: true
+	// responses:
+	//   '200':
+	//     description: incomes breakdown response
+	//     schema:
+	//       type: array
+	//       items:
+	//         "$ref": "#/definitions/IncomeBreakdown"
+	//   '400':
+	//     description: bad request
+	//     examples:
+	//       application/json: { "message": "could not get incomes breakdown", "details": "invalid or missing 'year' param" }
+	//     type: json
+	//   '500':
+	//     description: internal server error
+	//     examples:
+	//       application/json: {"message": "<ERROR_DETAILS>"}
+	//     type: json
+	router.Handle("/api/v1/incomes/{owner_id}", m.JSON(m.Auth(h.GetIncomesBreakdownHandler))).Methods("GET")
+
+	// swagger:operation POST /api/v1/taxes/net Taxes net
+	//
+	// Derives a net salary from a gross one, withholding INSS and IRRF based on the tables of the given
+	// year (defaults to the current one). Years without a table are rejected, and the 2026 table waives
+	// part of the IRRF from gross salaries up to 7350.00
+	// ---
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: content-type
+	//   in: headers
+	//   description: application/json
+	//   required: true
+	// - name: body
+	//   in: body
+	//   description: gross salary, year, dependents and other deductions
+	//   required: true
+	//   schema:
+	//     "$ref": "#/definitions/Input"
+	// responses:
+	//   '200':
+	//     description: taxes and net salary
+	//     schema:
+	//       "$ref": "#/definitions/Result"
+	//   '400':
+	//     description: bad request
+	//     examples:
+	//       application/json: { "message": "could not calculate net income", "details": "<ERROR_DETAILS>" }
+	//     type: json
+	router.Handle("/api/v1/taxes/net", m.JSON(m.Auth(h.CalculateNetIncomeHandler))).Methods("POST")
+
+	// swagger:operation POST /api/v1/goals Goals create
+	//
+	// Creates a savings goal for a given owner
+	// ---
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: content-type
+	//   in: headers
+	//   description: application/json
+	//   required: true
+	// - name: body
+	//   in: body
+	//   description: goal
+	//   required: true
+	//   schema:
+	//     "$ref": "#/definitions/Goal"
+	// responses:
+	//   '201':
+	//     description: created goal
+	//     examples:
+	//       application/json: { "message": "created goal '<GOAL_NAME>'", "id": "<GOAL_ID>" }
+	//     type: json
+	//   '422':
+	//     description: invalid goal
+	//     schema:
+	//       "$ref": "#/definitions/ValidationErrors"
+	//   '500':
+	//     description: internal server error
+	//     examples:
+	//       application/json: { "message": "could not create goal", "details": "<ERROR_DETAILS>" }
+	//     type: json
+	router.Handle("/api/v1/goals", m.JSON(m.Auth(h.CreateGoalHandler))).Methods("POST")
+
+	// swagger:operation GET /api/v1/goals/{owner_id} Goals list
+	//
+	// List all goals from a given owner along with their progress and projected completion date
+	// ---
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: content-type
+	//   in: headers
+	//   description: application/json
+	//   required: true
+	// - name: owner_id
+	//   in: owner_id
+	//   description: owner id
+	//   required: true
+	// responses:
+	//   '200':
+	//     description: goals response
+	//     schema:
+	//       type: array
+	//       items:
+	//         "$ref": "#/definitions/GoalProgress"
+	//   '500':
+	//     description: internal server error
+	//     examples:
+	//       application/json: {"message": "<ERROR_DETAILS>"}
+	//     type: json
+	router.Handle("/api/v1/goals/{owner_id}", m.JSON(m.Auth(h.GetGoalsHandler))).Methods("GET")
+
+	// swagger:operation PATCH /api/v1/goals/{id} Goals update
+	//
+	// Updates the name, target amount or deadline from a goal
+	// ---
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: content-type
+	//   in: headers
+	//   description: application/json
+	//   required: true
+	// - name: id
+	//   in: id
+	//   description: goal id
+	//   required: true
+	// - name: body
+	//   in: body
+	//   description: goal attributes to update
+	//   required: true
+	//   schema:
+	//     "$ref": "#/definitions/GoalUpdate"
+	// responses:
+	//   '200':
+	//     description: updated goal
+	//     examples:
+	//       application/json: { "message": "updated goal '<GOAL_ID>'" }
+	//     type: json
+	//   '400':
+	//     description: bad request
+	//     examples:
+	//       application/json: { "message": "could not update goal", "details": "<ERROR_DETAILS>" }
+	//     type: json
+	//   '404':
+	//     description: goal not found
+	//     examples:
+	//       application/json: { "message": "could not update goal", "details": "non existent goal" }
+	//     type: json
+	router.Handle("/api/v1/goals/{id}", m.JSON(m.Auth(h.UpdateGoalHandler))).Methods("PATCH")
+
+	// swagger:operation DELETE /api/v1/goals/{id} Goals delete
+	//
+	// Deletes a goal, keeping its contributions at the balances
+	// ---
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: content-type
+	//   in: headers
+	//   description: application/json
+	//   required: true
+	// - name: id
+	//   in: id
+	//   description: goal id
+	//   required: true
+	// responses:
+	//   '200':
+	//     description: deleted goal
+	//     examples:
+	//       application/json: { "message": "deleted goal '<GOAL_ID>'" }
+	//     type: json
+	//   '404':
+	//     description: goal not found
+	//     examples:
+	//       application/json: { "message": "could not delete goal", "details": "non existent goal" }
+	//     type: json
+	//   '500':
+	//     description: internal server error
+	//     examples:
+	//       application/json: { "message": "could not delete goal", "details": "<ERROR_DETAILS>" }
+	//     type: json
+	router.Handle("/api/v1/goals/{id}", m.JSON(m.Auth(h.DeleteGoalHandler))).Methods("DELETE")
+
+	// swagger:operation POST /api/v1/goals/{id}/contributions Goals contribute
+	//
+	// Saves an amount to a goal, accounting it as savings outcome of the balance from the contribution month,
+	// which is created when it doesn't exist yet
+	// ---
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: content-type
+	//   in: headers
+	//   description: application/json
+	//   required: true
+	// - name: id
+	//   in: id
+	//   description: goal id
+	//   required: true
+	// - name: body
+	//   in: body
+	//   description: contribution
+	//   required: true
+	//   schema:
+	//     "$ref": "#/definitions/GoalContribution"
+	// responses:
+	//   '201':
+	//     description: added contribution
+	//     examples:
+	//       application/json: { "message": "added contribution to goal '<GOAL_ID>'", "id": "<CONTRIBUTION_ID>" }
+	//     type: json
+	//   '400':
+	//     description: bad request
+	//     examples:
+	//       application/json: { "message": "could not add contribution", "details": "contribution amount must be greater than zero" }
+	//     type: json
+	//   '404':
+	//     description: goal not found
+	//     examples:
+	//       application/json: { "message": "could not add contribution", "details": "non existent goal" }
+	//     type: json
+	//   '409':
+	//     description: closed balance
+	//     examples:
+	//       application/json: { "message": "could not add contribution", "details": "balance is closed" }
+	//     type: json
+	router.Handle("/api/v1/goals/{id}/contributions", m.JSON(m.Auth(h.AddGoalContributionHandler))).Methods("POST")
+
+	// swagger:operation GET /api/v1/forecast/{owner_id} Forecast get
+	//
+	// Projects the income, outcome and spendable amount from a given owner for the upcoming months, based on
+	// recurring rules, open installments and the average dynamic spend per category. Every projected value is
+	// broken down into line items along with its assumption
+	// ---
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: content-type
+	//   in: headers
+	//   description: application/json
+	//   required: true
+	// - name: owner_id
+	//   in: owner_id
+	//   description: owner id
+	//   required: true
+	// - name: months
+	//   in: query
+	//   description: how many months to forecast (defaults to 6, up to 24)
+	// - name: timezone
+	//   in: query
+	//   description: timezone bounding the forecast months (defaults to UTC)
+	// responses:
+	//   '200':
+	//     description: forecast response
+	//     schema:
+	//       type: array
+	//       items:
+	//         "$ref": "#/definitions/ForecastMonth"
+	//   '400':
+	//     description: bad request
+	//     examples:
+	//       application/json: { "message": "could not get forecast", "details": "'months' must be between 1 and 24" }
+	//     type: json
+	//   '500':
+	//     description: internal server error
+	//     examples:
+	//       application/json: { "message": "could not get forecast", "details": "<ERROR_DETAILS>" }
+	//     type: json
+	router.Handle("/api/v1/forecast/{owner_id}", m.JSON(m.Auth(h.GetForecastHandler))).Methods("GET")
+
+	// swagger:operation GET /api/v1/reports/{owner_id} Reports totals
+	//
+	// Returns the totals spent by a given owner grouped by category, payment method, card or type (fixed
+	// vs dynamic), optionally by day, week, month or year as well. Received refunds are subtracted at
+	// the period they were credited to, reimbursements are left out of payment method and card totals
+	// ---
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: content-type
+	//   in: headers
+	//   description: application/json
+	//   required: true
+	// - name: owner_id
+	//   in: owner_id
+	//   description: owner id
+	//   required: true
+	// - name: group_by
+	//   in: query
+	//   description: category (default), payment_method, card, type or tag
+	// - name: granularity
+	//   in: query
+	//   description: day, week, month or year
+	// - name: from
+	//   in: query
+	//   description: spends from date (RFC3339 or YYYY-MM-DD)
+	// - name: to
+	//   in: query
+	//   description: spends up to date (RFC3339 or YYYY-MM-DD)
+	// - name: timezone
+	//   in: query
+	//   description: timezone of plain from and to dates and used to group spends by period (defaults to UTC)
+	// responses:
+	//   '200':
+	//     description: report response
+	//     schema:
+	//       "$ref": "#/definitions/Report"
+	//   '400':
+	//     description: bad request
+	//     examples:
+	//       application/json: { "message": "could not get report", "details": "<ERROR_DETAILS>" }
+	//     type: json
+	router.Handle("/api/v1/reports/{owner_id}", m.JSON(m.Auth(h.GetReportHandler))).Methods("GET")
+
+	// swagger:operation GET /api/v1/reports/{owner_id}/compare Reports compare
+	//
+	// Compares the totals spent by a given owner on a month with the previous month and the same month from
+	// the previous year, grouped by category, payment method, card or type
+	// ---
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: content-type
+	//   in: headers
+	//   description: application/json
+	//   required: true
+	// - name: owner_id
+	//   in: owner_id
+	//   description: owner id
+	//   required: true
+	// - name: group_by
+	//   in: query
+	//   description: category (default), payment_method, card, type or tag
+	// - name: month
+	//   in: query
+	//   description: month (defaults to the current one)
+	// - name: year
+	//   in: query
+	//   description: year (defaults to the current one)
+	// responses:
+	//   '200':
+	//     description: comparison response
+	//     schema:
+	//       "$ref": "#/definitions/ReportComparison"
+	//   '400':
+	//     description: bad request
+	//     examples:
+	//       application/json: { "message": "could not compare report", "details": "<ERROR_DETAILS>" }
+	//     type: json
+	router.Handle("/api/v1/reports/{owner_id}/compare", m.JSON(m.Auth(h.CompareReportHandler))).Methods("GET")
+
+	// swagger:operation POST /api/v1/imports/profiles Imports profile
+	//
+	// Creates a profile mapping the columns of a bank statement CSV into spends: date format, decimal
+	// comma and the sign used by spends amounts
+	// ---
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: content-type
+	//   in: headers
+	//   description: application/json
+	//   required: true
+	// - name: body
+	//   in: body
+	//   description: import profile
+	//   required: true
+	//   schema:
+	//     "$ref": "#/definitions/ImportProfile"
+	// responses:
+	//   '201':
+	//     description: created import profile
+	//     examples:
+	//       application/json: { "message": "created import profile '<PROFILE_NAME>'", "id": "<PROFILE_ID>" }
+	//     type: json
+	//   '409':
+	//     description: duplicated import profile
+	//     examples:
+	//       application/json: { "message": "could not create import profile", "details": "import profile '<PROFILE_NAME>' already exists" }
+	//     type: json
+	//   '422':
+	//     description: invalid import profile
+	//     schema:
+	//       "$ref": "#/definitions/ValidationErrors"
+	router.Handle("/api/v1/imports/profiles", m.JSON(m.Auth(h.CreateImportProfileHandler))).Methods("POST")
+
+	// swagger:operation GET /api/v1/imports/profiles/{owner_id} Imports profile
+	//
+	// List all import profiles from a given owner
+	// ---
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: content-type
+	//   in: headers
+	//   description: application/json
+	//   required: true
+	// - name: owner_id
+	//   in: owner_id
+	//   description: owner id
+	//   required: true
+	// responses:
+	//   '200':
+	//     description: import profiles response
+	//     schema:
+	//       type: array
+	//       items:
+	//         "$ref": "#/definitions/ImportProfile"
+	//   '500':
+	//     description: internal server error
+	//     examples:
+	//       application/json: {"message": "<ERROR_DETAILS>"}
+	//     type: json
+	router.Handle("/api/v1/imports/profiles/{owner_id}", m.JSON(m.Auth(h.GetImportProfilesHandler))).Methods("GET")
+
+	// swagger:operation DELETE /api/v1/imports/profiles/{id} Imports profile
+	//
+	// Deletes an import profile
+	// ---
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: content-type
+	//   in: headers
+	//   description: application/json
+	//   required: true
+	// - name: id
+	//   in: id
+	//   description: import profile id
+	//   required: true
+	// responses:
+	//   '200':
+	//     description: deleted import profile
+	//     examples:
+	//       application/json: { "message": "deleted import profile '<PROFILE_ID>'" }
+	//     type: json
+	//   '404':
+	//     description: import profile not found
+	//     examples:
+	//       application/json: { "message": "could not delete import profile", "details": "non existent import profile" }
+	//     type: json
+	router.Handle("/api/v1/imports/profiles/{id}", m.JSON(m.Auth(h.DeleteImportProfileHandler))).Methods("DELETE")
+
+	// swagger:operation POST /api/v1/imports/{owner_id}/csv Imports CSV
+	//
+	// Imports spends from a bank statement CSV mapped by an import profile. Spends already registered
+	// (same description, cost and day) are skipped. Dry runs only preview the parsed spends. Large files
+	// are imported in background and their job status can be followed at the returned location
+	// ---
+	// consumes:
+	// - multipart/form-data
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: content-type
+	//   in: headers
+	//   description: multipart/form-data
+	//   required: true
+	// - name: owner_id
+	//   in: owner_id
+	//   description: owner id
+	//   required: true
+	// - name: file
+	//   in: formData
+	//   type: file
+	//   description: bank statement CSV
+	//   required: true
+	// - name: profile_id
+	//   in: formData
+	//   description: import profile id
+	//   required: true
+	// - name: dry_run
+	//   in: formData
+	//   description: only preview the parsed spends
+	// responses:
+	//   '200':
+	//     description: dry run preview
+	//     schema:
+	//       "$ref": "#/definitions/ImportJob"
+	//   '201':
+	//     description: imported spends
+	//     schema:
+	//       "$ref": "#/definitions/ImportJob"
+	//   '202':
+	//     description: import started in background
+	//     schema:
+	//       "$ref": "#/definitions/ImportJob"
+	//   '400':
+	//     description: bad request
+	//     examples:
+	//       application/json: { "message": "could not import file", "details": "missing column '<COLUMN>'" }
+	//     type: json
+	router.Handle("/api/v1/imports/{owner_id}/csv", m.Multipart(m.Auth(h.ImportCSVHandler))).Methods("POST")
+
+	// swagger:operation POST /api/v1/imports/{owner_id}/ofx Imports OFX
+	//
+	// Imports spends and incomes from an OFX (SGML or XML) bank or credit card statement. Debits are
+	// imported as spends and credits as incomes, except for credit card statements where credits are
+	// skipped. Credit card statements must be imported with the card they belong to. Transactions already
+	// imported are told apart by their account and FITID
+	// ---
+	// consumes:
+	// - multipart/form-data
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: content-type
+	//   in: headers
+	//   description: multipart/form-data
+	//   required: true
+	// - name: owner_id
+	//   in: owner_id
+	//   description: owner id
+	//   required: true
+	// - name: file
+	//   in: formData
+	//   type: file
+	//   description: OFX statement
+	//   required: true
+	// - name: card_id
+	//   in: formData
+	//   description: card paying the imported spends, the debit account when not informed. Required for credit card statements
+	// - name: timezone
+	//   in: formData
+	//   description: timezone of the statement dates, UTC when not informed
+	// - name: dry_run
+	//   in: formData
+	//   description: only preview the parsed transactions
+	// responses:
+	//   '200':
+	//     description: dry run preview
+	//     schema:
+	//       "$ref": "#/definitions/ImportJob"
+	//   '201':
+	//     description: imported transactions
+	//     schema:
+	//       "$ref": "#/definitions/ImportJob"
+	//   '202':
+	//     description: import started in background
+	//     schema:
+	//       "$ref": "#/definitions/ImportJob"
+	//   '400':
+	//     description: bad request
+	//     examples:
+	//       application/json: { "message": "could not import file", "details": "not an OFX file" }
+	//     type: json
+	router.Handle("/api/v1/imports/{owner_id}/ofx", m.Multipart(m.Auth(h.ImportOFXHandler))).Methods("POST")
+
+	// swagger:operation POST /api/v1/imports/{owner_id}/qif Imports QIF
+	//
+	// Imports spends and incomes from a QIF bank or credit card statement. Debits are imported as spends
+	// and credits as incomes, except for credit card statements where credits are skipped. Credit card
+	// statements must be imported with the card they belong to. Transactions already imported (same
+	// description, amount and day) are skipped
+	// ---
+	// consumes:
+	// - multipart/form-data
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: content-type
+	//   in: headers
+	//   description: multipart/form-data
+	//   required: true
+	// - name: owner_id
+	//   in: owner_id
+	//   description: owner id
+	//   required: true
+	// - name: file
+	//   in: formData
+	//   type: file
+	//   description: QIF statement
+	//   required: true
+	// - name: card_id
+	//   in: formData
+	//   description: card paying the imported spends, the debit account when not informed. Required for credit card statements
+	// - name: timezone
+	//   in: formData
+	//   description: timezone of the statement dates, UTC when not informed
+	// - name: date_format
+	//   in: formData
+	//   description: format of the statement dates, DD/MM/YYYY when not informed
+	// - name: dry_run
+	//   in: formData
+	//   description: only preview the parsed transactions
+	// responses:
+	//   '200':
+	//     description: dry run preview
+	//     schema:
+	//       "$ref": "#/definitions/ImportJob"
+	//   '201':
+	//     description: imported transactions
+	//     schema:
+	//       "$ref": "#/definitions/ImportJob"
+	//   '202':
+	//     description: import started in background
+	//     schema:
+	//       "$ref": "#/definitions/ImportJob"
+	//   '400':
+	//     description: bad request
+	//     examples:
+	//       application/json: { "message": "could not import file", "details": "not a QIF file" }
+	//     type: json
+	router.Handle("/api/v1/imports/{owner_id}/qif", m.Multipart(m.Auth(h.ImportQIFHandler))).Methods("POST")
+
+	// swagger:operation GET /api/v1/imports/jobs/{id} Imports job
+	//
+	// Returns the status of an import job
+	// ---
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: content-type
+	//   in: headers
+	//   description: application/json
+	//   required: true
+	// - name: id
+	//   in: id
+	//   description: import job id
+	//   required: true
+	// responses:
+	//   '200':
+	//     description: import job response
+	//     schema:
+	//       "$ref": "#/definitions/ImportJob"
+	//   '404':
+	//     description: import job not found
+	//     examples:
+	//       application/json: { "message": "could not get import job", "details": "non existent import job" }
+	//     type: json
+	router.Handle("/api/v1/imports/jobs/{id}", m.JSON(m.Auth(h.GetImportJobHandler))).Methods("GET")
+
+	// swagger:operation GET /api/v1/exports/{owner_id}/spends Exports spends
+	//
+	// Exports spends from a given owner as a CSV or XLSX download. Spends are filtered by the same params of
+	// spends listing and by balance period, and are streamed oldest first unless sorted otherwise. Only
+	// spends booked to balances are exported, so installment plans and split spends are exported as their
+	// installments and parts instead of at their full cost
+	// ---
+	// produces:
+	// - text/csv
+	// - application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+	// parameters:
+	// - name: owner_id
+	//   in: owner_id
+	//   description: owner id
+	//   required: true
+	// - name: format
+	//   in: query
+	//   description: csv (default) or xlsx
+	// - name: month
+	//   in: query
+	//   description: balance month spends belong to
+	// - name: year
+	//   in: query
+	//   description: balance year spends belong to
+	// - name: from
+	//   in: query
+	//   description: spends made from date
+	// - name: to
+	//   in: query
+	//   description: spends made until date
+	// - name: timezone
+	//   in: query
+	//   description: timezone of plain from and to dates (defaults to UTC)
+	// - name: category
+	//   in: query
+	//   description: category names
+	// - name: tag
+	//   in: query
+	//   description: spend tags
+	// - name: type
+	//   in: query
+	//   description: spend type
+	// - name: payment_method
+	//   in: query
+	//   description: credit, debit or payment_slip
+	// - name: card_id
+	//   in: query
+	//   description: card id
+	// - name: sort
+	//   in: query
+	//   description: date, cost or created_at, a '-' prefix means descending order
+	// responses:
+	//   '200':
+	//     description: spends export
+	//   '400':
+	//     description: bad request
+	//     examples:
+	//       application/json: { "message": "could not export spends", "details": "invalid 'format' value" }
+	//     type: json
+	router.Handle("/api/v1/exports/{owner_id}/spends", m.Auth(h.ExportSpendsHandler)).Methods("GET")
+
+	// swagger:operation GET /api/v1/exports/{owner_id}/balances Exports balances
+	//
+	// Exports balances from a given owner as a CSV or XLSX download
+	// ---
+	// produces:
+	// - text/csv
+	// - application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+	// parameters:
+	// - name: owner_id
+	//   in: owner_id
+	//   description: owner id
+	//   required: true
+	// - name: format
+	//   in: query
+	//   description: csv (default) or xlsx
+	// - name: year
+	//   in: query
+	//   description: only balances from year
+	// responses:
+	//   '200':
+	//     description: balances export
+	//   '400':
+	//     description: bad request
+	//     examples:
+	//       application/json: { "message": "could not export balances", "details": "invalid 'format' value" }
+	//     type: json
+	router.Handle("/api/v1/exports/{owner_id}/balances", m.Auth(h.ExportBalancesHandler)).Methods("GET")
+
+	// swagger:operation GET /api/v1/exports/{owner_id}/statement Exports statement
+	//
+	// Exports the monthly statement of a balance as a PDF download, summarizing its incomes and outcomes
+	// and listing its spends
+	// ---
+	// produces:
+	// - application/pdf
+	// parameters:
+	// - name: owner_id
+	//   in: owner_id
+	//   description: owner id
+	//   required: true
+	// - name: month
+	//   in: query
+	//   description: balance month
+	//   required: true
+	// - name: year
+	//   in: query
+	//   description: balance year
+	//   required: true
+	// responses:
+	//   '200':
+	//     description: monthly statement
+	//   '404':
+	//     description: balance not found
+	//     examples:
+	//       application/json: { "message": "could not export statement", "details": "non existent balance" }
+	//     type: json
+	router.Handle("/api/v1/exports/{owner_id}/statement", m.Auth(h.ExportStatementHandler)).Methods("GET")
+
+	// swagger:operation POST /api/v1/spends/{id}/attachments Attachments upload
+	//
+	// Attaches a file, such as a receipt photo, to a spend. Files must be JPEG, PNG, WebP or GIF images or
+	// PDF documents, detected from their contents, with at most 10MB
+	// ---
+	// consumes:
+	// - multipart/form-data
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: content-type
+	//   in: headers
+	//   description: multipart/form-data
+	//   required: true
+	// - name: id
+	//   in: id
+	//   description: spend id
+	//   required: true
+	// - name: file
+	//   in: formData
+	//   type: file
+	//   description: attached file
+	//   required: true
+	// responses:
+	//   '201':
+	//     description: attached file
+	//     examples:
+	//       application/json: { "message": "attached file to spend '<SPEND_ID>'", "id": "<ATTACHMENT_ID>" }
+	//     type: json
+	//   '404':
+	//     description: spend not found
+	//     examples:
+	//       application/json: { "message": "could not attach file", "details": "non existent spend" }
+	//     type: json
+	//   '413':
+	//     description: file too large
+	//     examples:
+	//       application/json: { "message": "could not attach file", "details": "attachments must have at most 10MB" }
+	//     type: json
+	//   '415':
+	//     description: unsupported file type
+	//     examples:
+	//       application/json: { "message": "could not attach file", "details": "attachments must be JPEG, PNG, WebP or GIF images or PDF documents" }
+	//     type: json
+	router.Handle("/api/v1/spends/{id}/attachments", m.Multipart(m.Auth(h.UploadAttachmentHandler))).Methods("POST")
+
+	// swagger:operation GET /api/v1/spends/{id}/attachments Attachments list
+	//
+	// List all attachments from a spend
+	// ---
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: content-type
+	//   in: headers
+	//   description: application/json
+	//   required: true
+	// - name: id
+	//   in: id
+	//   description: spend id
+	//   required: true
+	// responses:
+	//   '200':
+	//     description: attachments response
+	//     schema:
+	//       type: array
+	//       items:
+	//         "$ref": "#/definitions/Attachment"
+	//   '500':
+	//     description: internal server error
+	//     examples:
+	//       application/json: {"message": "<ERROR_DETAILS>"}
+	//     type: json
+	router.Handle("/api/v1/spends/{id}/attachments", m.JSON(m.Auth(h.GetAttachmentsHandler))).Methods("GET")
+
+	// swagger:operation GET /api/v1/attachments/{id} Attachments download
+	//
+	// Downloads the file of an attachment
+	// ---
+	// produces:
+	// - image/jpeg
+	// - image/png
+	// - image/webp
+	// - image/gif
+	// - application/pdf
+	// parameters:
+	// - name: id
+	//   in: id
+	//   description: attachment id
+	//   required: true
+	// responses:
+	//   '200':
+	//     description: attachment file
+	//   '404':
+	//     description: attachment not found
+	//     examples:
+	//       application/json: { "message": "could not download attachment", "details": "non existent attachment" }
+	//     type: json
+	router.Handle("/api/v1/attachments/{id}", m.Auth(h.DownloadAttachmentHandler)).Methods("GET")
+
+	// swagger:operation DELETE /api/v1/attachments/{id} Attachments delete
+	//
+	// Deletes an attachment along with its file
+	// ---
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: content-type
+	//   in: headers
+	//   description: application/json
+	//   required: true
+	// - name: id
+	//   in: id
+	//   description: attachment id
+	//   required: true
+	// responses:
+	//   '200':
+	//     description: deleted attachment
+	//     examples:
+	//       application/json: { "message": "deleted attachment '<ATTACHMENT_ID>'" }
+	//     type: json
+	//   '404':
+	//     description: attachment not found
+	//     examples:
+	//       application/json: { "message": "could not delete attachment", "details": "non existent attachment" }
+	//     type: json
+	router.Handle("/api/v1/attachments/{id}", m.JSON(m.Auth(h.DeleteAttachmentHandler))).Methods("DELETE")
+
+	// swagger:operation POST /api/v1/refunds Refunds create
+	//
+	// Creates a refund from the merchant or a reimbursement from someone else for a spend. Refunds are
+	// credited right away to the balance of the refund month, or of the card statement for credit spends,
+	// reducing its outcome. Reimbursements stay pending until received, unless created as received. A spend
+	// can't be refunded more than its cost
+	// ---
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: content-type
+	//   in: headers
+	//   description: application/json
+	//   required: true
+	// - name: body
+	//   in: body
+	//   description: refund
+	//   required: true
+	//   schema:
+	//     "$ref": "#/definitions/Refund"
+	// responses:
+	//   '201':
+	//     description: created refund
+	//     examples:
+	//       application/json: { "message": "created reimbursement for spend '<SPEND_ID>'", "id": "<REFUND_ID>" }
+	//     type: json
+	//   '400':
+	//     description: bad request
+	//     examples:
+	//       application/json: { "message": "could not create refund", "details": "non existent or closed balance for month <MONTH>/<YEAR>" }
+	//     type: json
+	//   '409':
+	//     description: closed balance
+	//     examples:
+	//       application/json: { "message": "could not create refund", "details": "balance is closed" }
+	//     type: json
+	//   '422':
+	//     description: invalid refund attributes
+	//     schema:
+	//       "$ref": "#/definitions/ValidationErrors"
+	router.Handle("/api/v1/refunds", m.JSON(m.Auth(h.CreateRefundHandler))).Methods("POST")
+
+	// swagger:operation GET /api/v1/refunds/{owner_id} Refunds list
+	//
+	// List the refunds and reimbursements from a given owner, newest first
+	// ---
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: content-type
+	//   in: headers
+	//   description: application/json
+	//   required: true
+	// - name: owner_id
+	//   in: owner_id
+	//   description: owner id
+	//   required: true
+	// - name: status
+	//   in: query
+	//   description: pending or received
+	// - name: kind
+	//   in: query
+	//   description: refund or reimbursement
+	// - name: spend_id
+	//   in: query
+	//   description: only refunds from this spend
+	// responses:
+	//   '200':
+	//     description: refunds response
+	//     schema:
+	//       type: array
+	//       items:
+	//         "$ref": "#/definitions/Refund"
+	//   '500':
+	//     description: internal server error
+	//     examples:
+	//       application/json: { "message": "could not get refunds", "details": "<ERROR_DETAILS>" }
+	//     type: json
+	router.Handle("/api/v1/refunds/{owner_id}", m.JSON(m.Auth(h.GetRefundsHandler))).Methods("GET")
+
+	// swagger:operation GET /api/v1/refunds/{owner_id}/receivables Refunds receivables
+	//
+	// List the pending reimbursements from a given owner by expected date, along with their total and how
+	// much of it is overdue
+	// ---
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: content-type
+	//   in: headers
+	//   description: application/json
+	//   required: true
+	// - name: owner_id
+	//   in: owner_id
+	//   description: owner id
+	//   required: true
+	// responses:
+	//   '200':
+	//     description: receivables response
+	//     schema:
+	//       "$ref": "#/definitions/Receivables"
+	//   '500':
+	//     description: internal server error
+	//     examples:
+	//       application/json: { "message": "could not get receivables", "details": "<ERROR_DETAILS>" }
+	//     type: json
+	router.Handle("/api/v1/refunds/{owner_id}/receivables", m.JSON(m.Auth(h.GetReceivablesHandler))).Methods("GET")
+
+	// swagger:operation POST /api/v1/refunds/{id}/receive Refunds receive
+	//
+	// Marks a pending reimbursement as received, crediting it to the balance of the month it was received
+	// ---
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: content-type
+	//   in: headers
+	//   description: application/json
+	//   required: true
+	// - name: id
+	//   in: id
+	//   description: refund id
+	//   required: true
+	// - name: date
+	//   in: query
+	//   description: when it was received (RFC3339 or YYYY-MM-DD), defaults to now
+	// - name: timezone
+	//   in: query
+	//   description: timezone of a plain date (defaults to UTC)
+	// responses:
+	//   '200':
+	//     description: received refund
+	//     schema:
+	//       "$ref": "#/definitions/Refund"
+	//   '404':
+	//     description: refund not found
+	//     examples:
+	//       application/json: { "message": "could not receive refund", "details": "non existent refund" }
+	//     type: json
+	//   '409':
+	//     description: already received or closed balance
+	//     examples:
+	//       application/json: { "message": "could not receive refund", "details": "refund was already received" }
+	//     type: json
+	router.Handle("/api/v1/refunds/{id}/receive", m.JSON(m.Auth(h.ReceiveRefundHandler))).Methods("POST")
+
+	// swagger:operation DELETE /api/v1/refunds/{id} Refunds delete
+	//
+	// Deletes a pending reimbursement. Received refunds were already credited and can't be deleted
+	// ---
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: content-type
+	//   in: headers
+	//   description: application/json
+	//   required: true
+	// - name: id
+	//   in: id
+	//   description: refund id
+	//   required: true
+	// responses:
+	//   '200':
+	//     description: deleted refund
+	//     examples:
+	//       application/json: { "message": "deleted refund '<REFUND_ID>'" }
+	//     type: json
+	//   '404':
+	//     description: refund not found
+	//     examples:
+	//       application/json: { "message": "could not delete refund", "details": "non existent refund" }
+	//     type: json
+	//   '409':
+	//     description: received refund
+	//     examples:
+	//       application/json: { "message": "could not delete refund", "details": "refund was already received" }
+	//     type: json
+	router.Handle("/api/v1/refunds/{id}", m.JSON(m.Auth(h.DeleteRefundHandler))).Methods("DELETE")
+
+	// swagger:operation GET /api/v1/debts/{owner_id} Debts list
+	//
+	// Summarizes who owes whom between a given owner and every user it has unsettled shared spends with,
+	// sorted by how much the other user owes. Only accepted shares are owed
+	// ---
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: content-type
+	//   in: headers
+	//   description: application/json
+	//   required: true
+	// - name: owner_id
+	//   in: owner_id
+	//   description: owner id
+	//   required: true
+	// responses:
+	//   '200':
+	//     description: debts response
+	//     schema:
+	//       type: array
+	//       items:
+	//         "$ref": "#/definitions/Debt"
+	//   '500':
+	//     description: internal server error
+	//     examples:
+	//       application/json: { "message": "could not get debts", "details": "<ERROR_DETAILS>" }
+	//     type: json
+	router.Handle("/api/v1/debts/{owner_id}", m.JSON(m.Auth(h.GetDebtsHandler))).Methods("GET")
+
+	// swagger:operation POST /api/v1/debts/{owner_id}/settle/{user_id} Debts settle
+	//
+	// Settles every unsettled shared spend between a given owner and another user, both ways
+	// ---
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: content-type
+	//   in: headers
+	//   description: application/json
+	//   required: true
+	// - name: owner_id
+	//   in: owner_id
+	//   description: owner id
+	//   required: true
+	// - name: user_id
+	//   in: user_id
+	//   description: the other user id
+	//   required: true
+	// responses:
+	//   '200':
+	//     description: settled debts
+	//     examples:
+	//       application/json: { "message": "settled 3 shared spends with user '<USER_ID>'" }
+	//     type: json
+	//   '500':
+	//     description: internal server error
+	//     examples:
+	//       application/json: { "message": "could not settle debts", "details": "<ERROR_DETAILS>" }
+	//     type: json
+	router.Handle("/api/v1/debts/{owner_id}/settle/{user_id}", m.JSON(m.Auth(h.SettleDebtsHandler))).Methods("POST")
+
+	// swagger:operation GET /api/v1/shares/{owner_id} Shares list
+	//
+	// Lists the shares other users split with a given owner which are waiting to be accepted or declined,
+	// newest first. Shares are only booked and owed once accepted
+	// ---
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: content-type
+	//   in: headers
+	//   description: application/json
+	//   required: true
+	// - name: owner_id
+	//   in: owner_id
+	//   description: owner id
+	//   required: true
+	// responses:
+	//   '200':
+	//     description: pending shares response
+	//     schema:
+	//       type: array
+	//       items:
+	//         "$ref": "#/definitions/ShareRequest"
+	//   '500':
+	//     description: internal server error
+	//     examples:
+	//       application/json: { "message": "could not get shares", "details": "<ERROR_DETAILS>" }
+	//     type: json
+	router.Handle("/api/v1/shares/{owner_id}", m.JSON(m.Auth(h.GetShareRequestsHandler))).Methods("GET")
+
+	// swagger:operation POST /api/v1/shares/{owner_id}/{spend_id}/accept Shares accept
+	//
+	// Accepts a pending share of another user's spend, booking it to the given owner's balance of the spend
+	// month and counting it as owed to the spend owner
+	// ---
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: content-type
+	//   in: headers
+	//   description: application/json
+	//   required: true
+	// - name: owner_id
+	//   in: owner_id
+	//   description: id of the user who owes the share
+	//   required: true
+	// - name: spend_id
+	//   in: spend_id
+	//   description: shared spend id
+	//   required: true
+	// responses:
+	//   '200':
+	//     description: booked share part
+	//     schema:
+	//       "$ref": "#/definitions/Spend"
+	//   '404':
+	//     description: share not found
+	//     examples:
+	//       application/json: { "message": "could not accept share", "details": "non existent share" }
+	//     type: json
+	//   '409':
+	//     description: answered share or closed balance
+	//     examples:
+	//       application/json: { "message": "could not accept share", "details": "share was already answered" }
+	//     type: json
+	//   '500':
+	//     description: internal server error
+	//     examples:
+	//       application/json: { "message": "could not accept share", "details": "<ERROR_DETAILS>" }
+	//     type: json
+	router.Handle("/api/v1/shares/{owner_id}/{spend_id}/accept", m.JSON(m.Auth(h.AcceptShareHandler))).Methods("POST")
+
+	// swagger:operation POST /api/v1/shares/{owner_id}/{spend_id}/decline Shares decline
+	//
+	// Declines a pending share of another user's spend, booking its amount back to the spend owner
+	// ---
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: content-type
+	//   in: headers
+	//   description: application/json
+	//   required: true
+	// - name: owner_id
+	//   in: owner_id
+	//   description: id of the user who owes the share
+	//   required: true
+	// - name: spend_id
+	//   in: spend_id
+	//   description: shared spend id
+	//   required: true
+	// responses:
+	//   '200':
+	//     description: booked share part
+	//     schema:
+	//       "$ref": "#/definitions/Spend"
+	//   '404':
+	//     description: share not found
+	//     examples:
+	//       application/json: { "message": "could not decline share", "details": "non existent share" }
+	//     type: json
+	//   '409':
+	//     description: answered share or closed balance
+	//     examples:
+	//       application/json: { "message": "could not decline share", "details": "share was already answered" }
+	//     type: json
+	//   '500':
+	//     description: internal server error
+	//     examples:
+	//       application/json: { "message": "could not decline share", "details": "<ERROR_DETAILS>" }
+	//     type: json
+	router.Handle("/api/v1/shares/{owner_id}/{spend_id}/decline", m.JSON(m.Auth(h.DeclineShareHandler))).Methods("POST")
 }
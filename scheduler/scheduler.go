@@ -0,0 +1,42 @@
+package scheduler
+
+import (
+	"budget-tracker-api/models"
+	"budget-tracker-api/notifications"
+	"context"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// cardDueNotice defines how long before a card due date its owner is notified
+const cardDueNotice = 3 * 24 * time.Hour
+
+// Run will materialize recurring rules and check card due dates right away and then at every interval
+// until the context is done
+func Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		materialized, err := models.MaterializeRecurringRules(ctx, time.Now())
+		if err != nil {
+			log.Errorln("could not materialize recurring rules:", err)
+		}
+
+		if materialized > 0 {
+			log.Infoln("materialized", materialized, "recurring occurrences")
+		}
+
+		err = notifications.CheckCardDueDates(ctx, time.Now(), cardDueNotice)
+		if err != nil {
+			log.Errorln("could not check card due dates:", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,234 @@
+package taxes
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"strconv"
+	"sync"
+)
+
+// Bracket defines a tax rate applied to values up to a certain limit. A zero limit means no limit,
+// and the deduction is the amount subtracted from the tax (IRRF "parcela a deduzir")
+type Bracket struct {
+	UpTo      float64 `json:"up_to"`
+	Rate      float64 `json:"rate"`
+	Deduction float64 `json:"deduction,omitempty"`
+}
+
+// Reduction defines the IRRF reduction granted by the 2026 income tax reform (Lei 15.270/2025) based on
+// the gross taxable income: up to `full_up_to` the tax is reduced by up to `max`, zeroing it, and up to
+// `partial_up_to` it is reduced by `constant - rate * gross`
+type Reduction struct {
+	FullUpTo    float64 `json:"full_up_to"`
+	Max         float64 `json:"max"`
+	PartialUpTo float64 `json:"partial_up_to"`
+	Constant    float64 `json:"constant"`
+	Rate        float64 `json:"rate"`
+}
+
+// Table defines the INSS and IRRF rules valid for a given year. INSS brackets are progressive, the
+// last one limit being the contribution ceiling
+type Table struct {
+	Year                int        `json:"year"`
+	INSS                []Bracket  `json:"inss"`
+	IRRF                []Bracket  `json:"irrf"`
+	DependentDeduction  float64    `json:"dependent_deduction"`
+	SimplifiedDeduction float64    `json:"simplified_deduction,omitempty"`
+	IRRFReduction       *Reduction `json:"irrf_reduction,omitempty"`
+}
+
+var (
+	mu     sync.RWMutex
+	tables = map[int]Table{
+		2021: {
+			Year: 2021,
+			INSS: []Bracket{
+				{UpTo: 1100.00, Rate: 0.075},
+				{UpTo: 2203.48, Rate: 0.09},
+				{UpTo: 3305.22, Rate: 0.12},
+				{UpTo: 6433.57, Rate: 0.14},
+			},
+			IRRF: []Bracket{
+				{UpTo: 1903.98, Rate: 0},
+				{UpTo: 2826.65, Rate: 0.075, Deduction: 142.80},
+				{UpTo: 3751.05, Rate: 0.15, Deduction: 354.80},
+				{UpTo: 4664.68, Rate: 0.225, Deduction: 636.13},
+				{Rate: 0.275, Deduction: 869.36},
+			},
+			DependentDeduction: 189.59,
+		},
+		2022: {
+			Year: 2022,
+			INSS: []Bracket{
+				{UpTo: 1212.00, Rate: 0.075},
+				{UpTo: 2427.35, Rate: 0.09},
+				{UpTo: 3641.03, Rate: 0.12},
+				{UpTo: 7087.22, Rate: 0.14},
+			},
+			IRRF: []Bracket{
+				{UpTo: 1903.98, Rate: 0},
+				{UpTo: 2826.65, Rate: 0.075, Deduction: 142.80},
+				{UpTo: 3751.05, Rate: 0.15, Deduction: 354.80},
+				{UpTo: 4664.68, Rate: 0.225, Deduction: 636.13},
+				{Rate: 0.275, Deduction: 869.36},
+			},
+			DependentDeduction: 189.59,
+		},
+		2023: {
+			Year: 2023,
+			INSS: []Bracket{
+				{UpTo: 1320.00, Rate: 0.075},
+				{UpTo: 2571.29, Rate: 0.09},
+				{UpTo: 3856.94, Rate: 0.12},
+				{UpTo: 7507.49, Rate: 0.14},
+			},
+			IRRF: []Bracket{
+				{UpTo: 2112.00, Rate: 0},
+				{UpTo: 2826.65, Rate: 0.075, Deduction: 158.40},
+				{UpTo: 3751.05, Rate: 0.15, Deduction: 370.40},
+				{UpTo: 4664.68, Rate: 0.225, Deduction: 651.73},
+				{Rate: 0.275, Deduction: 884.96},
+			},
+			DependentDeduction:  189.59,
+			SimplifiedDeduction: 528.00,
+		},
+		2024: {
+			Year: 2024,
+			INSS: []Bracket{
+				{UpTo: 1412.00, Rate: 0.075},
+				{UpTo: 2666.68, Rate: 0.09},
+				{UpTo: 4000.03, Rate: 0.12},
+				{UpTo: 7786.02, Rate: 0.14},
+			},
+			IRRF: []Bracket{
+				{UpTo: 2259.20, Rate: 0},
+				{UpTo: 2826.65, Rate: 0.075, Deduction: 169.44},
+				{UpTo: 3751.05, Rate: 0.15, Deduction: 381.44},
+				{UpTo: 4664.68, Rate: 0.225, Deduction: 662.77},
+				{Rate: 0.275, Deduction: 896.00},
+			},
+			DependentDeduction:  189.59,
+			SimplifiedDeduction: 564.80,
+		},
+		// IRRF brackets valid since May 2025
+		2025: {
+			Year: 2025,
+			INSS: []Bracket{
+				{UpTo: 1518.00, Rate: 0.075},
+				{UpTo: 2793.88, Rate: 0.09},
+				{UpTo: 4190.83, Rate: 0.12},
+				{UpTo: 8157.41, Rate: 0.14},
+			},
+			IRRF: []Bracket{
+				{UpTo: 2428.80, Rate: 0},
+				{UpTo: 2826.65, Rate: 0.075, Deduction: 182.16},
+				{UpTo: 3751.05, Rate: 0.15, Deduction: 394.16},
+				{UpTo: 4664.68, Rate: 0.225, Deduction: 675.49},
+				{Rate: 0.275, Deduction: 908.73},
+			},
+			DependentDeduction:  189.59,
+			SimplifiedDeduction: 607.20,
+		},
+		// the 2026 reform keeps the 2025 IRRF brackets, adding a reduction for gross incomes up to 7350.00
+		2026: {
+			Year: 2026,
+			INSS: []Bracket{
+				{UpTo: 1621.00, Rate: 0.075},
+				{UpTo: 2902.84, Rate: 0.09},
+				{UpTo: 4354.27, Rate: 0.12},
+				{UpTo: 8475.55, Rate: 0.14},
+			},
+			IRRF: []Bracket{
+				{UpTo: 2428.80, Rate: 0},
+				{UpTo: 2826.65, Rate: 0.075, Deduction: 182.16},
+				{UpTo: 3751.05, Rate: 0.15, Deduction: 394.16},
+				{UpTo: 4664.68, Rate: 0.225, Deduction: 675.49},
+				{Rate: 0.275, Deduction: 908.73},
+			},
+			DependentDeduction:  189.59,
+			SimplifiedDeduction: 607.20,
+			IRRFReduction: &Reduction{
+				FullUpTo:    5000.00,
+				Max:         312.89,
+				PartialUpTo: 7350.00,
+				Constant:    978.62,
+				Rate:        0.133145,
+			},
+		},
+	}
+)
+
+// validateTable will validate if a table brackets are sorted by their limits, with only the last
+// IRRF bracket being unlimited
+func validateTable(t Table) error {
+	year := strconv.Itoa(t.Year)
+
+	if len(t.INSS) == 0 || len(t.IRRF) == 0 {
+		return errors.New("table " + year + " must have INSS and IRRF brackets")
+	}
+
+	for i, b := range t.INSS {
+		if b.UpTo <= 0 || (i > 0 && b.UpTo <= t.INSS[i-1].UpTo) {
+			return errors.New("table " + year + " INSS brackets must have increasing limits")
+		}
+	}
+
+	for i, b := range t.IRRF {
+		last := i == len(t.IRRF)-1
+		if (!last && b.UpTo <= 0) || (last && b.UpTo != 0) || (i > 0 && !last && b.UpTo <= t.IRRF[i-1].UpTo) {
+			return errors.New("table " + year + " IRRF brackets must have increasing limits and an unlimited last one")
+		}
+	}
+
+	if r := t.IRRFReduction; r != nil && (r.FullUpTo <= 0 || r.PartialUpTo < r.FullUpTo || r.Max < 0 || r.Rate < 0) {
+		return errors.New("table " + year + " IRRF reduction must have increasing limits and non negative amounts")
+	}
+
+	return nil
+}
+
+// LoadTables will read a JSON list of tables from a file, adding them to the known tables. Tables from
+// years already known are replaced
+func LoadTables(path string) (err error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	var loaded []Table
+	err = json.Unmarshal(content, &loaded)
+	if err != nil {
+		return err
+	}
+
+	for _, t := range loaded {
+		err = validateTable(t)
+		if err != nil {
+			return err
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	for _, t := range loaded {
+		tables[t.Year] = t
+	}
+
+	return nil
+}
+
+// TableFor will return the table of a given year. Years without a table aren't guessed from other
+// years, since brackets change almost every year
+func TableFor(year int) (t Table, err error) {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	t, ok := tables[year]
+	if !ok {
+		return Table{}, errors.New("no tax table for year " + strconv.Itoa(year))
+	}
+
+	return t, nil
+}
@@ -0,0 +1,111 @@
+package taxes
+
+import (
+	"errors"
+	"math"
+)
+
+// Input defines the attributes used to derive a net salary from a gross one
+type Input struct {
+	// example: 7500.00
+	Gross float64 `json:"gross"`
+	// example: 2024
+	Year int `json:"year"`
+	// example: 1
+	Dependents int `json:"dependents,omitempty"`
+	// deductions from the IRRF base other than INSS and dependents, such as alimony or private pension
+	// example: 300.00
+	OtherDeductions float64 `json:"other_deductions,omitempty"`
+}
+
+// Result defines the taxes withheld from a gross salary and the resulting net salary
+type Result struct {
+	Gross         float64 `json:"gross"`
+	INSS          float64 `json:"inss"`
+	IRRFBase      float64 `json:"irrf_base"`
+	IRRF          float64 `json:"irrf"`
+	IRRFReduction float64 `json:"irrf_reduction,omitempty"`
+	Net           float64 `json:"net"`
+	EffectiveRate float64 `json:"effective_rate"`
+}
+
+// roundCents will round a monetary value to its cents
+func roundCents(value float64) float64 {
+	return math.Round(value*100) / 100
+}
+
+// INSS will compute the progressive INSS contribution from a gross salary, limited to the ceiling
+func INSS(gross float64, t Table) float64 {
+	contribution := 0.0
+	lower := 0.0
+	for _, b := range t.INSS {
+		if gross <= lower {
+			break
+		}
+
+		contribution += (math.Min(gross, b.UpTo) - lower) * b.Rate
+		lower = b.UpTo
+	}
+
+	return roundCents(contribution)
+}
+
+// IRRF will compute the income tax withheld from a taxable base
+func IRRF(base float64, t Table) float64 {
+	for _, b := range t.IRRF {
+		if b.UpTo == 0 || base <= b.UpTo {
+			return roundCents(math.Max(base*b.Rate-b.Deduction, 0))
+		}
+	}
+
+	return 0
+}
+
+// IRRFReduction will compute how much of the income tax is waived for a gross taxable income, which is
+// never more than the tax itself
+func IRRFReduction(gross float64, tax float64, t Table) float64 {
+	r := t.IRRFReduction
+	if r == nil || gross > r.PartialUpTo {
+		return 0
+	}
+
+	reduction := r.Max
+	if gross > r.FullUpTo {
+		reduction = r.Constant - r.Rate*gross
+	}
+
+	return roundCents(math.Min(math.Max(reduction, 0), tax))
+}
+
+// Calculate will derive the net salary from a gross one based on the table of the given year. The IRRF
+// base uses the simplified deduction whenever it's greater than the legal ones, and tables with an IRRF
+// reduction waive part of the tax from lower incomes
+func Calculate(in Input) (r Result, err error) {
+	if in.Gross <= 0 {
+		return Result{}, errors.New("gross must be greater than zero")
+	}
+
+	if in.Dependents < 0 || in.OtherDeductions < 0 {
+		return Result{}, errors.New("dependents and deductions can't be negative")
+	}
+
+	t, err := TableFor(in.Year)
+	if err != nil {
+		return Result{}, err
+	}
+
+	r.Gross = roundCents(in.Gross)
+	r.INSS = INSS(in.Gross, t)
+
+	deductions := r.INSS + float64(in.Dependents)*t.DependentDeduction + in.OtherDeductions
+	deductions = math.Max(deductions, t.SimplifiedDeduction)
+
+	r.IRRFBase = roundCents(math.Max(in.Gross-deductions, 0))
+	r.IRRF = IRRF(r.IRRFBase, t)
+	r.IRRFReduction = IRRFReduction(in.Gross, r.IRRF, t)
+	r.IRRF = roundCents(r.IRRF - r.IRRFReduction)
+	r.Net = roundCents(in.Gross - r.INSS - r.IRRF)
+	r.EffectiveRate = math.Round((r.INSS+r.IRRF)/in.Gross*10000) / 100
+
+	return r, nil
+}
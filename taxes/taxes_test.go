@@ -0,0 +1,138 @@
+package taxes
+
+import "testing"
+
+func mustTable(t *testing.T, year int) Table {
+	t.Helper()
+
+	table, err := TableFor(year)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return table
+}
+
+func TestINSS(t *testing.T) {
+	table := mustTable(t, 2025)
+
+	tests := []struct {
+		gross float64
+		want  float64
+	}{
+		{1000.00, 75.00},
+		{1518.00, 113.85},
+		{2000.00, 157.23},
+		{3000.00, 253.41},
+		{5000.00, 509.60},
+		{8157.41, 951.63},
+		{20000.00, 951.63},
+	}
+
+	for _, tt := range tests {
+		if got := INSS(tt.gross, table); got != tt.want {
+			t.Errorf("INSS(%.2f) = %.2f, want %.2f", tt.gross, got, tt.want)
+		}
+	}
+}
+
+func TestIRRF(t *testing.T) {
+	table := mustTable(t, 2025)
+
+	tests := []struct {
+		base float64
+		want float64
+	}{
+		{2000.00, 0},
+		{2428.80, 0},
+		{2500.00, 5.34},
+		{3000.00, 55.84},
+		{4000.00, 224.51},
+		{5000.00, 466.27},
+	}
+
+	for _, tt := range tests {
+		if got := IRRF(tt.base, table); got != tt.want {
+			t.Errorf("IRRF(%.2f) = %.2f, want %.2f", tt.base, got, tt.want)
+		}
+	}
+}
+
+func TestIRRFReduction(t *testing.T) {
+	tests := []struct {
+		year  int
+		gross float64
+		tax   float64
+		want  float64
+	}{
+		{2026, 4000.00, 114.76, 114.76},
+		{2026, 5000.00, 312.89, 312.89},
+		{2026, 5000.00, 400.00, 312.89},
+		{2026, 6000.00, 564.85, 179.75},
+		{2026, 7350.00, 884.13, 0},
+		{2026, 10000.00, 1569.55, 0},
+		{2025, 4000.00, 114.76, 0},
+	}
+
+	for _, tt := range tests {
+		if got := IRRFReduction(tt.gross, tt.tax, mustTable(t, tt.year)); got != tt.want {
+			t.Errorf("IRRFReduction(%.2f, %.2f) for %d = %.2f, want %.2f", tt.gross, tt.tax, tt.year, got, tt.want)
+		}
+	}
+}
+
+func TestCalculate(t *testing.T) {
+	tests := []struct {
+		name string
+		in   Input
+		want Result
+	}{
+		{"2024 lowest taxed bracket", Input{Gross: 3000, Year: 2024}, Result{Gross: 3000, INSS: 258.82, IRRFBase: 2435.20, IRRF: 13.20, Net: 2727.98, EffectiveRate: 9.07}},
+		{"2025 exempt", Input{Gross: 3000, Year: 2025}, Result{Gross: 3000, INSS: 253.41, IRRFBase: 2392.80, Net: 2746.59, EffectiveRate: 8.45}},
+		{"2025 with dependent", Input{Gross: 7500, Year: 2025, Dependents: 1}, Result{Gross: 7500, INSS: 859.60, IRRFBase: 6450.81, IRRF: 865.24, Net: 5775.16, EffectiveRate: 23}},
+		{"2025 above the INSS ceiling", Input{Gross: 10000, Year: 2025}, Result{Gross: 10000, INSS: 951.63, IRRFBase: 9048.37, IRRF: 1579.57, Net: 7468.80, EffectiveRate: 25.31}},
+		{"2026 fully reduced", Input{Gross: 5000, Year: 2026}, Result{Gross: 5000, INSS: 501.51, IRRFBase: 4392.80, IRRFReduction: 312.89, Net: 4498.49, EffectiveRate: 10.03}},
+		{"2026 partially reduced", Input{Gross: 6000, Year: 2026}, Result{Gross: 6000, INSS: 641.51, IRRFBase: 5358.49, IRRF: 385.10, IRRFReduction: 179.75, Net: 4973.39, EffectiveRate: 17.11}},
+		{"2026 without reduction", Input{Gross: 10000, Year: 2026}, Result{Gross: 10000, INSS: 988.09, IRRFBase: 9011.91, IRRF: 1569.55, Net: 7442.36, EffectiveRate: 25.58}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Calculate(tt.in)
+			if err != nil {
+				t.Fatalf("Calculate() error = %v", err)
+			}
+
+			if got != tt.want {
+				t.Errorf("Calculate() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCalculateInvalidInput(t *testing.T) {
+	inputs := []Input{
+		{Gross: 0, Year: 2025},
+		{Gross: 5000, Year: 2025, Dependents: -1},
+		{Gross: 5000, Year: 2025, OtherDeductions: -100},
+		{Gross: 5000, Year: 2020},
+		{Gross: 5000, Year: 2030},
+	}
+
+	for _, in := range inputs {
+		if _, err := Calculate(in); err == nil {
+			t.Errorf("Calculate(%+v) should fail", in)
+		}
+	}
+}
+
+func TestTablesAreValid(t *testing.T) {
+	for year, table := range tables {
+		if table.Year != year {
+			t.Errorf("table %d has year %d", year, table.Year)
+		}
+
+		if err := validateTable(table); err != nil {
+			t.Error(err)
+		}
+	}
+}